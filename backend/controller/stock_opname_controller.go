@@ -171,7 +171,7 @@ func (h *StockOpnameController) CreateDraft(ctx *gin.Context) {
 		return
 	}
 	fmt.Println("Request payload:", utils.GetCurrentUserID(ctx))
-	opname, err := h.service.CreateDraft(strconv.FormatUint(uint64(utils.GetCurrentUserID(ctx)), 10), req.Tanggal, req.Catatan)
+	opname, err := h.service.CreateDraft(strconv.FormatUint(uint64(utils.GetCurrentUserID(ctx)), 10), req.Tanggal, req.Catatan, opname.MovementPolicy(req.MovementPolicy))
 	if err != nil {
 		utils.Respond(ctx, http.StatusInternalServerError, "Error", err.Error(), nil)
 		return
@@ -298,7 +298,7 @@ func (h *StockOpnameController) UpdateDraft(c *gin.Context) {
 		return
 	}
 
-	opname, err := h.service.UpdateDraft(opnameID, req.Tanggal, req.Catatan)
+	opname, err := h.service.UpdateDraft(opnameID, req.Tanggal, req.Catatan, opname.MovementPolicy(req.MovementPolicy))
 	if err != nil {
 		utils.Respond(c, http.StatusInternalServerError, "Error", err.Error(), nil)
 		return
@@ -413,3 +413,22 @@ func (c *StockOpnameController) GetProducts(ctx *gin.Context) {
 	}
 	utils.Respond(ctx, http.StatusOK, "Suksess", nil, products)
 }
+
+// GetOpnameReport returns the signed stock opname report (berita acara) as JSON or PDF
+func (h *StockOpnameController) GetOpnameReport(c *gin.Context) {
+	opnameID := c.Param("opnameID")
+
+	report, err := h.service.GetOpnameReport(opnameID)
+	if err != nil {
+		utils.Respond(c, http.StatusBadRequest, "Error", err.Error(), nil)
+		return
+	}
+
+	if c.Query("format") == "pdf" {
+		c.Header("Content-Disposition", fmt.Sprintf("inline; filename=berita-acara-%s.pdf", report.OpnameID))
+		c.Data(http.StatusOK, "application/pdf", service.RenderOpnameReportPDF(report))
+		return
+	}
+
+	utils.Respond(c, http.StatusOK, "Suksess", nil, report)
+}
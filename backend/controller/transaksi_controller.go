@@ -28,7 +28,6 @@ func (c *TransaksiController) CreateTransaksi(ctx *gin.Context) {
 	}
 	// Mapping DTO to model
 	transaksi := &model.Transaksi{
-		NomorTransaksi:   model.GenerateNomorTransaksi(),
 		ObatID:           input.ObatID,
 		JumlahObat:       input.JumlahObat,
 		TanggalPembelian: time.Now(),
@@ -7,13 +7,15 @@ import (
 )
 
 type CreateDraftRequest struct {
-	Tanggal DateOnly `json:"opname_date" binding:"required"`
-	Catatan string   `json:"notes"`
+	Tanggal        DateOnly `json:"opname_date" binding:"required"`
+	Catatan        string   `json:"notes"`
+	MovementPolicy string   `json:"movement_policy" binding:"omitempty,oneof=reconcile freeze"`
 }
 
 type UpdateDraftRequest struct {
-	Tanggal DateOnly `json:"opname_date" binding:"required"`
-	Catatan string   `json:"notes"`
+	Tanggal        DateOnly `json:"opname_date" binding:"required"`
+	Catatan        string   `json:"notes"`
+	MovementPolicy string   `json:"movement_policy" binding:"omitempty,oneof=reconcile freeze"`
 }
 
 type DateOnly struct {
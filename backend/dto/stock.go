@@ -33,6 +33,8 @@ type StockDiscrepancy struct {
 	ActualStock           int       `json:"actual_stock"`
 	Discrepancy           int       `json:"discrepancy"`
 	DiscrepancyPercentage float64   `json:"discrepancy_percentage"`
+	VarianceCost          float64   `json:"variance_cost"`
+	VarianceSelling       float64   `json:"variance_selling"`
 	Flag                  string    `json:"flag"`
 	OpnameDate            time.Time `json:"opname_date"`
 	PerformedBy           string    `json:"performed_by"`
@@ -74,6 +76,9 @@ type StockOpnameDetailResponse struct {
 	Adjustment_note        string        `json:"adjustment_note"`
 	Performed_by           string        `json:"performed_by"`
 	Performed_at           time.Time     `json:"performed_at"`
+	SnapshotAt             *time.Time    `json:"snapshot_at"`
+	MovementSinceSnapshot  int           `json:"movement_since_snapshot"`
+	FinalStock             int           `json:"final_stock"`
 	Product                ProductSimple `json:"product"`
 }
 
@@ -85,7 +90,47 @@ type StockOpnameResponse struct {
 	Status          string                      `json:"status"`
 	Notes           string                      `json:"notes"`
 	JenisStokOpname string                      `json:"jenis_stok_opname"`
+	MovementPolicy  string                      `json:"movement_policy"`
 	FlagActive      bool                        `json:"FlagActive"`
 	CreatedBy       string                      `json:"created_by"`
 	Details         []StockOpnameDetailResponse `json:"details"`
 }
+
+// DTO untuk berita acara stock opname
+type StockOpnameReportLine struct {
+	ProductID       uint    `json:"product_id"`
+	ProductCode     string  `json:"product_code"`
+	ProductName     string  `json:"product_name"`
+	Category        string  `json:"category"`
+	SystemStock     int     `json:"system_stock"`
+	ActualStock     int     `json:"actual_stock"`
+	Discrepancy     int     `json:"discrepancy"`
+	CostPrice       float64 `json:"cost_price"`
+	SellingPrice    float64 `json:"selling_price"`
+	VarianceCost    float64 `json:"variance_cost"`
+	VarianceSelling float64 `json:"variance_selling"`
+	Note            string  `json:"note"`
+	PerformedBy     string  `json:"performed_by"`
+}
+
+type StockOpnameCategoryVariance struct {
+	Category        string  `json:"category"`
+	ProductCount    int     `json:"product_count"`
+	VarianceCost    float64 `json:"variance_cost"`
+	VarianceSelling float64 `json:"variance_selling"`
+}
+
+type StockOpnameReport struct {
+	OpnameID             string                        `json:"opname_id"`
+	OpnameDate           time.Time                     `json:"opname_date"`
+	StartTime            time.Time                     `json:"start_time"`
+	EndTime              time.Time                     `json:"end_time"`
+	Notes                string                        `json:"notes"`
+	Counters             []string                      `json:"counters"`
+	Approver             string                        `json:"approver"`
+	Lines                []StockOpnameReportLine       `json:"lines"`
+	Categories           []StockOpnameCategoryVariance `json:"categories"`
+	TotalVarianceCost    float64                       `json:"total_variance_cost"`
+	TotalVarianceSelling float64                       `json:"total_variance_selling"`
+	ShrinkagePercent     float64                       `json:"shrinkage_percent"`
+}
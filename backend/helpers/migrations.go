@@ -5,24 +5,40 @@ import (
 	"go-gin-auth/internal/adjustment"
 	"go-gin-auth/internal/brand"
 	"go-gin-auth/internal/category"
+	"go-gin-auth/internal/customer"
+	"go-gin-auth/internal/dispensing"
 	"go-gin-auth/internal/doctor"
 	"go-gin-auth/internal/drug_category"
 	"go-gin-auth/internal/expense"
 	"go-gin-auth/internal/expense_type"
+	"go-gin-auth/internal/idempotency"
 	"go-gin-auth/internal/incomingProducts"
+	"go-gin-auth/internal/interaction"
+	"go-gin-auth/internal/ledger"
+	"go-gin-auth/internal/narcotic"
 	"go-gin-auth/internal/nonpbf"
+	"go-gin-auth/internal/numbering"
 	"go-gin-auth/internal/opname"
 	"go-gin-auth/internal/outgoingProducts"
 	"go-gin-auth/internal/patient"
+	"go-gin-auth/internal/payment"
 	"go-gin-auth/internal/pbf"
+	"go-gin-auth/internal/pharmacy"
+	"go-gin-auth/internal/pos"
 	"go-gin-auth/internal/prescription"
 	"go-gin-auth/internal/product"
+	"go-gin-auth/internal/promotion"
+	"go-gin-auth/internal/receipt"
+	"go-gin-auth/internal/receivable"
+	"go-gin-auth/internal/register"
+	"go-gin-auth/internal/sale_void"
 	"go-gin-auth/internal/sales"
 	"go-gin-auth/internal/shift"
 	"go-gin-auth/internal/stock"
 	"go-gin-auth/internal/stock_correction"
 	storagelocation "go-gin-auth/internal/storage_location"
 	"go-gin-auth/internal/supplier"
+	"go-gin-auth/internal/tax"
 	"go-gin-auth/internal/unit"
 	"go-gin-auth/model"
 	"go-gin-auth/service"
@@ -31,6 +47,12 @@ import (
 func MigrateDB() error {
 	db := config.DB
 
+	if db.Dialector.Name() != "sqlite" {
+		if err := ledger.DropView(db); err != nil {
+			return err
+		}
+	}
+
 	err := db.AutoMigrate(
 		&model.User{},
 		&model.ActivityLog{},
@@ -53,14 +75,40 @@ func MigrateDB() error {
 		&doctor.Doctor{},
 		&patient.Patient{},
 		&drug_category.DrugCategory{},
+		&register.Register{},
 		&shift.Shift{},
+		&shift.CashMovement{},
+		&shift.CashCount{},
 		&stock_correction.StockCorrection{},
 		&pbf.IncomingPBF{}, &pbf.IncomingPBFDetail{},
 		&nonpbf.IncomingNonPBF{}, &nonpbf.IncomingNonPBFDetail{},
 		&prescription.PrescriptionSale{},
 		&prescription.PrescriptionItem{},
+		&prescription.PrescriptionCompound{},
+		&prescription.PrescriptionCompoundComponent{},
 		&sales.SalesRegular{},
 		&sales.SalesRegularItem{},
+		&sales.HeldSale{},
+		&sales.HeldSaleItem{},
+		&sale_void.Void{},
+		&payment.Payment{},
+		&receipt.PrintLog{},
+		&pharmacy.Profile{},
+		&promotion.Promotion{},
+		&promotion.Application{},
+		&customer.Customer{},
+		&customer.PointEntry{},
+		&receivable.Receivable{},
+		&receivable.Payment{},
+		&tax.Rate{},
+		&numbering.Sequence{},
+		&numbering.Counter{},
+		&idempotency.Record{},
+		&pos.OfflineSale{},
+		&interaction.Rule{},
+		&interaction.Override{},
+		&narcotic.Entry{},
+		&dispensing.Override{},
 		&adjustment.StockAdjustment{},
 		&expense_type.ExpenseType{},
 		&expense.Expense{},
@@ -113,6 +161,9 @@ func MigrateDB() error {
 	}
 
 	if db.Dialector.Name() != "sqlite" {
+		if err := ledger.Migrate(db); err != nil {
+			return err
+		}
 		if db.Migrator().HasColumn(&product.Product{}, "storage_location") {
 			if !db.Migrator().HasColumn(&product.Product{}, "StorageLocationID") {
 				err = db.Migrator().AddColumn(&product.Product{}, "StorageLocationID")
@@ -3,8 +3,7 @@ package analysis
 import (
 	"fmt"
 	"go-gin-auth/internal/expense"
-	"go-gin-auth/internal/prescription"
-	"go-gin-auth/internal/sales"
+	"go-gin-auth/internal/ledger"
 	"time"
 
 	"gorm.io/gorm"
@@ -30,28 +29,26 @@ func NewRepository(db *gorm.DB) Repository {
 // GetTotalRevenue gets total revenue from prescription and regular sales
 // for a given date range.
 func (r *repository) GetTotalRevenue(start, end time.Time) ([]revenueDetail, error) {
-	var results []revenueDetail
-	var prescriptionTotal float64
-	err := r.db.Model(&prescription.PrescriptionSale{}).
-		Where("transaction_date BETWEEN ? AND ?", start, end).
-		Select("COALESCE(SUM(total_amount), 0)").
-		Row().Scan(&prescriptionTotal)
-	if err != nil {
-		return nil, fmt.Errorf("gagal query penjualan resep: %w", err)
+	var totals []struct {
+		SaleType string
+		Total    float64
 	}
-	results = append(results, revenueDetail{Source: "Penjualan Resep", Total: prescriptionTotal})
-
-	var regularTotal float64
-	err = r.db.Model(&sales.SalesRegular{}).
-		Where("transaction_date BETWEEN ? AND ?", start, end).
-		Select("COALESCE(SUM(total_pay), 0)").
-		Row().Scan(&regularTotal)
+	err := ledger.Query(r.db, ledger.Filter{Start: &start, End: &end}).
+		Select("sale_type, COALESCE(SUM(total), 0) AS total").
+		Group("sale_type").
+		Scan(&totals).Error
 	if err != nil {
-		return nil, fmt.Errorf("gagal query penjualan reguler: %w", err)
+		return nil, fmt.Errorf("gagal query buku besar penjualan: %w", err)
 	}
-	results = append(results, revenueDetail{Source: "Penjualan Reguler", Total: regularTotal})
 
-	return results, nil
+	byType := map[string]float64{}
+	for _, t := range totals {
+		byType[t.SaleType] = t.Total
+	}
+	return []revenueDetail{
+		{Source: "Penjualan Resep", Total: byType["prescription"]},
+		{Source: "Penjualan Reguler", Total: byType["regular"]},
+	}, nil
 }
 
 // GetExpenseBreakdown gets the total expense for each expense type within a given date range.
@@ -93,24 +90,11 @@ func getDateFormatString(db *gorm.DB, interval string) string {
 // The function returns an error if the query execution fails.
 func (r *repository) GetRevenueTimeline(start, end time.Time, interval string) ([]timelineQueryResult, error) {
 	var results []timelineQueryResult
-	dateCol := "transaction_date"
-	query := r.db.Raw(`
-        SELECT T.date, SUM(T.value) as value
-        FROM (
-            SELECT DATE(`+dateCol+`) as date, SUM(total_amount) as value
-            FROM prescription_sales
-            WHERE `+dateCol+` BETWEEN ? AND ? AND deleted_at IS NULL
-            GROUP BY DATE(`+dateCol+`)
-            UNION ALL
-            SELECT DATE(`+dateCol+`) as date, SUM(total_pay) as value
-            FROM sales_regulars
-            WHERE `+dateCol+` BETWEEN ? AND ? AND deleted_at IS NULL
-            GROUP BY DATE(`+dateCol+`)
-        ) AS T
-        GROUP BY T.date
-        ORDER BY T.date ASC`, start, end, start, end)
-
-	err := query.Scan(&results).Error
+	err := ledger.Query(r.db, ledger.Filter{Start: &start, End: &end}).
+		Select("DATE(transaction_date) AS date, SUM(total) AS value").
+		Group("DATE(transaction_date)").
+		Order("date ASC").
+		Scan(&results).Error
 	return results, err
 }
 
@@ -155,7 +139,7 @@ func (r *repository) GetTopSellingProducts(start, end time.Time, limit int) ([]T
             FROM prescription_items pi
             JOIN prescription_sales ps ON pi.prescription_sale_id = ps.id
             JOIN stocks s ON pi.stock_id = s.id
-            WHERE ps.transaction_date BETWEEN ? AND ? AND pi.deleted_at IS NULL
+            WHERE ps.transaction_date BETWEEN ? AND ? AND pi.deleted_at IS NULL AND ps.status <> 'Batal'
             UNION ALL
             SELECT
                 sri.product_id, 
@@ -165,7 +149,7 @@ func (r *repository) GetTopSellingProducts(start, end time.Time, limit int) ([]T
                 sri.sub_total as revenue  
             FROM sales_regular_items sri
             JOIN sales_regulars sr ON sri.sales_regular_id = sr.id
-            WHERE sr.transaction_date BETWEEN ? AND ? AND sri.deleted_at IS NULL
+            WHERE sr.transaction_date BETWEEN ? AND ? AND sri.deleted_at IS NULL AND sr.status <> 'Batal'
         ) as combined_sales
         GROUP BY product_id, product_name, product_code
         ORDER BY total_revenue DESC
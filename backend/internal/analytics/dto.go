@@ -1,6 +1,10 @@
 package analytics
 
-import "time"
+import (
+	"go-gin-auth/internal/payment"
+	"go-gin-auth/internal/promotion"
+	"time"
+)
 
 // TimeRange represents the time range options
 type TimeRange string
@@ -44,13 +48,21 @@ type ProductSalesData struct {
 
 // SalesAnalyticsResponse represents the complete analytics response
 type SalesAnalyticsResponse struct {
-	LineChart     []LineChartData    `json:"line_chart"`
-	BarChart      []BarChartData     `json:"bar_chart"`
-	TopProducts   []ProductSalesData `json:"top_products"`
-	LeastProducts []ProductSalesData `json:"least_products"`
-	Summary       SalesSummary       `json:"summary"`
+	LineChart     []LineChartData     `json:"line_chart"`
+	BarChart      []BarChartData      `json:"bar_chart"`
+	TopProducts   []ProductSalesData  `json:"top_products"`
+	LeastProducts []ProductSalesData  `json:"least_products"`
+	Summary       SalesSummary        `json:"summary"`
+	Payments      []PaymentMethodData `json:"payments"`
+	Promotions    []PromotionCostData `json:"promotions"`
 }
 
+// PaymentMethodData represents revenue per payment method, net of change given
+type PaymentMethodData = payment.MethodTotal
+
+// PromotionCostData represents discount given per promotion
+type PromotionCostData = promotion.Cost
+
 // SalesSummary represents sales summary data
 type SalesSummary struct {
 	TotalSales            float64 `json:"total_sales"`
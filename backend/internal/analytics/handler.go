@@ -235,6 +235,80 @@ func (h *SalesAnalyticsHandler) GetSalesSummary(c *gin.Context) {
 	})
 }
 
+// GetPaymentBreakdown handles revenue per payment method request
+// @Summary Get payment method breakdown
+// @Description Get revenue grouped by payment method, net of change given
+// @Tags Sales Analytics
+// @Accept json
+// @Produce json
+// @Param request body SalesAnalyticsRequest true "Analytics request"
+// @Success 200 {object} []PaymentMethodData
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/sales/analytics/payment-methods [post]
+func (h *SalesAnalyticsHandler) GetPaymentBreakdown(c *gin.Context) {
+	var req SalesAnalyticsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	startDate, endDate := h.service.calculateDateRange(req.TimeRange, req.StartDate, req.EndDate)
+	result, err := h.service.getPaymentBreakdown(startDate, endDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get payment breakdown",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// GetPromotionCosts handles promotion cost request
+// @Summary Get promotion costs
+// @Description Get total discount given per promotion
+// @Tags Sales Analytics
+// @Accept json
+// @Produce json
+// @Param request body SalesAnalyticsRequest true "Analytics request"
+// @Success 200 {object} []PromotionCostData
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/sales/analytics/promotions [post]
+func (h *SalesAnalyticsHandler) GetPromotionCosts(c *gin.Context) {
+	var req SalesAnalyticsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	startDate, endDate := h.service.calculateDateRange(req.TimeRange, req.StartDate, req.EndDate)
+	result, err := h.service.getPromotionCosts(startDate, endDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get promotion costs",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
 // ErrorResponse represents error response structure
 type ErrorResponse struct {
 	Error   string `json:"error"`
@@ -249,4 +323,6 @@ func (h *SalesAnalyticsHandler) SetupAnalyticsRoutes(r *gin.RouterGroup) {
 	r.POST("/top-products", h.GetTopProducts)
 	r.POST("/least-products", h.GetLeastProducts)
 	r.POST("/summary", h.GetSalesSummary)
+	r.POST("/payment-methods", h.GetPaymentBreakdown)
+	r.POST("/promotions", h.GetPromotionCosts)
 }
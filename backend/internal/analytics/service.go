@@ -2,6 +2,9 @@ package analytics
 
 import (
 	"fmt"
+	"go-gin-auth/internal/ledger"
+	"go-gin-auth/internal/payment"
+	"go-gin-auth/internal/promotion"
 	"sort"
 	"time"
 
@@ -45,12 +48,24 @@ func (s *SalesAnalyticsService) GetSalesAnalytics(req SalesAnalyticsRequest) (*S
 		return nil, fmt.Errorf("failed to get sales summary: %w", err)
 	}
 
+	payments, err := s.getPaymentBreakdown(startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment breakdown: %w", err)
+	}
+
+	promotions, err := s.getPromotionCosts(startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get promotion costs: %w", err)
+	}
+
 	return &SalesAnalyticsResponse{
 		LineChart:     lineChart,
 		BarChart:      barChart,
 		TopProducts:   topProducts,
 		LeastProducts: leastProducts,
 		Summary:       summary,
+		Payments:      payments,
+		Promotions:    promotions,
 	}, nil
 }
 
@@ -74,60 +89,22 @@ func (s *SalesAnalyticsService) getLineChartData(timeRange TimeRange, startDate,
 		return nil, fmt.Errorf("invalid time range")
 	}
 
-	// Query untuk sales_regulars
-	regularQuery := fmt.Sprintf(`
-		SELECT 
-			%s AS date,
-			COALESCE(SUM(total_pay), 0) AS total,
-			'regular' AS period
-		FROM sales_regulars 
-		WHERE transaction_date BETWEEN ? AND ?
-		AND deleted_at IS NULL
-		GROUP BY %s
-		ORDER BY date ASC
-	`, selectDate, groupBy)
-
-	// Query untuk prescription_sales
-	prescriptionQuery := fmt.Sprintf(`
-		SELECT 
-			%s AS date,
-			COALESCE(SUM(total_amount), 0) AS total,
-			'prescription' AS period
-		FROM prescription_sales 
-		WHERE transaction_date BETWEEN ? AND ?
-		AND deleted_at IS NULL
-		GROUP BY %s
-		ORDER BY date ASC
-	`, selectDate, groupBy)
-
-	// Struct untuk menampung hasil query
-	var regularResults []struct {
-		Date   string  `json:"date"`
-		Total  float64 `json:"total"`
-		Period string  `json:"period"`
+	// Buku besar sudah menyatukan penjualan reguler dan resep serta
+	// melewati penjualan yang dibatalkan
+	var rows []struct {
+		Date  string
+		Total float64
 	}
-	var prescriptionResults []struct {
-		Date   string  `json:"date"`
-		Total  float64 `json:"total"`
-		Period string  `json:"period"`
-	}
-
-	// Eksekusi query sales_regulars
-	if err := s.db.Raw(regularQuery, startDate, endDate).Scan(&regularResults).Error; err != nil {
-		return nil, fmt.Errorf("failed to fetch regular sales: %w", err)
-	}
-
-	// Eksekusi query prescription_sales
-	if err := s.db.Raw(prescriptionQuery, startDate, endDate).Scan(&prescriptionResults).Error; err != nil {
-		return nil, fmt.Errorf("failed to fetch prescription sales: %w", err)
+	err := ledger.Query(s.db, ledger.Filter{Start: &startDate, End: &endDate}).
+		Select(selectDate + " AS date, COALESCE(SUM(total), 0) AS total").
+		Group(groupBy).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sales: %w", err)
 	}
 
-	// Gabungkan hasil dari kedua sumber ke map berdasarkan tanggal
 	dateMap := make(map[string]float64)
-	for _, r := range regularResults {
-		dateMap[r.Date] += r.Total
-	}
-	for _, r := range prescriptionResults {
+	for _, r := range rows {
 		dateMap[r.Date] += r.Total
 	}
 
@@ -152,17 +129,18 @@ func (s *SalesAnalyticsService) getLineChartData(timeRange TimeRange, startDate,
 func (s *SalesAnalyticsService) getBarChartData(timeRange TimeRange, startDate, endDate time.Time) ([]BarChartData, error) {
 	var results []BarChartData
 
+	sales := s.ledgerSales(startDate, endDate)
+
 	regularQuery := `
 		SELECT 
 			COALESCE(c.name, 'Uncategorized') as category,
 			SUM(sri.sub_total) as total,
-			COUNT(DISTINCT sr.id) as count
-		FROM sales_regulars sr
-		JOIN sales_regular_items sri ON sr.id = sri.sales_regular_id
+			COUNT(DISTINCT sri.sales_regular_id) as count
+		FROM sales_regular_items sri
+		JOIN (?) l ON l.sale_type = ? AND l.sale_id = sri.sales_regular_id
 		JOIN products p ON sri.product_id = p.id
 		LEFT JOIN categories c ON p.category_id = c.id
-		WHERE sr.transaction_date BETWEEN ? AND ?
-		AND sr.deleted_at IS NULL AND sri.deleted_at IS NULL AND p.deleted_at IS NULL
+		WHERE sri.deleted_at IS NULL AND p.deleted_at IS NULL
 		GROUP BY c.name
 	`
 
@@ -171,21 +149,23 @@ func (s *SalesAnalyticsService) getBarChartData(timeRange TimeRange, startDate,
 		SELECT 
 			'Prescription' as category,
 			SUM(pi.sub_total) as total,
-			COUNT(DISTINCT ps.id) as count
-		FROM prescription_sales ps
-		JOIN prescription_items pi ON ps.id = pi.prescription_sale_id
-		WHERE ps.transaction_date BETWEEN ? AND ?
-		AND ps.deleted_at IS NULL AND pi.deleted_at IS NULL
+			COUNT(DISTINCT pi.prescription_sale_id) as count
+		FROM (
+			SELECT prescription_sale_id, sub_total FROM prescription_items WHERE deleted_at IS NULL
+			UNION ALL
+			SELECT prescription_sale_id, sub_total FROM prescription_compounds WHERE deleted_at IS NULL
+		) pi
+		JOIN (?) l ON l.sale_type = ? AND l.sale_id = pi.prescription_sale_id
 		GROUP BY category
 	`
 
 	var regularResults []BarChartData
-	if err := s.db.Raw(regularQuery, startDate, endDate).Scan(&regularResults).Error; err != nil {
+	if err := s.db.Raw(regularQuery, sales, payment.SaleRegular).Scan(&regularResults).Error; err != nil {
 		return nil, err
 	}
 
 	var prescriptionResults []BarChartData
-	if err := s.db.Raw(prescriptionQuery, startDate, endDate).Scan(&prescriptionResults).Error; err != nil {
+	if err := s.db.Raw(prescriptionQuery, sales, payment.SalePrescription).Scan(&prescriptionResults).Error; err != nil {
 		return nil, err
 	}
 
@@ -228,6 +208,8 @@ func (s *SalesAnalyticsService) getBarChartData(timeRange TimeRange, startDate,
 func (s *SalesAnalyticsService) getTopProducts(startDate, endDate time.Time, limit int) ([]ProductSalesData, error) {
 	var results []ProductSalesData
 
+	sales := s.ledgerSales(startDate, endDate)
+
 	// Query for regular sales
 	regularQuery := `
 		SELECT 
@@ -236,11 +218,10 @@ func (s *SalesAnalyticsService) getTopProducts(startDate, endDate time.Time, lim
 			sri.product_name,
 			SUM(sri.qty) as total_qty,
 			SUM(sri.sub_total) as total_amount,
-			COUNT(DISTINCT sr.id) as sales_count
-		FROM sales_regulars sr
-		JOIN sales_regular_items sri ON sr.id = sri.sales_regular_id
-		WHERE sr.transaction_date >= ? AND sr.transaction_date <= ?
-		AND sr.deleted_at IS NULL AND sri.deleted_at IS NULL
+			COUNT(DISTINCT sri.sales_regular_id) as sales_count
+		FROM sales_regular_items sri
+		JOIN (?) l ON l.sale_type = ? AND l.sale_id = sri.sales_regular_id
+		WHERE sri.deleted_at IS NULL
 		GROUP BY sri.product_id, sri.product_code, sri.product_name
 	`
 
@@ -252,23 +233,22 @@ func (s *SalesAnalyticsService) getTopProducts(startDate, endDate time.Time, lim
 			pi.item_name as product_name,
 			SUM(pi.quantity) as total_qty,
 			SUM(pi.sub_total) as total_amount,
-			COUNT(DISTINCT ps.id) as sales_count
-		FROM prescription_sales ps
-		JOIN prescription_items pi ON ps.id = pi.prescription_sale_id
-		WHERE ps.transaction_date >= ? AND ps.transaction_date <= ?
-		AND ps.deleted_at IS NULL AND pi.deleted_at IS NULL
+			COUNT(DISTINCT pi.prescription_sale_id) as sales_count
+		FROM prescription_items pi
+		JOIN (?) l ON l.sale_type = ? AND l.sale_id = pi.prescription_sale_id
+		WHERE pi.deleted_at IS NULL
 		GROUP BY pi.stock_id, pi.item_code, pi.item_name
 	`
 
 	// Execute regular sales query
 	var regularResults []ProductSalesData
-	if err := s.db.Raw(regularQuery, startDate, endDate).Scan(&regularResults).Error; err != nil {
+	if err := s.db.Raw(regularQuery, sales, payment.SaleRegular).Scan(&regularResults).Error; err != nil {
 		return nil, err
 	}
 
 	// Execute prescription sales query
 	var prescriptionResults []ProductSalesData
-	if err := s.db.Raw(prescriptionQuery, startDate, endDate).Scan(&prescriptionResults).Error; err != nil {
+	if err := s.db.Raw(prescriptionQuery, sales, payment.SalePrescription).Scan(&prescriptionResults).Error; err != nil {
 		return nil, err
 	}
 
@@ -359,46 +339,19 @@ func (s *SalesAnalyticsService) getLeastProducts(startDate, endDate time.Time, l
 func (s *SalesAnalyticsService) getSalesSummary(startDate, endDate time.Time, period string) (SalesSummary, error) {
 	var summary SalesSummary
 
-	// Query for regular sales summary
-	regularQuery := `
-		SELECT 
-			COALESCE(SUM(total_pay), 0) as total_sales,
-			COUNT(*) as total_transactions
-		FROM sales_regulars 
-		WHERE transaction_date >= ? AND transaction_date <= ?
-		AND deleted_at IS NULL
-	`
-
-	// Query for prescription sales summary
-	prescriptionQuery := `
-		SELECT 
-			COALESCE(SUM(total_amount), 0) as total_sales,
-			COUNT(*) as total_transactions
-		FROM prescription_sales 
-		WHERE transaction_date >= ? AND transaction_date <= ?
-		AND deleted_at IS NULL
-	`
-
-	var regularSummary struct {
-		TotalSales        float64 `json:"total_sales"`
-		TotalTransactions int     `json:"total_transactions"`
-	}
-
-	var prescriptionSummary struct {
-		TotalSales        float64 `json:"total_sales"`
-		TotalTransactions int     `json:"total_transactions"`
-	}
-
-	if err := s.db.Raw(regularQuery, startDate, endDate).Scan(&regularSummary).Error; err != nil {
-		return summary, err
+	var totals struct {
+		TotalSales        float64
+		TotalTransactions int
 	}
-
-	if err := s.db.Raw(prescriptionQuery, startDate, endDate).Scan(&prescriptionSummary).Error; err != nil {
+	err := ledger.Query(s.db, ledger.Filter{Start: &startDate, End: &endDate}).
+		Select("COALESCE(SUM(total), 0) AS total_sales, COUNT(*) AS total_transactions").
+		Scan(&totals).Error
+	if err != nil {
 		return summary, err
 	}
 
-	summary.TotalSales = regularSummary.TotalSales + prescriptionSummary.TotalSales
-	summary.TotalTransactions = regularSummary.TotalTransactions + prescriptionSummary.TotalTransactions
+	summary.TotalSales = totals.TotalSales
+	summary.TotalTransactions = totals.TotalTransactions
 	summary.Period = period
 
 	if summary.TotalTransactions > 0 {
@@ -408,6 +361,23 @@ func (s *SalesAnalyticsService) getSalesSummary(startDate, endDate time.Time, pe
 	return summary, nil
 }
 
+// ledgerSales adalah subquery penjualan yang tidak dibatalkan pada periode,
+// dipakai untuk menyaring item penjualan reguler dan resep
+func (s *SalesAnalyticsService) ledgerSales(startDate, endDate time.Time) *gorm.DB {
+	return ledger.Query(s.db, ledger.Filter{Start: &startDate, End: &endDate}).
+		Select("sale_type, sale_id")
+}
+
+// getPaymentBreakdown returns revenue grouped by payment method
+func (s *SalesAnalyticsService) getPaymentBreakdown(startDate, endDate time.Time) ([]PaymentMethodData, error) {
+	return payment.BreakdownByPeriod(s.db, startDate, endDate)
+}
+
+// getPromotionCosts returns discount given per promotion
+func (s *SalesAnalyticsService) getPromotionCosts(startDate, endDate time.Time) ([]PromotionCostData, error) {
+	return promotion.CostByPeriod(s.db, startDate, endDate)
+}
+
 // calculateDateRange calculates start and end dates based on time range
 func (s *SalesAnalyticsService) calculateDateRange(timeRange TimeRange, startDate, endDate *time.Time) (time.Time, time.Time) {
 	now := time.Now()
@@ -10,6 +10,7 @@ type Category struct {
 	ID            uint           `gorm:"primaryKey" json:"id"`
 	Name          string         `gorm:"type:varchar(100);not null;uniqueIndex;comment:Nama Kategori" json:"name" form:"name"`
 	Description   string         `gorm:"type:varchar(255);comment:Deskripsi" json:"description" form:"description"`
+	TaxRateID     *uint          `gorm:"comment:ID Tarif Pajak" json:"tax_rate_id" form:"tax_rate_id"`
 	CreatedBy     uint           `gorm:"not null;comment:ID Pengguna Pembuat" json:"created_by"`
 	CreatedByName string         `gorm:"-" json:"created_by_name,omitempty"`
 	CreatedAt     time.Time      `json:"created_at"`
@@ -0,0 +1,117 @@
+package customer
+
+import (
+	"errors"
+	"go-gin-auth/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) GetAll(c *gin.Context) {
+	customers, err := h.service.GetAll(c.Query("search"))
+	if err != nil {
+		utils.Respond(c, http.StatusInternalServerError, "Gagal mengambil daftar pelanggan", err.Error(), nil)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Daftar pelanggan berhasil diambil", nil, customers)
+}
+
+func (h *Handler) GetByID(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	customer, err := h.service.GetByID(uint(id))
+	if err != nil {
+		h.respondError(c, "Gagal mengambil pelanggan", err)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Detail pelanggan berhasil diambil", nil, customer)
+}
+
+func (h *Handler) GetByPhone(c *gin.Context) {
+	customer, err := h.service.FindByPhone(c.Param("phone"))
+	if err != nil {
+		h.respondError(c, "Gagal mencari pelanggan", err)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Pelanggan ditemukan", nil, customer)
+}
+
+func (h *Handler) Create(c *gin.Context) {
+	var input Customer
+	if err := c.ShouldBind(&input); err != nil {
+		utils.Respond(c, http.StatusBadRequest, "Input tidak valid", err.Error(), nil)
+		return
+	}
+	customer, err := h.service.Create(&input)
+	if err != nil {
+		h.respondError(c, "Gagal membuat pelanggan", err)
+		return
+	}
+	utils.Respond(c, http.StatusCreated, "Pelanggan berhasil dibuat", nil, customer)
+}
+
+func (h *Handler) Update(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var input Customer
+	if err := c.ShouldBind(&input); err != nil {
+		utils.Respond(c, http.StatusBadRequest, "Input tidak valid", err.Error(), nil)
+		return
+	}
+	customer, err := h.service.Update(uint(id), &input)
+	if err != nil {
+		h.respondError(c, "Gagal memperbarui pelanggan", err)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Pelanggan berhasil diperbarui", nil, customer)
+}
+
+func (h *Handler) Delete(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err := h.service.Delete(uint(id)); err != nil {
+		h.respondError(c, "Gagal menghapus pelanggan", err)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Pelanggan berhasil dihapus", nil, nil)
+}
+
+func (h *Handler) Points(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	entries, err := h.service.Points(uint(id))
+	if err != nil {
+		h.respondError(c, "Gagal mengambil buku poin", err)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Buku poin pelanggan berhasil diambil", nil, entries)
+}
+
+func (h *Handler) Purchases(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	purchases, err := h.service.Purchases(uint(id))
+	if err != nil {
+		h.respondError(c, "Gagal mengambil riwayat belanja", err)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Riwayat belanja pelanggan berhasil diambil", nil, purchases)
+}
+
+func (h *Handler) respondError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		utils.Respond(c, http.StatusNotFound, err.Error(), err.Error(), nil)
+	case errors.Is(err, ErrInvalidInput), errors.Is(err, ErrInactive):
+		utils.Respond(c, http.StatusBadRequest, err.Error(), err.Error(), nil)
+	case errors.Is(err, ErrPhoneExists):
+		utils.Respond(c, http.StatusConflict, err.Error(), err.Error(), nil)
+	default:
+		utils.Respond(c, http.StatusInternalServerError, message, err.Error(), nil)
+	}
+}
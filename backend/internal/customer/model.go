@@ -0,0 +1,72 @@
+package customer
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Customer adalah pelanggan penjualan bebas yang dikenali dari nomor
+// teleponnya. Points adalah saldo poin yang masih berlaku.
+type Customer struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	Name      string `gorm:"type:varchar(255);not null" json:"name" form:"name"`
+	Phone     string `gorm:"type:varchar(30);not null;uniqueIndex" json:"phone" form:"phone"`
+	Email     string `gorm:"type:varchar(255)" json:"email" form:"email"`
+	Address   string `gorm:"type:text" json:"address" form:"address"`
+	PatientID *uint  `gorm:"index" json:"patient_id,omitempty" form:"patient_id"`
+	Points    int    `gorm:"not null;default:0" json:"points"`
+	// CreditLimit membatasi total piutang terbuka; 0 berarti tanpa batas.
+	// PaymentTermDays adalah jatuh tempo piutang sejak tanggal transaksi.
+	CreditLimit     float64        `gorm:"type:decimal(15,2);not null;default:0" json:"credit_limit" form:"credit_limit"`
+	PaymentTermDays int            `gorm:"not null;default:0" json:"payment_term_days" form:"payment_term_days"`
+	Active          bool           `gorm:"not null;default:true" json:"active" form:"active"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+func (Customer) TableName() string {
+	return "customers"
+}
+
+// Jenis mutasi poin
+const (
+	PointEarned   = "Perolehan"
+	PointRedeemed = "Penukaran"
+	PointExpired  = "Kedaluwarsa"
+	PointReversed = "Pembatalan"
+)
+
+// PointEntry adalah satu mutasi pada buku poin pelanggan. Points bertanda
+// positif untuk poin masuk dan negatif untuk poin keluar. Remaining hanya
+// dipakai pada poin masuk: sisa poin yang belum ditukar atau kedaluwarsa,
+// dipakai berurutan dari yang paling dulu kedaluwarsa.
+type PointEntry struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	CustomerID uint       `gorm:"not null;index" json:"customer_id"`
+	Type       string     `gorm:"type:varchar(20);not null" json:"type"`
+	Points     int        `gorm:"not null" json:"points"`
+	Remaining  int        `gorm:"not null;default:0" json:"remaining"`
+	SaleType   string     `gorm:"type:varchar(20);index:idx_point_entries_sale" json:"sale_type,omitempty"`
+	SaleID     *uint      `gorm:"index:idx_point_entries_sale" json:"sale_id,omitempty"`
+	Reversed   bool       `gorm:"not null;default:false" json:"reversed"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	Note       string     `gorm:"type:varchar(255)" json:"note,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (PointEntry) TableName() string {
+	return "customer_point_entries"
+}
+
+// Purchase adalah satu baris riwayat belanja pelanggan
+type Purchase struct {
+	SaleType        string    `json:"sale_type"`
+	SaleID          uint      `json:"sale_id"`
+	Code            string    `json:"code"`
+	TransactionDate time.Time `json:"transaction_date"`
+	ItemCount       int64     `json:"item_count"`
+	Total           int64     `json:"total"`
+	Status          string    `json:"status"`
+}
@@ -0,0 +1,265 @@
+package customer
+
+import (
+	"errors"
+	"fmt"
+	"go-gin-auth/internal/pharmacy"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInsufficientPoints = errors.New("poin pelanggan tidak mencukupi")
+	ErrRedeemDisabled     = errors.New("penukaran poin belum diatur pada profil apotek")
+)
+
+// Policy adalah aturan program poin yang diambil dari profil apotek
+type Policy struct {
+	EarnAmount   int
+	PointValue   int
+	ExpiryMonths int
+}
+
+func LoadPolicy(db *gorm.DB) (Policy, error) {
+	profile, err := pharmacy.Get(db)
+	if err != nil {
+		return Policy{}, err
+	}
+	return Policy{
+		EarnAmount:   profile.PointEarnAmount,
+		PointValue:   profile.PointValue,
+		ExpiryMonths: profile.PointExpiryMonths,
+	}, nil
+}
+
+// Earned menghitung poin yang diperoleh dari nilai belanja, dibulatkan ke bawah
+func (p Policy) Earned(amount int) int {
+	if p.EarnAmount <= 0 || amount <= 0 {
+		return 0
+	}
+	return amount / p.EarnAmount
+}
+
+// Discount menghitung potongan rupiah untuk sejumlah poin yang ditukar
+func (p Policy) Discount(points int) (int, error) {
+	if points <= 0 {
+		return 0, nil
+	}
+	if p.PointValue <= 0 {
+		return 0, ErrRedeemDisabled
+	}
+	return points * p.PointValue, nil
+}
+
+func (p Policy) expiry(from time.Time) *time.Time {
+	if p.ExpiryMonths <= 0 {
+		return nil
+	}
+	at := from.AddDate(0, p.ExpiryMonths, 0)
+	return &at
+}
+
+// Find mengambil pelanggan aktif untuk dipakai pada transaksi
+func Find(db *gorm.DB, id uint) (*Customer, error) {
+	var c Customer
+	if err := db.First(&c, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if !c.Active {
+		return nil, ErrInactive
+	}
+	return &c, nil
+}
+
+// Apply mencatat penukaran dan perolehan poin sebuah penjualan di dalam
+// transaksi penjualan. Saldo pelanggan dikunci agar penukaran bersamaan
+// tidak membuat saldo minus.
+func Apply(tx *gorm.DB, customerID uint, saleType string, saleID uint, redeemed, earned int, at time.Time) error {
+	if err := Lock(tx, customerID); err != nil {
+		return err
+	}
+	if err := expire(tx, customerID, time.Now()); err != nil {
+		return err
+	}
+
+	if redeemed > 0 {
+		taken, err := consume(tx, customerID, redeemed)
+		if err != nil {
+			return err
+		}
+		if taken < redeemed {
+			return ErrInsufficientPoints
+		}
+		entry := PointEntry{
+			CustomerID: customerID,
+			Type:       PointRedeemed,
+			Points:     -redeemed,
+			SaleType:   saleType,
+			SaleID:     &saleID,
+		}
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+	}
+
+	if earned > 0 {
+		policy, err := LoadPolicy(tx)
+		if err != nil {
+			return err
+		}
+		entry := PointEntry{
+			CustomerID: customerID,
+			Type:       PointEarned,
+			Points:     earned,
+			Remaining:  earned,
+			SaleType:   saleType,
+			SaleID:     &saleID,
+			ExpiresAt:  policy.expiry(at),
+		}
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+	}
+
+	return refresh(tx, customerID)
+}
+
+// Reverse membatalkan mutasi poin sebuah penjualan, dipakai saat penjualan
+// diubah atau dibatalkan. Poin yang ditukar dikembalikan sebagai poin baru;
+// poin yang diperoleh ditarik sebanyak saldo yang masih tersedia.
+func Reverse(tx *gorm.DB, saleType string, saleID uint) error {
+	var entries []PointEntry
+	err := tx.Where("sale_type = ? AND sale_id = ? AND reversed = ? AND type IN ?",
+		saleType, saleID, false, []string{PointEarned, PointRedeemed}).
+		Order("id").Find(&entries).Error
+	if err != nil || len(entries) == 0 {
+		return err
+	}
+
+	customerID := entries[0].CustomerID
+	if err := Lock(tx, customerID); err != nil {
+		return err
+	}
+	if err := expire(tx, customerID, time.Now()); err != nil {
+		return err
+	}
+	policy, err := LoadPolicy(tx)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		reversal := PointEntry{
+			CustomerID: customerID,
+			Type:       PointReversed,
+			SaleType:   saleType,
+			SaleID:     &saleID,
+		}
+		switch e.Type {
+		case PointRedeemed:
+			reversal.Points = -e.Points
+			reversal.Remaining = -e.Points
+			reversal.ExpiresAt = policy.expiry(time.Now())
+			reversal.Note = "Pengembalian poin yang ditukar"
+		case PointEarned:
+			// sisa poin transaksi ini ditarik lebih dulu, kekurangannya
+			// diambil dari poin lain yang masih berlaku
+			taken := e.Remaining
+			if err := tx.Model(&PointEntry{}).Where("id = ?", e.ID).Update("remaining", 0).Error; err != nil {
+				return err
+			}
+			if rest := e.Points - taken; rest > 0 {
+				more, err := consume(tx, customerID, rest)
+				if err != nil {
+					return err
+				}
+				taken += more
+			}
+			reversal.Points = -taken
+			reversal.Note = fmt.Sprintf("Penarikan %d dari %d poin yang diperoleh", taken, e.Points)
+		}
+		if err := tx.Create(&reversal).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&PointEntry{}).Where("id = ?", e.ID).Update("reversed", true).Error; err != nil {
+			return err
+		}
+	}
+
+	return refresh(tx, customerID)
+}
+
+// Lock mengunci baris pelanggan FOR UPDATE sampai transaksi selesai agar
+// perubahan poin dan piutang pelanggan yang sama berjalan bergantian
+func Lock(tx *gorm.DB, customerID uint) error {
+	var c Customer
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&c, customerID).Error
+}
+
+// consume memakai sisa poin masuk yang masih berlaku, dari yang paling dulu
+// kedaluwarsa, sampai sejumlah points atau saldo habis
+func consume(tx *gorm.DB, customerID uint, points int) (int, error) {
+	var lots []PointEntry
+	err := tx.Where("customer_id = ? AND remaining > 0 AND (expires_at IS NULL OR expires_at > ?)", customerID, time.Now()).
+		Order("expires_at ASC NULLS LAST, id ASC").
+		Find(&lots).Error
+	if err != nil {
+		return 0, err
+	}
+
+	taken := 0
+	for _, lot := range lots {
+		if taken == points {
+			break
+		}
+		use := lot.Remaining
+		if use > points-taken {
+			use = points - taken
+		}
+		if err := tx.Model(&PointEntry{}).Where("id = ?", lot.ID).Update("remaining", lot.Remaining-use).Error; err != nil {
+			return 0, err
+		}
+		taken += use
+	}
+	return taken, nil
+}
+
+// expire mencatat sisa poin yang sudah melewati masa berlakunya
+func expire(tx *gorm.DB, customerID uint, now time.Time) error {
+	var lots []PointEntry
+	err := tx.Where("customer_id = ? AND remaining > 0 AND expires_at <= ?", customerID, now).
+		Find(&lots).Error
+	if err != nil || len(lots) == 0 {
+		return err
+	}
+
+	for _, lot := range lots {
+		entry := PointEntry{
+			CustomerID: customerID,
+			Type:       PointExpired,
+			Points:     -lot.Remaining,
+			Note:       fmt.Sprintf("Poin perolehan %s", lot.CreatedAt.Format("02-01-2006")),
+		}
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&PointEntry{}).Where("id = ?", lot.ID).Update("remaining", 0).Error; err != nil {
+			return err
+		}
+	}
+	return refresh(tx, customerID)
+}
+
+// refresh menyamakan saldo pelanggan dengan sisa poin yang masih berlaku
+func refresh(tx *gorm.DB, customerID uint) error {
+	return tx.Exec(`
+		UPDATE customers SET points = (
+			SELECT COALESCE(SUM(remaining), 0) FROM customer_point_entries
+			WHERE customer_id = ? AND remaining > 0
+		) WHERE id = ?`, customerID, customerID).Error
+}
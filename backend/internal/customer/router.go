@@ -0,0 +1,27 @@
+package customer
+
+import (
+	"go-gin-auth/config"
+	"go-gin-auth/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CustomerRouter dipasang sebelum grup admin karena kasir perlu mencari dan
+// mendaftarkan pelanggan saat transaksi
+func CustomerRouter(api *gin.RouterGroup) {
+	service := NewService(config.DB)
+	handler := NewHandler(service)
+
+	customers := api.Group("/customers", middleware.AuthMiddleware())
+	{
+		customers.GET("", handler.GetAll)
+		customers.GET("/phone/:phone", handler.GetByPhone)
+		customers.GET("/:id", handler.GetByID)
+		customers.GET("/:id/points", handler.Points)
+		customers.GET("/:id/purchases", handler.Purchases)
+		customers.POST("", handler.Create)
+		customers.PUT("/:id", handler.Update)
+		customers.DELETE("/:id", middleware.AuthAdminMiddleware(), handler.Delete)
+	}
+}
@@ -0,0 +1,201 @@
+package customer
+
+import (
+	"errors"
+	"go-gin-auth/internal/ledger"
+	"go-gin-auth/internal/payment"
+	"strings"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotFound     = errors.New("pelanggan tidak ditemukan")
+	ErrInactive     = errors.New("pelanggan tidak aktif")
+	ErrInvalidInput = errors.New("nama dan nomor telepon pelanggan wajib diisi, limit kredit dan tempo tidak boleh negatif")
+	ErrPhoneExists  = errors.New("nomor telepon sudah terdaftar untuk pelanggan lain")
+)
+
+type Service struct {
+	db *gorm.DB
+}
+
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db}
+}
+
+// NormalizePhone menyeragamkan nomor telepon agar pencarian di kasir tidak
+// bergantung pada spasi, tanda hubung atau awalan 62
+func NormalizePhone(phone string) string {
+	var b strings.Builder
+	for _, r := range phone {
+		if unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	digits := b.String()
+	if strings.HasPrefix(digits, "62") {
+		digits = "0" + digits[2:]
+	}
+	return digits
+}
+
+func (s *Service) GetAll(search string) ([]Customer, error) {
+	customers := []Customer{}
+	query := s.db.Model(&Customer{})
+	if search = strings.TrimSpace(search); search != "" {
+		like := "%" + strings.ToLower(search) + "%"
+		query = query.Where("LOWER(name) LIKE ? OR phone LIKE ?", like, "%"+NormalizePhone(search)+"%")
+	}
+	if err := query.Order("name").Find(&customers).Error; err != nil {
+		return nil, err
+	}
+	return customers, nil
+}
+
+func (s *Service) GetByID(id uint) (*Customer, error) {
+	if err := s.expire(id); err != nil {
+		return nil, err
+	}
+	var c Customer
+	if err := s.db.First(&c, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+// FindByPhone dipakai kasir untuk memilih pelanggan dari nomor telepon
+func (s *Service) FindByPhone(phone string) (*Customer, error) {
+	var c Customer
+	err := s.db.Where("phone = ?", NormalizePhone(phone)).First(&c).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if !c.Active {
+		return nil, ErrInactive
+	}
+	return s.GetByID(c.ID)
+}
+
+func (s *Service) Create(input *Customer) (*Customer, error) {
+	if err := s.validate(input, 0); err != nil {
+		return nil, err
+	}
+	input.ID = 0
+	input.Points = 0
+	input.Active = true
+	if err := s.db.Create(input).Error; err != nil {
+		return nil, err
+	}
+	return input, nil
+}
+
+func (s *Service) Update(id uint, input *Customer) (*Customer, error) {
+	existing, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validate(input, id); err != nil {
+		return nil, err
+	}
+	err = s.db.Model(existing).
+		Select("name", "phone", "email", "address", "patient_id", "credit_limit", "payment_term_days", "active").
+		Updates(Customer{
+			Name:            input.Name,
+			Phone:           input.Phone,
+			Email:           input.Email,
+			Address:         input.Address,
+			PatientID:       input.PatientID,
+			CreditLimit:     input.CreditLimit,
+			PaymentTermDays: input.PaymentTermDays,
+			Active:          input.Active,
+		}).Error
+	if err != nil {
+		return nil, err
+	}
+	return s.GetByID(id)
+}
+
+func (s *Service) Delete(id uint) error {
+	result := s.db.Delete(&Customer{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Points mengambil buku poin pelanggan, terbaru lebih dulu
+func (s *Service) Points(id uint) ([]PointEntry, error) {
+	if _, err := s.GetByID(id); err != nil {
+		return nil, err
+	}
+	entries := []PointEntry{}
+	if err := s.db.Where("customer_id = ?", id).Order("created_at DESC, id DESC").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Purchases mengambil riwayat belanja pelanggan dari buku besar penjualan,
+// termasuk penjualan resep bila pelanggan terhubung ke data pasien
+func (s *Service) Purchases(id uint) ([]Purchase, error) {
+	c, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	query := ledger.Query(s.db, ledger.Filter{IncludeVoided: true})
+	if c.PatientID != nil {
+		query = query.Where("(sale_type = ? AND sale_id IN (?)) OR (sale_type = ? AND patient_id = ?)",
+			payment.SaleRegular, s.regularSales(id), payment.SalePrescription, *c.PatientID)
+	} else {
+		query = query.Where("sale_type = ? AND sale_id IN (?)", payment.SaleRegular, s.regularSales(id))
+	}
+
+	purchases := []Purchase{}
+	err = query.Select("sale_type, sale_id, code, transaction_date, item_count, total, status").
+		Order("transaction_date DESC").
+		Scan(&purchases).Error
+	if err != nil {
+		return nil, err
+	}
+	return purchases, nil
+}
+
+func (s *Service) regularSales(customerID uint) *gorm.DB {
+	return s.db.Table("sales_regulars").Select("id").Where("customer_id = ?", customerID)
+}
+
+func (s *Service) expire(id uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return expire(tx, id, time.Now())
+	})
+}
+
+func (s *Service) validate(input *Customer, id uint) error {
+	input.Name = strings.TrimSpace(input.Name)
+	input.Phone = NormalizePhone(input.Phone)
+	if input.Name == "" || input.Phone == "" || input.CreditLimit < 0 || input.PaymentTermDays < 0 {
+		return ErrInvalidInput
+	}
+
+	var count int64
+	if err := s.db.Model(&Customer{}).Where("phone = ? AND id <> ?", input.Phone, id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrPhoneExists
+	}
+	return nil
+}
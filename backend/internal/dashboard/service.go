@@ -157,7 +157,7 @@ func (s *DashboardService) GetTotalSalesRegular(date time.Time) (float64, error)
 
 	err := s.db.Model(&sales.SalesRegular{}).
 		Select("COALESCE(SUM(total_pay), 0)").
-		Where("transaction_date >= ? AND transaction_date < ? AND deleted_at IS NULL", startOfDay, endOfDay).
+		Where("transaction_date >= ? AND transaction_date < ? AND deleted_at IS NULL AND status <> 'Batal'", startOfDay, endOfDay).
 		Scan(&total).Error
 
 	return total, err
@@ -173,7 +173,7 @@ func (s *DashboardService) GetTotalSalesPrescription(date time.Time) (float64, e
 
 	err := s.db.Model(&prescription.PrescriptionSale{}).
 		Select("COALESCE(SUM(total_amount), 0)").
-		Where("transaction_date >= ? AND transaction_date < ? AND deleted_at IS NULL", startOfDay, endOfDay).
+		Where("transaction_date >= ? AND transaction_date < ? AND deleted_at IS NULL AND status <> 'Batal'", startOfDay, endOfDay).
 		Scan(&total).Error
 
 	return total, err
@@ -0,0 +1,159 @@
+package dispensing
+
+import (
+	"errors"
+	"fmt"
+	"go-gin-auth/internal/drug_category"
+	"go-gin-auth/service"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrOverrideRequired dikembalikan bila penjualan bebas memuat obat
+	// keras dan apoteker belum mengisi alasan untuk tetap menyerahkan
+	ErrOverrideRequired = errors.New("penjualan memuat obat keras, gunakan penjualan resep atau isi alasan dan login apoteker")
+	// ErrPharmacistRejected dikembalikan bila login apoteker yang menyetujui
+	// tidak valid atau akunnya bukan apoteker aktif
+	ErrPharmacistRejected = errors.New("login apoteker tidak valid atau akun bukan apoteker aktif")
+	// ErrOWALimit dikembalikan bila jumlah OWA melebihi batas per transaksi
+	ErrOWALimit = errors.New("jumlah Obat Wajib Apotek melebihi batas per transaksi")
+)
+
+// BlockedError membawa produk yang menahan penyimpanan penjualan bebas
+type BlockedError struct {
+	Restrictions []Restriction
+}
+
+func (e *BlockedError) Error() string {
+	return ErrOverrideRequired.Error()
+}
+
+func (e *BlockedError) Is(target error) bool {
+	return target == ErrOverrideRequired
+}
+
+// Check memeriksa golongan setiap produk pada penjualan bebas. Jumlah
+// produk yang sama dijumlahkan lebih dulu agar batas OWA berlaku per
+// transaksi.
+func Check(db *gorm.DB, lines []Line) ([]Restriction, error) {
+	restrictions := []Restriction{}
+	var ids []uint
+	quantities := make(map[uint]int)
+	for _, l := range lines {
+		if _, ok := quantities[l.ProductID]; !ok {
+			ids = append(ids, l.ProductID)
+		}
+		quantities[l.ProductID] += l.Quantity
+	}
+	if len(ids) == 0 {
+		return restrictions, nil
+	}
+
+	var rows []struct {
+		ID           uint
+		Code         string
+		Name         string
+		Category     string
+		ControlClass string
+		OWALimit     int
+	}
+	err := db.Table("products p").
+		Select("p.id, p.code, p.name, COALESCE(dc.name, '') AS category, COALESCE(dc.control_class, '') AS control_class, COALESCE(dc.owa_limit, 0) AS owa_limit").
+		Joins("LEFT JOIN drug_categories dc ON dc.id = p.drug_category_id").
+		Where("p.id IN ?", ids).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]int, len(rows))
+	for i, r := range rows {
+		byID[r.ID] = i
+	}
+
+	for _, id := range ids {
+		i, ok := byID[id]
+		if !ok {
+			continue
+		}
+		r := rows[i]
+		category := drug_category.DrugCategory{Name: r.Category, ControlClass: r.ControlClass, OWALimit: r.OWALimit}
+		restriction := Restriction{
+			ProductID:      r.ID,
+			ProductCode:    r.Code,
+			ProductName:    r.Name,
+			Classification: category.Classification(),
+			Quantity:       quantities[id],
+		}
+		switch restriction.Classification {
+		case drug_category.ClassHard, drug_category.ClassNarcotic, drug_category.ClassPsychotropic:
+			restriction.Message = "hanya dapat diserahkan dengan resep dokter"
+		case drug_category.ClassOWA:
+			if r.OWALimit == 0 || restriction.Quantity <= r.OWALimit {
+				continue
+			}
+			restriction.Limit = r.OWALimit
+			restriction.Message = fmt.Sprintf("maksimal %d per transaksi", r.OWALimit)
+		default:
+			continue
+		}
+		restrictions = append(restrictions, restriction)
+	}
+	return restrictions, nil
+}
+
+// Guard memeriksa penjualan bebas sebelum disimpan. OWA yang melebihi batas
+// selalu ditolak, sedangkan obat keras tanpa alasan dan login apoteker
+// menghasilkan BlockedError. Nilai kedua adalah ID apoteker yang menyetujui.
+// Narkotika dan psikotropika ditolak lebih dulu oleh
+// narcotic.EnsureNotControlled.
+func Guard(db *gorm.DB, lines []Line, approval Approval) ([]Restriction, uint, error) {
+	restrictions, err := Check(db, lines)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, r := range restrictions {
+		if r.Classification == drug_category.ClassOWA {
+			return restrictions, 0, fmt.Errorf("%w: %s %s", ErrOWALimit, r.ProductName, r.Message)
+		}
+	}
+	if len(restrictions) == 0 {
+		return restrictions, 0, nil
+	}
+	if strings.TrimSpace(approval.Reason) == "" || approval.Email == "" {
+		return restrictions, 0, &BlockedError{Restrictions: restrictions}
+	}
+	pharmacist, err := service.VerifyApprover(db, approval.Email, approval.Password, service.PharmacistRoles...)
+	if errors.Is(err, service.ErrInvalidApprover) || errors.Is(err, service.ErrApproverLocked) {
+		return restrictions, 0, fmt.Errorf("%w: %v", ErrPharmacistRejected, err)
+	}
+	if err != nil {
+		return restrictions, 0, err
+	}
+	return restrictions, pharmacist.ID, nil
+}
+
+// RecordOverride menyimpan alasan dan apoteker yang menyetujui bersama
+// produk yang diserahkan tanpa resep. Tidak ada yang dicatat bila tidak ada
+// pembatasan.
+func RecordOverride(tx *gorm.DB, saleType string, saleID, userID, pharmacistID uint, reason string, restrictions []Restriction) error {
+	reason = strings.TrimSpace(reason)
+	if len(restrictions) == 0 || reason == "" {
+		return nil
+	}
+	return tx.Create(&Override{
+		SaleType:     saleType,
+		SaleID:       saleID,
+		UserID:       userID,
+		Reason:       reason,
+		Restrictions: restrictions,
+		PharmacistID: pharmacistID,
+	}).Error
+}
+
+// RemoveOverrides menghapus catatan alasan penjualan yang diubah agar
+// dicatat ulang sesuai item terbaru
+func RemoveOverrides(tx *gorm.DB, saleType string, saleID uint) error {
+	return tx.Where("sale_type = ? AND sale_id = ?", saleType, saleID).Delete(&Override{}).Error
+}
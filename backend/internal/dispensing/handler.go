@@ -0,0 +1,54 @@
+package dispensing
+
+import (
+	"go-gin-auth/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+type checkRequest struct {
+	Items []struct {
+		ProductID uint `json:"product_id" binding:"required"`
+		Qty       int  `json:"qty"`
+	} `json:"items" binding:"required,dive"`
+}
+
+// Check memeriksa item penjualan bebas tanpa menyimpan apa pun
+func (h *Handler) Check(c *gin.Context) {
+	var req checkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Respond(c, http.StatusBadRequest, "Input tidak valid", err.Error(), nil)
+		return
+	}
+	lines := make([]Line, len(req.Items))
+	for i, item := range req.Items {
+		lines[i] = Line{ProductID: item.ProductID, Quantity: item.Qty}
+	}
+
+	restrictions, err := h.service.Check(lines)
+	if err != nil {
+		utils.Respond(c, http.StatusInternalServerError, "Gagal memeriksa golongan obat", err.Error(), nil)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Pemeriksaan golongan obat selesai", nil, restrictions)
+}
+
+func (h *Handler) Overrides(c *gin.Context) {
+	saleID, _ := strconv.ParseUint(c.Query("sale_id"), 10, 32)
+	overrides, err := h.service.Overrides(c.Query("sale_type"), uint(saleID))
+	if err != nil {
+		utils.Respond(c, http.StatusInternalServerError, "Gagal mengambil catatan alasan apoteker", err.Error(), nil)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Catatan alasan apoteker berhasil diambil", nil, overrides)
+}
@@ -0,0 +1,50 @@
+package dispensing
+
+import "time"
+
+// Line adalah jumlah satu produk yang akan diserahkan tanpa resep
+type Line struct {
+	ProductID uint
+	Quantity  int
+}
+
+// Restriction adalah produk pada penjualan bebas yang golongannya
+// membatasi penyerahan tanpa resep
+type Restriction struct {
+	ProductID      uint   `json:"product_id"`
+	ProductCode    string `json:"product_code"`
+	ProductName    string `json:"product_name"`
+	Classification string `json:"classification"`
+	Quantity       int    `json:"quantity"`
+	// Limit diisi untuk OWA yang melebihi batas per transaksi
+	Limit   int    `json:"limit,omitempty"`
+	Message string `json:"message"`
+}
+
+// Approval adalah alasan beserta login ulang apoteker yang mengizinkan obat
+// keras diserahkan tanpa resep
+type Approval struct {
+	Reason   string
+	Email    string
+	Password string
+}
+
+// Override mencatat alasan apoteker menyerahkan obat keras tanpa resep.
+// UserID adalah kasir, PharmacistID apoteker yang menyetujui.
+type Override struct {
+	ID           uint          `gorm:"primaryKey" json:"id"`
+	SaleType     string        `gorm:"type:varchar(20);not null;index:idx_dispensing_override_sale" json:"sale_type"`
+	SaleID       uint          `gorm:"not null;index:idx_dispensing_override_sale" json:"sale_id"`
+	UserID       uint          `gorm:"not null" json:"user_id"`
+	UserName     string        `gorm:"-" json:"user_name,omitempty"`
+	Reason       string        `gorm:"type:text;not null" json:"reason"`
+	Restrictions []Restriction `gorm:"serializer:json;type:text" json:"restrictions"`
+	CreatedAt    time.Time     `json:"created_at"`
+
+	PharmacistID   uint   `gorm:"not null;default:0" json:"pharmacist_id"`
+	PharmacistName string `gorm:"-" json:"pharmacist_name,omitempty"`
+}
+
+func (Override) TableName() string {
+	return "dispensing_overrides"
+}
@@ -0,0 +1,18 @@
+package dispensing
+
+import (
+	"go-gin-auth/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func DispensingRouter(api *gin.RouterGroup) {
+	service := NewService(config.DB)
+	handler := NewHandler(service)
+
+	dispensing := api.Group("/dispensing")
+	{
+		dispensing.POST("/check", handler.Check)
+		dispensing.GET("/overrides", handler.Overrides)
+	}
+}
@@ -0,0 +1,38 @@
+package dispensing
+
+import "gorm.io/gorm"
+
+type Service struct {
+	db *gorm.DB
+}
+
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db}
+}
+
+// Check menampilkan pembatasan penyerahan tanpa resep sebelum kasir
+// menyimpan penjualan
+func (s *Service) Check(lines []Line) ([]Restriction, error) {
+	return Check(s.db, lines)
+}
+
+// Overrides mengambil catatan alasan apoteker, terbaru lebih dulu
+func (s *Service) Overrides(saleType string, saleID uint) ([]Override, error) {
+	query := s.db.Model(&Override{})
+	if saleType != "" {
+		query = query.Where("sale_type = ?", saleType)
+	}
+	if saleID != 0 {
+		query = query.Where("sale_id = ?", saleID)
+	}
+
+	var overrides []Override
+	if err := query.Order("created_at DESC").Limit(500).Find(&overrides).Error; err != nil {
+		return nil, err
+	}
+	for i := range overrides {
+		s.db.Table("users").Where("id = ?", overrides[i].UserID).Pluck("full_name", &overrides[i].UserName)
+		s.db.Table("users").Where("id = ?", overrides[i].PharmacistID).Pluck("full_name", &overrides[i].PharmacistName)
+	}
+	return overrides, nil
+}
@@ -25,7 +25,7 @@ func (h *Handler) CreateCategory(c *gin.Context) {
 	}
 	newCategory, err := h.service.CreateCategory(&input)
 	if err != nil {
-		if errors.Is(err, ErrInvalidInput) || errors.Is(err, ErrNameExists) {
+		if errors.Is(err, ErrInvalidInput) || errors.Is(err, ErrNameExists) || errors.Is(err, ErrControlClass) || errors.Is(err, ErrOWALimit) {
 			utils.Respond(c, http.StatusBadRequest, err.Error(), err.Error(), nil)
 		} else {
 			utils.Respond(c, http.StatusInternalServerError, "Gagal membuat golongan obat", err.Error(), nil)
@@ -69,7 +69,7 @@ func (h *Handler) UpdateCategory(c *gin.Context) {
 	if err != nil {
 		if errors.Is(err, ErrNotFound) {
 			utils.Respond(c, http.StatusNotFound, err.Error(), err.Error(), nil)
-		} else if errors.Is(err, ErrNameExists) {
+		} else if errors.Is(err, ErrNameExists) || errors.Is(err, ErrControlClass) || errors.Is(err, ErrOWALimit) {
 			utils.Respond(c, http.StatusBadRequest, err.Error(), err.Error(), nil)
 		} else {
 			utils.Respond(c, http.StatusInternalServerError, "Gagal memperbarui data golongan obat", err.Error(), nil)
@@ -1,8 +1,94 @@
 package drug_category
 
+import (
+	"strings"
+	"unicode"
+)
+
 type DrugCategory struct {
 	ID          uint   `gorm:"primaryKey" json:"id"`
 	Name        string `gorm:"type:varchar(255);not null" json:"name" form:"name"`
 	Description string `gorm:"type:text" json:"description,omitempty" form:"description"`
 	Status      string `gorm:"type:varchar(20);not null;default:'Aktif'" json:"status" form:"status"`
+	// ControlClass menandai golongan yang wajib dicatat pada register
+	// narkotika/psikotropika. Kosong berarti mengikuti nama golongan.
+	ControlClass string `gorm:"type:varchar(20)" json:"control_class" form:"control_class"`
+
+	// OWALimit adalah jumlah maksimum per produk dalam satu transaksi untuk
+	// Obat Wajib Apotek yang diserahkan tanpa resep. 0 berarti tanpa batas.
+	OWALimit int `gorm:"not null;default:0" json:"owa_limit" form:"owa_limit"`
+}
+
+// Nilai ControlClass
+const (
+	ClassNarcotic     = "Narkotika"
+	ClassPsychotropic = "Psikotropika"
+	// ClassNone menyatakan golongan tidak diawasi walaupun namanya memuat
+	// kata narkotika/psikotropika
+	ClassNone = "Bukan"
+)
+
+// Controlled mengembalikan ClassNarcotic atau ClassPsychotropic untuk
+// golongan yang diawasi, selain itu string kosong
+func (d DrugCategory) Controlled() string {
+	switch d.ControlClass {
+	case ClassNarcotic, ClassPsychotropic:
+		return d.ControlClass
+	case ClassNone:
+		return ""
+	}
+	name := strings.ToLower(d.Name)
+	switch {
+	case strings.Contains(name, "narkotika"):
+		return ClassNarcotic
+	case strings.Contains(name, "psikotropika"):
+		return ClassPsychotropic
+	}
+	return ""
+}
+
+// Penggolongan obat untuk penyerahan
+const (
+	ClassFree    = "Bebas"
+	ClassLimited = "Bebas Terbatas"
+	ClassHard    = "Keras"
+	// ClassOWA adalah Obat Wajib Apotek: obat keras yang boleh diserahkan
+	// apoteker tanpa resep dalam jumlah terbatas
+	ClassOWA = "OWA"
+)
+
+// Classification menurunkan golongan penyerahan dari ControlClass dan nama
+// golongan. Golongan yang tidak dikenali dianggap obat bebas.
+func (d DrugCategory) Classification() string {
+	if class := d.Controlled(); class != "" {
+		return class
+	}
+	name := strings.ToLower(d.Name)
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for _, w := range words {
+		if w == "owa" {
+			return ClassOWA
+		}
+	}
+	switch {
+	case strings.Contains(name, "wajib apotek"):
+		return ClassOWA
+	case strings.Contains(name, "keras"):
+		return ClassHard
+	case strings.Contains(name, "terbatas"):
+		return ClassLimited
+	}
+	return ClassFree
+}
+
+// RequiresPrescription menandakan golongan obat yang hanya boleh diserahkan
+// dengan resep dokter (obat keras, psikotropika, narkotika)
+func (d DrugCategory) RequiresPrescription() bool {
+	switch d.Classification() {
+	case ClassHard, ClassNarcotic, ClassPsychotropic:
+		return true
+	}
+	return false
 }
@@ -11,6 +11,8 @@ var (
 	ErrNotFound     = errors.New("golongan obat tidak ditemukan")
 	ErrInvalidInput = errors.New("input tidak valid, nama tidak boleh kosong")
 	ErrNameExists   = errors.New("nama golongan obat yang aktif sudah ada")
+	ErrControlClass = errors.New("control_class harus kosong, Narkotika, Psikotropika atau Bukan")
+	ErrOWALimit     = errors.New("owa_limit tidak boleh negatif")
 )
 
 type Service interface {
@@ -34,6 +36,12 @@ func (s *service) CreateCategory(category *DrugCategory) (*DrugCategory, error)
 	if category.Name == "" {
 		return nil, ErrInvalidInput
 	}
+	if !validControlClass(category.ControlClass) {
+		return nil, ErrControlClass
+	}
+	if category.OWALimit < 0 {
+		return nil, ErrOWALimit
+	}
 
 	existing, err := s.repository.FindActiveByName(category.Name)
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
@@ -54,6 +62,12 @@ func (s *service) UpdateCategory(id uint, category *DrugCategory) (*DrugCategory
 	if _, err := s.repository.GetByID(id); err != nil {
 		return nil, err
 	}
+	if !validControlClass(category.ControlClass) {
+		return nil, ErrControlClass
+	}
+	if category.OWALimit < 0 {
+		return nil, ErrOWALimit
+	}
 
 	if category.Name != "" {
 		category.Name = strings.TrimSpace(category.Name)
@@ -83,3 +97,11 @@ func (s *service) DeleteCategory(id uint) error {
 	}
 	return s.repository.Delete(id)
 }
+
+func validControlClass(class string) bool {
+	switch class {
+	case "", ClassNarcotic, ClassPsychotropic, ClassNone:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"go-gin-auth/config"
+	"go-gin-auth/utils"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Header yang dibaca dari klien dan ditandai pada respons ulang
+const (
+	HeaderKey      = "Idempotency-Key"
+	HeaderReplayed = "Idempotent-Replayed"
+)
+
+// TTL adalah lama respons disimpan untuk diputar ulang
+const TTL = 24 * time.Hour
+
+var (
+	ErrKeyReused  = errors.New("Idempotency-Key sudah dipakai untuk payload yang berbeda")
+	ErrInProgress = errors.New("permintaan dengan Idempotency-Key yang sama masih diproses")
+)
+
+// Middleware membuat endpoint pembuat dokumen aman diulang. Permintaan
+// pertama dengan sebuah key diproses biasa dan respons suksesnya disimpan;
+// permintaan berikutnya dengan key dan payload yang sama menerima respons
+// tersimpan tanpa menjalankan handler lagi. Tanpa header, endpoint berjalan
+// seperti biasa. Harus dipasang setelah middleware autentikasi.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(HeaderKey)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > 255 {
+			utils.Respond(c, http.StatusBadRequest, "Idempotency-Key maksimal 255 karakter", nil, nil)
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			utils.Respond(c, http.StatusBadRequest, "Gagal membaca payload", err.Error(), nil)
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(body)
+
+		// scope memakai path sebenarnya, bukan pola route, agar key yang sama
+		// pada resource berbeda (misalnya /held/1 dan /held/2) tidak saling
+		// memutar ulang respons
+		record := Record{
+			Key:         key,
+			Scope:       c.Request.Method + " " + c.Request.URL.Path,
+			UserID:      utils.GetCurrentUserID(c),
+			RequestHash: hex.EncodeToString(sum[:]),
+			Status:      StatusProcessing,
+		}
+		claimed, err := claim(config.DB, &record)
+		if err != nil {
+			utils.Respond(c, http.StatusInternalServerError, "Gagal memeriksa Idempotency-Key", err.Error(), nil)
+			c.Abort()
+			return
+		}
+		if !claimed {
+			replay(c, config.DB, &record)
+			return
+		}
+
+		// respons gagal (termasuk panic) tidak disimpan agar klien dapat
+		// mencoba lagi dengan key yang sama setelah masalahnya diperbaiki
+		defer func() {
+			if record.Status != StatusCompleted {
+				config.DB.Delete(&record)
+			}
+		}()
+
+		writer := &recorder{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		status := writer.Status()
+		if status < 200 || status >= 300 {
+			return
+		}
+		record.Status = StatusCompleted
+		config.DB.Model(&record).Updates(map[string]interface{}{
+			"status":          StatusCompleted,
+			"response_status": status,
+			"response_body":   writer.body.Bytes(),
+			"content_type":    writer.Header().Get("Content-Type"),
+		})
+	}
+}
+
+// claim menyimpan key sebagai sedang diproses. Nilai false berarti key sudah
+// dipakai permintaan lain yang masih berlaku.
+func claim(db *gorm.DB, record *Record) (bool, error) {
+	err := db.Where("key = ? AND scope = ? AND user_id = ? AND created_at < ?",
+		record.Key, record.Scope, record.UserID, time.Now().Add(-TTL)).
+		Delete(&Record{}).Error
+	if err != nil {
+		return false, err
+	}
+
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// replay mengirim ulang respons tersimpan, atau menolak bila payload berbeda
+// atau permintaan pertama belum selesai
+func replay(c *gin.Context, db *gorm.DB, incoming *Record) {
+	defer c.Abort()
+
+	var stored Record
+	err := db.Where("key = ? AND scope = ? AND user_id = ?", incoming.Key, incoming.Scope, incoming.UserID).
+		First(&stored).Error
+	if err != nil {
+		utils.Respond(c, http.StatusInternalServerError, "Gagal memeriksa Idempotency-Key", err.Error(), nil)
+		return
+	}
+
+	switch {
+	case stored.RequestHash != incoming.RequestHash:
+		utils.Respond(c, http.StatusUnprocessableEntity, ErrKeyReused.Error(), ErrKeyReused.Error(), nil)
+	case stored.Status != StatusCompleted:
+		utils.Respond(c, http.StatusConflict, ErrInProgress.Error(), ErrInProgress.Error(), nil)
+	default:
+		c.Header(HeaderReplayed, "true")
+		c.Data(stored.ResponseStatus, stored.ContentType, stored.ResponseBody)
+	}
+}
+
+// recorder menyalin body respons sambil tetap menuliskannya ke klien
+type recorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *recorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,30 @@
+package idempotency
+
+import "time"
+
+// Status rekaman idempotency
+const (
+	StatusProcessing = "processing"
+	StatusCompleted  = "completed"
+)
+
+// Record menyimpan respons pertama untuk satu Idempotency-Key. Kunci berlaku
+// per pengguna dan per endpoint, sehingga key yang sama dari kasir lain atau
+// ke endpoint lain tidak saling bertabrakan.
+type Record struct {
+	ID             uint      `gorm:"primaryKey"`
+	Key            string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_idempotency_key"`
+	Scope          string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_idempotency_key"`
+	UserID         uint      `gorm:"not null;uniqueIndex:idx_idempotency_key"`
+	RequestHash    string    `gorm:"type:char(64);not null"`
+	Status         string    `gorm:"type:varchar(20);not null"`
+	ResponseStatus int       `gorm:"not null;default:0"`
+	ResponseBody   []byte    `gorm:"type:bytea"`
+	ContentType    string    `gorm:"type:varchar(100)"`
+	CreatedAt      time.Time `gorm:"index"`
+	UpdatedAt      time.Time
+}
+
+func (Record) TableName() string {
+	return "idempotency_keys"
+}
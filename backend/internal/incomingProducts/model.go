@@ -1,5 +1,7 @@
 package incomingProducts
 
+import "time"
+
 type IncomingProduct struct {
 	ID            uint    `gorm:"primaryKey" json:"id"`
 	Date          string  `gorm:"type:date;not null;comment:Tanggal" json:"date"`
@@ -9,6 +11,21 @@ type IncomingProduct struct {
 	TotalAmount   float64 `gorm:"-" json:"total_amount"`
 }
 
+// receivedAt mengubah kolom tanggal menjadi waktu penerimaan; tanggal yang
+// tidak dapat dibaca dikembalikan sebagai nol sehingga register memakai
+// waktu pencatatan
+func (p *IncomingProduct) receivedAt() time.Time {
+	date := p.Date
+	if len(date) > 10 {
+		date = date[:10]
+	}
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
 type IncomingProductDetail struct {
 	ID                uint    `gorm:"primaryKey" json:"id"`
 	IncomingProductID uint    `gorm:"not null;comment:ID Produk Masuk" json:"incoming_product_id"`
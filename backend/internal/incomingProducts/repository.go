@@ -3,6 +3,8 @@ package incomingProducts
 import (
 	"errors"
 	"go-gin-auth/config"
+	"go-gin-auth/internal/narcotic"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -39,12 +41,18 @@ func (r *repository) Create(incomingProduct *IncomingProduct, details []Incoming
 		return err
 	}
 
+	lines := make([]narcotic.Line, 0, len(details))
 	for i := range details {
 		details[i].IncomingProductID = incomingProduct.ID
 		if err := tx.Create(&details[i]).Error; err != nil {
 			tx.Rollback()
 			return err
 		}
+		lines = append(lines, narcotic.Line{ProductID: details[i].ProductID, QtyIn: details[i].Quantity})
+	}
+	if err := recordNarcotic(tx, incomingProduct, narcotic.TypeReceipt, lines); err != nil {
+		tx.Rollback()
+		return err
 	}
 
 	return tx.Commit().Error
@@ -131,12 +139,18 @@ func (r *repository) UpdateDetails(details []IncomingProductDetail) error {
 		}
 	}()
 
+	var added, removed []narcotic.Line
 	for _, detail := range details {
 		var existingDetail IncomingProductDetail
 		if err := tx.Where("incoming_product_id = ? AND product_id = ?", detail.IncomingProductID, detail.ProductID).First(&existingDetail).Error; err != nil {
 			tx.Rollback()
 			return err
 		}
+		if diff := detail.Quantity - existingDetail.Quantity; diff > 0 {
+			added = append(added, narcotic.Line{ProductID: detail.ProductID, QtyIn: diff})
+		} else if diff < 0 {
+			removed = append(removed, narcotic.Line{ProductID: detail.ProductID, QtyOut: -diff})
+		}
 
 		existingDetail.ProductID = detail.ProductID
 		existingDetail.Quantity = detail.Quantity
@@ -149,6 +163,22 @@ func (r *repository) UpdateDetails(details []IncomingProductDetail) error {
 		}
 	}
 
+	if len(added) > 0 || len(removed) > 0 {
+		var header IncomingProduct
+		if err := tx.First(&header, details[0].IncomingProductID).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := recordNarcotic(tx, &header, narcotic.TypeReceipt, added); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := recordNarcotic(tx, &header, narcotic.TypeCancellation, removed); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
 	return tx.Commit().Error
 }
 
@@ -160,6 +190,25 @@ func (r *repository) Delete(id uint) error {
 		}
 	}()
 
+	var header IncomingProduct
+	if err := tx.First(&header, id).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	var details []IncomingProductDetail
+	if err := tx.Where("incoming_product_id = ?", id).Find(&details).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	lines := make([]narcotic.Line, 0, len(details))
+	for _, detail := range details {
+		lines = append(lines, narcotic.Line{ProductID: detail.ProductID, QtyOut: detail.Quantity})
+	}
+	if err := recordNarcotic(tx, &header, narcotic.TypeCancellation, lines); err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	// Hapus detail terlebih dahulu
 	if err := tx.Where("incoming_product_id = ?", id).Delete(&IncomingProductDetail{}).Error; err != nil {
 		tx.Rollback()
@@ -185,3 +234,24 @@ func (r *repository) GetDetailByIncomingProductID(incomingProductID uint) (*Inco
 	}
 	return &detail, nil
 }
+
+// recordNarcotic meneruskan penerimaan atau pembatalan produk masuk ke
+// register narkotika/psikotropika. Dipanggil setelah stok diperbarui.
+func recordNarcotic(tx *gorm.DB, header *IncomingProduct, movementType string, lines []narcotic.Line) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	date := time.Now()
+	if movementType == narcotic.TypeReceipt {
+		date = header.receivedAt()
+	}
+	return narcotic.Record(tx, narcotic.Movement{
+		Type:    movementType,
+		RefType: "incoming_product",
+		RefID:   header.ID,
+		RefCode: header.NoFaktur,
+		Date:    date,
+		Party:   header.Supplier,
+		Lines:   lines,
+	})
+}
@@ -2,6 +2,8 @@ package incomingProducts
 
 import (
 	"errors"
+	"go-gin-auth/config"
+	"go-gin-auth/internal/opname"
 	"go-gin-auth/internal/stock"
 )
 
@@ -41,6 +43,9 @@ func (s *service) CreateIncomingProduct(incomingProduct *IncomingProduct, detail
 	if len(details) == 0 {
 		return errors.New("detail produk masuk tidak boleh kosong")
 	}
+	if err := opname.EnsureNotFrozen(config.DB, detailProductIDs(details)); err != nil {
+		return err
+	}
 
 	// Hitung total setiap detail
 	for i := range details {
@@ -101,6 +106,9 @@ func (s *service) UpdateIncomingProductDetails(details []IncomingProductDetail)
 	if len(details) == 0 {
 		return errors.New("detail produk masuk tidak boleh kosong")
 	}
+	if err := opname.EnsureNotFrozen(config.DB, detailProductIDs(details)); err != nil {
+		return err
+	}
 
 	// Hitung total setiap detail
 	for i := range details {
@@ -117,7 +125,9 @@ func (s *service) UpdateIncomingProductDetails(details []IncomingProductDetail)
 		// Hitung total
 		details[i].Total = float64(details[i].Quantity) * details[i].Price
 
-		existingDetail, err := s.repository.GetDetailByIncomingProductID(details[i].IncomingProductID)
+		// stok dikoreksi dari detail produk yang sama agar sesuai dengan
+		// selisih yang dicatat ke register narkotika
+		existingDetail, err := s.existingDetail(details[i].IncomingProductID, details[i].ProductID)
 		if err != nil {
 			return errors.New("gagal mendapatkan detail produk masuk")
 		}
@@ -136,11 +146,27 @@ func (s *service) UpdateIncomingProductDetails(details []IncomingProductDetail)
 	return s.repository.UpdateDetails(details)
 }
 
+func (s *service) existingDetail(incomingProductID, productID uint) (*IncomingProductDetail, error) {
+	details, err := s.repository.GetDetailsByIncomingProductID(incomingProductID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range details {
+		if details[i].ProductID == productID {
+			return &details[i], nil
+		}
+	}
+	return nil, errors.New("incoming product detail not found")
+}
+
 func (s *service) DeleteIncomingProduct(id uint) error {
 	details, err := s.repository.GetDetailsByIncomingProductID(id)
 	if err != nil {
 		return err
 	}
+	if err := opname.EnsureNotFrozen(config.DB, detailProductIDs(details)); err != nil {
+		return err
+	}
 
 	for _, detail := range details {
 		if err := s.repositoryStock.UpdateProductStock(detail.ProductID, detail.Quantity, false); err != nil {
@@ -150,3 +176,11 @@ func (s *service) DeleteIncomingProduct(id uint) error {
 
 	return s.repository.Delete(id)
 }
+
+func detailProductIDs(details []IncomingProductDetail) []uint {
+	ids := make([]uint, 0, len(details))
+	for _, detail := range details {
+		ids = append(ids, detail.ProductID)
+	}
+	return ids
+}
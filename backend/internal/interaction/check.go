@@ -0,0 +1,319 @@
+package interaction
+
+import (
+	"errors"
+	"fmt"
+	"go-gin-auth/internal/payment"
+	"go-gin-auth/service"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// DefaultDays adalah rentang riwayat penyerahan pasien yang ikut diperiksa
+const DefaultDays = 30
+
+var (
+	// ErrOverrideRequired dikembalikan bila ada peringatan Berat dan apoteker
+	// belum mengisi alasan dan login untuk tetap melayani
+	ErrOverrideRequired = errors.New("terdapat interaksi atau kontraindikasi berat, isi alasan dan login apoteker untuk tetap melayani")
+	// ErrPharmacistRejected dikembalikan bila login apoteker yang menyetujui
+	// tidak valid atau akunnya bukan apoteker aktif
+	ErrPharmacistRejected = errors.New("login apoteker tidak valid atau akun bukan apoteker aktif")
+)
+
+// BlockedError membawa peringatan yang menahan penyimpanan resep
+type BlockedError struct {
+	Warnings []Warning
+}
+
+func (e *BlockedError) Error() string {
+	return ErrOverrideRequired.Error()
+}
+
+func (e *BlockedError) Is(target error) bool {
+	return target == ErrOverrideRequired
+}
+
+// Check memeriksa interaksi antar produk yang akan diserahkan, antara produk
+// tersebut dan riwayat penyerahan pasien selama req.Days hari terakhir, serta
+// kontraindikasi terhadap diagnosis
+func Check(db *gorm.DB, req CheckRequest) ([]Warning, error) {
+	var rules []Rule
+	if err := db.Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	warnings := []Warning{}
+	if len(rules) == 0 || len(req.ProductIDs) == 0 {
+		return warnings, nil
+	}
+
+	current, err := loadProducts(db, req.ProductIDs)
+	if err != nil {
+		return nil, err
+	}
+	var history []Product
+	if req.PatientID != nil {
+		days := req.Days
+		if days <= 0 {
+			days = DefaultDays
+		}
+		history, err = patientHistory(db, *req.PatientID, time.Now().AddDate(0, 0, -days), req.ExcludeSaleID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	diagnosis := normalize(req.Diagnosis)
+
+	seen := make(map[string]bool)
+	add := func(w Warning) {
+		key := fmt.Sprintf("%d|%d", w.RuleID, w.Product.ProductID)
+		if w.Other != nil {
+			key += fmt.Sprintf("|%d|%t", w.Other.ProductID, w.Other.History)
+		}
+		if !seen[key] {
+			seen[key] = true
+			warnings = append(warnings, w)
+		}
+	}
+
+	for _, rule := range rules {
+		if rule.Kind == KindContraindication {
+			if diagnosis == "" || !containsTerm(diagnosis, rule.IngredientB) {
+				continue
+			}
+			for _, p := range current {
+				if containsTerm(p.text, rule.IngredientA) {
+					add(warning(rule, p, nil))
+				}
+			}
+			continue
+		}
+
+		for i, p := range current {
+			for _, other := range current[i+1:] {
+				if p.ProductID != other.ProductID && pairMatches(rule, p, other) {
+					o := other
+					add(warning(rule, p, &o))
+				}
+			}
+			for _, other := range history {
+				if p.ProductID != other.ProductID && pairMatches(rule, p, other) {
+					o := other
+					add(warning(rule, p, &o))
+				}
+			}
+		}
+	}
+
+	sortWarnings(warnings)
+	return warnings, nil
+}
+
+// RequiresOverride bernilai true bila ada peringatan Berat
+func RequiresOverride(warnings []Warning) bool {
+	for _, w := range warnings {
+		if w.Severity == SeverityMajor {
+			return true
+		}
+	}
+	return false
+}
+
+// Guard memeriksa peringatan sebelum penjualan disimpan: peringatan Berat
+// tanpa alasan dan login apoteker menghasilkan BlockedError. Peringatan
+// yang lebih ringan hanya dikembalikan untuk ditampilkan. Nilai kedua
+// adalah ID apoteker yang menyetujui.
+func Guard(db *gorm.DB, req CheckRequest, approval Approval) ([]Warning, uint, error) {
+	warnings, err := Check(db, req)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !RequiresOverride(warnings) {
+		return warnings, 0, nil
+	}
+	if strings.TrimSpace(approval.Reason) == "" || approval.Email == "" {
+		return warnings, 0, &BlockedError{Warnings: warnings}
+	}
+	pharmacist, err := service.VerifyApprover(db, approval.Email, approval.Password, service.PharmacistRoles...)
+	if errors.Is(err, service.ErrInvalidApprover) || errors.Is(err, service.ErrApproverLocked) {
+		return warnings, 0, fmt.Errorf("%w: %v", ErrPharmacistRejected, err)
+	}
+	if err != nil {
+		return warnings, 0, err
+	}
+	return warnings, pharmacist.ID, nil
+}
+
+// RecordOverride menyimpan alasan dan apoteker yang menyetujui bersama
+// peringatan yang diabaikan. Tidak ada yang dicatat bila tidak ada
+// peringatan.
+func RecordOverride(tx *gorm.DB, saleType string, saleID, userID, pharmacistID uint, reason string, warnings []Warning) error {
+	reason = strings.TrimSpace(reason)
+	if len(warnings) == 0 || reason == "" {
+		return nil
+	}
+	return tx.Create(&Override{
+		SaleType:     saleType,
+		SaleID:       saleID,
+		UserID:       userID,
+		Reason:       reason,
+		Warnings:     warnings,
+		PharmacistID: pharmacistID,
+	}).Error
+}
+
+func warning(rule Rule, p Product, other *Product) Warning {
+	w := Warning{
+		RuleID:      rule.ID,
+		Kind:        rule.Kind,
+		Severity:    rule.Severity,
+		Product:     p,
+		Other:       other,
+		Ingredients: []string{rule.IngredientA, rule.IngredientB},
+		Description: rule.Description,
+		Management:  rule.Management,
+	}
+	if rule.Kind == KindContraindication {
+		w.Ingredients = []string{rule.IngredientA}
+		w.Condition = rule.IngredientB
+	}
+	return w
+}
+
+func pairMatches(rule Rule, a, b Product) bool {
+	return (containsTerm(a.text, rule.IngredientA) && containsTerm(b.text, rule.IngredientB)) ||
+		(containsTerm(a.text, rule.IngredientB) && containsTerm(b.text, rule.IngredientA))
+}
+
+// loadProducts mengambil nama dan komposisi produk sebagai teks pencocokan
+func loadProducts(db *gorm.DB, ids []uint) ([]Product, error) {
+	var rows []struct {
+		ID                     uint
+		Name                   string
+		CompositionDescription string
+	}
+	if err := db.Table("products").Select("id, name, composition_description").
+		Where("id IN ?", ids).Order("id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	products := make([]Product, 0, len(rows))
+	for _, r := range rows {
+		products = append(products, Product{
+			ProductID: r.ID,
+			Name:      r.Name,
+			text:      normalize(r.Name + " " + r.CompositionDescription),
+		})
+	}
+	return products, nil
+}
+
+// patientHistory mengambil produk yang diserahkan kepada pasien sejak since
+// dari resep (item dan komponen racikan) dan penjualan reguler pelanggan
+// yang terhubung ke pasien. Penjualan yang dibatalkan dilewati.
+func patientHistory(db *gorm.DB, patientID uint, since time.Time, excludeSaleID uint) ([]Product, error) {
+	var rows []struct {
+		ProductID              uint
+		Name                   string
+		CompositionDescription string
+		SaleCode               string
+		SaleDate               time.Time
+	}
+	err := db.Raw(`
+		SELECT h.product_id, p.name, p.composition_description, h.sale_code, h.sale_date
+		FROM (
+			SELECT st.product_id, s.transaction_code AS sale_code, s.transaction_date AS sale_date
+			FROM prescription_items i
+			JOIN prescription_sales s ON s.id = i.prescription_sale_id
+			JOIN stocks st ON st.id = i.stock_id
+			WHERE s.patient_id = ? AND s.transaction_date >= ? AND s.id <> ?
+			AND s.status <> ? AND s.deleted_at IS NULL AND i.deleted_at IS NULL
+			UNION ALL
+			SELECT k.product_id, s.transaction_code, s.transaction_date
+			FROM prescription_compound_components k
+			JOIN prescription_compounds c ON c.id = k.compound_id
+			JOIN prescription_sales s ON s.id = c.prescription_sale_id
+			WHERE s.patient_id = ? AND s.transaction_date >= ? AND s.id <> ?
+			AND s.status <> ? AND s.deleted_at IS NULL AND c.deleted_at IS NULL AND k.deleted_at IS NULL
+			UNION ALL
+			SELECT i.product_id, s.sales_code, s.transaction_date
+			FROM sales_regular_items i
+			JOIN sales_regulars s ON s.id = i.sales_regular_id
+			JOIN customers cu ON cu.id = s.customer_id
+			WHERE cu.patient_id = ? AND s.transaction_date >= ?
+			AND s.status <> ? AND s.deleted_at IS NULL AND i.deleted_at IS NULL
+		) h
+		JOIN products p ON p.id = h.product_id
+		ORDER BY h.sale_date DESC
+	`, patientID, since, excludeSaleID, payment.SaleVoided,
+		patientID, since, excludeSaleID, payment.SaleVoided,
+		patientID, since, payment.SaleVoided).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// cukup penyerahan terakhir untuk setiap produk
+	seen := make(map[uint]bool)
+	products := []Product{}
+	for _, r := range rows {
+		if seen[r.ProductID] {
+			continue
+		}
+		seen[r.ProductID] = true
+		date := r.SaleDate
+		products = append(products, Product{
+			ProductID: r.ProductID,
+			Name:      r.Name,
+			History:   true,
+			SaleCode:  r.SaleCode,
+			SaleDate:  &date,
+			text:      normalize(r.Name + " " + r.CompositionDescription),
+		})
+	}
+	return products, nil
+}
+
+// normalize menyeragamkan teks menjadi huruf kecil dengan satu spasi
+func normalize(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+// containsTerm mencari term sebagai kata utuh, sehingga "metformin" tidak
+// cocok dengan "metformina" dan sebaliknya
+func containsTerm(text, term string) bool {
+	if term == "" {
+		return false
+	}
+	for start := 0; ; {
+		i := strings.Index(text[start:], term)
+		if i < 0 {
+			return false
+		}
+		i += start
+		end := i + len(term)
+		if boundary(text, i-1) && boundary(text, end) {
+			return true
+		}
+		start = i + 1
+	}
+}
+
+func boundary(text string, i int) bool {
+	if i < 0 || i >= len(text) {
+		return true
+	}
+	r := rune(text[i])
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+var severityRank = map[string]int{SeverityMajor: 0, SeverityModerate: 1, SeverityMinor: 2}
+
+// sortWarnings mengurutkan peringatan dari yang paling berat
+func sortWarnings(warnings []Warning) {
+	sort.SliceStable(warnings, func(i, j int) bool {
+		return severityRank[warnings[i].Severity] < severityRank[warnings[j].Severity]
+	})
+}
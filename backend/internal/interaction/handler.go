@@ -0,0 +1,145 @@
+package interaction
+
+import (
+	"errors"
+	"go-gin-auth/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) GetAll(c *gin.Context) {
+	rules, err := h.service.GetAll(Filter{
+		Search:   c.Query("search"),
+		Kind:     c.Query("kind"),
+		Severity: c.Query("severity"),
+	})
+	if err != nil {
+		utils.Respond(c, http.StatusInternalServerError, "Gagal mengambil daftar interaksi obat", err.Error(), nil)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Daftar interaksi obat berhasil diambil", nil, rules)
+}
+
+func (h *Handler) GetByID(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	rule, err := h.service.GetByID(uint(id))
+	if err != nil {
+		h.respondError(c, "Gagal mengambil interaksi obat", err)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Detail interaksi obat berhasil diambil", nil, rule)
+}
+
+func (h *Handler) Create(c *gin.Context) {
+	var input Rule
+	if err := c.ShouldBind(&input); err != nil {
+		utils.Respond(c, http.StatusBadRequest, "Input tidak valid", err.Error(), nil)
+		return
+	}
+	rule, err := h.service.Create(&input)
+	if err != nil {
+		h.respondError(c, "Gagal membuat interaksi obat", err)
+		return
+	}
+	utils.Respond(c, http.StatusCreated, "Interaksi obat berhasil dibuat", nil, rule)
+}
+
+func (h *Handler) Update(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var input Rule
+	if err := c.ShouldBind(&input); err != nil {
+		utils.Respond(c, http.StatusBadRequest, "Input tidak valid", err.Error(), nil)
+		return
+	}
+	rule, err := h.service.Update(uint(id), &input)
+	if err != nil {
+		h.respondError(c, "Gagal memperbarui interaksi obat", err)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Interaksi obat berhasil diperbarui", nil, rule)
+}
+
+func (h *Handler) Delete(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err := h.service.Delete(uint(id)); err != nil {
+		h.respondError(c, "Gagal menghapus interaksi obat", err)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Interaksi obat berhasil dihapus", nil, nil)
+}
+
+// Import menerima berkas CSV pada field form "file"
+func (h *Handler) Import(c *gin.Context) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		utils.Respond(c, http.StatusBadRequest, "Berkas CSV wajib diunggah pada field file", err.Error(), nil)
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		utils.Respond(c, http.StatusBadRequest, "Berkas CSV tidak dapat dibaca", err.Error(), nil)
+		return
+	}
+	defer file.Close()
+
+	result, err := h.service.Import(file)
+	if err != nil {
+		h.respondError(c, "Gagal mengimpor interaksi obat", err)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Impor interaksi obat selesai", nil, result)
+}
+
+// Check dipakai layar penjualan untuk menampilkan peringatan sebelum
+// transaksi disimpan
+func (h *Handler) Check(c *gin.Context) {
+	var req CheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Respond(c, http.StatusBadRequest, "Input tidak valid", err.Error(), nil)
+		return
+	}
+	warnings, err := Check(h.service.db, req)
+	if err != nil {
+		utils.Respond(c, http.StatusInternalServerError, "Gagal memeriksa interaksi obat", err.Error(), nil)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Pemeriksaan interaksi obat selesai", nil, gin.H{
+		"warnings":          warnings,
+		"requires_override": RequiresOverride(warnings),
+	})
+}
+
+func (h *Handler) Overrides(c *gin.Context) {
+	saleID, _ := strconv.ParseUint(c.Query("sale_id"), 10, 32)
+	overrides, err := h.service.Overrides(c.Query("sale_type"), uint(saleID))
+	if err != nil {
+		utils.Respond(c, http.StatusInternalServerError, "Gagal mengambil catatan alasan apoteker", err.Error(), nil)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Catatan alasan apoteker berhasil diambil", nil, overrides)
+}
+
+func (h *Handler) respondError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		utils.Respond(c, http.StatusNotFound, "Interaksi obat tidak ditemukan", err.Error(), nil)
+	case errors.Is(err, ErrInvalidKind), errors.Is(err, ErrInvalidSeverity),
+		errors.Is(err, ErrIngredients), errors.Is(err, ErrCSVHeader):
+		utils.Respond(c, http.StatusBadRequest, err.Error(), err.Error(), nil)
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		utils.Respond(c, http.StatusConflict, "Pasangan zat aktif sudah terdaftar", err.Error(), nil)
+	default:
+		utils.Respond(c, http.StatusInternalServerError, message, err.Error(), nil)
+	}
+}
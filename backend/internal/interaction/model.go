@@ -0,0 +1,111 @@
+package interaction
+
+import "time"
+
+// Jenis aturan pada basis pengetahuan
+const (
+	// KindInteraction adalah interaksi antara dua zat aktif
+	KindInteraction = "interaksi"
+	// KindContraindication adalah zat aktif yang dikontraindikasikan untuk
+	// suatu kondisi; IngredientB berisi kata kunci kondisi/diagnosis
+	KindContraindication = "kontraindikasi"
+)
+
+// Tingkat keparahan, dari yang paling ringan
+const (
+	SeverityMinor    = "Ringan"
+	SeverityModerate = "Sedang"
+	SeverityMajor    = "Berat"
+)
+
+// Rule adalah satu baris basis pengetahuan interaksi obat yang dikelola
+// apotek sendiri. Zat aktif dicocokkan dengan nama dan komposisi produk,
+// disimpan dalam huruf kecil, dan untuk interaksi disimpan berurutan
+// sehingga pasangan A-B dan B-A adalah aturan yang sama.
+type Rule struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Kind        string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_drug_interaction_pair" json:"kind" form:"kind"`
+	IngredientA string    `gorm:"type:varchar(150);not null;uniqueIndex:idx_drug_interaction_pair" json:"ingredient_a" form:"ingredient_a"`
+	IngredientB string    `gorm:"type:varchar(150);not null;uniqueIndex:idx_drug_interaction_pair" json:"ingredient_b" form:"ingredient_b"`
+	Severity    string    `gorm:"type:varchar(10);not null;index" json:"severity" form:"severity"`
+	Description string    `gorm:"type:text" json:"description" form:"description"`
+	Management  string    `gorm:"type:text" json:"management" form:"management"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (Rule) TableName() string {
+	return "drug_interactions"
+}
+
+// Product adalah produk yang diperiksa beserta asalnya: resep yang sedang
+// diinput atau riwayat penyerahan pasien
+type Product struct {
+	ProductID uint       `json:"product_id"`
+	Name      string     `json:"name"`
+	History   bool       `json:"history"`
+	SaleCode  string     `json:"sale_code,omitempty"`
+	SaleDate  *time.Time `json:"sale_date,omitempty"`
+
+	text string
+}
+
+// Warning adalah hasil pemeriksaan satu aturan. Untuk kontraindikasi,
+// Other kosong dan Condition berisi kondisi yang cocok dengan diagnosis.
+type Warning struct {
+	RuleID      uint     `json:"rule_id"`
+	Kind        string   `json:"kind"`
+	Severity    string   `json:"severity"`
+	Product     Product  `json:"product"`
+	Other       *Product `json:"other,omitempty"`
+	Condition   string   `json:"condition,omitempty"`
+	Ingredients []string `json:"ingredients"`
+	Description string   `json:"description"`
+	Management  string   `json:"management"`
+}
+
+// Approval adalah alasan beserta login ulang apoteker yang mengizinkan
+// resep dengan peringatan Berat tetap dilayani
+type Approval struct {
+	Reason   string
+	Email    string
+	Password string
+}
+
+// Override mencatat alasan apoteker tetap melayani resep meski ada
+// peringatan interaksi. UserID adalah kasir, PharmacistID apoteker yang
+// menyetujui.
+type Override struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	SaleType  string    `gorm:"type:varchar(20);not null;index:idx_interaction_override_sale" json:"sale_type"`
+	SaleID    uint      `gorm:"not null;index:idx_interaction_override_sale" json:"sale_id"`
+	UserID    uint      `gorm:"not null" json:"user_id"`
+	UserName  string    `gorm:"-" json:"user_name,omitempty"`
+	Reason    string    `gorm:"type:text;not null" json:"reason"`
+	Warnings  []Warning `gorm:"serializer:json;type:text" json:"warnings"`
+	CreatedAt time.Time `json:"created_at"`
+
+	PharmacistID   uint   `gorm:"not null;default:0" json:"pharmacist_id"`
+	PharmacistName string `gorm:"-" json:"pharmacist_name,omitempty"`
+}
+
+func (Override) TableName() string {
+	return "drug_interaction_overrides"
+}
+
+// CheckRequest adalah produk yang akan diserahkan beserta konteks pasien.
+// ExcludeSaleID dilewati dari riwayat saat penjualan resep diubah.
+type CheckRequest struct {
+	PatientID     *uint  `json:"patient_id"`
+	ProductIDs    []uint `json:"product_ids" binding:"required,min=1"`
+	Diagnosis     string `json:"diagnosis"`
+	Days          int    `json:"days" binding:"min=0"`
+	ExcludeSaleID uint   `json:"exclude_sale_id"`
+}
+
+// ImportResult adalah ringkasan impor CSV basis pengetahuan
+type ImportResult struct {
+	Imported int      `json:"imported"`
+	Skipped  int      `json:"skipped"`
+	Errors   []string `json:"errors,omitempty"`
+}
@@ -0,0 +1,24 @@
+package interaction
+
+import (
+	"go-gin-auth/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func InteractionRouter(api *gin.RouterGroup) {
+	service := NewService(config.DB)
+	handler := NewHandler(service)
+
+	interactions := api.Group("/drug-interactions")
+	{
+		interactions.GET("", handler.GetAll)
+		interactions.GET("/overrides", handler.Overrides)
+		interactions.GET("/:id", handler.GetByID)
+		interactions.POST("", handler.Create)
+		interactions.POST("/import", handler.Import)
+		interactions.POST("/check", handler.Check)
+		interactions.PUT("/:id", handler.Update)
+		interactions.DELETE("/:id", handler.Delete)
+	}
+}
@@ -0,0 +1,216 @@
+package interaction
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidKind     = errors.New("jenis harus interaksi atau kontraindikasi")
+	ErrInvalidSeverity = errors.New("tingkat keparahan harus Ringan, Sedang atau Berat")
+	ErrIngredients     = errors.New("zat aktif A dan B wajib diisi dan tidak boleh sama")
+	ErrCSVHeader       = errors.New("kolom CSV wajib: ingredient_a, ingredient_b, severity; opsional: kind, description, management")
+)
+
+type Service struct {
+	db *gorm.DB
+}
+
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db}
+}
+
+// Filter membatasi daftar aturan interaksi
+type Filter struct {
+	Search   string
+	Kind     string
+	Severity string
+}
+
+func (s *Service) GetAll(f Filter) ([]Rule, error) {
+	query := s.db.Model(&Rule{})
+	if f.Search != "" {
+		like := "%" + strings.ToLower(f.Search) + "%"
+		query = query.Where("ingredient_a LIKE ? OR ingredient_b LIKE ?", like, like)
+	}
+	if f.Kind != "" {
+		query = query.Where("kind = ?", f.Kind)
+	}
+	if f.Severity != "" {
+		query = query.Where("severity = ?", f.Severity)
+	}
+
+	var rules []Rule
+	err := query.Order("ingredient_a, ingredient_b").Find(&rules).Error
+	return rules, err
+}
+
+func (s *Service) GetByID(id uint) (*Rule, error) {
+	var rule Rule
+	if err := s.db.First(&rule, id).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (s *Service) Create(input *Rule) (*Rule, error) {
+	if err := prepare(input); err != nil {
+		return nil, err
+	}
+	input.ID = 0
+	if err := s.db.Create(input).Error; err != nil {
+		return nil, err
+	}
+	return input, nil
+}
+
+func (s *Service) Update(id uint, input *Rule) (*Rule, error) {
+	rule, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := prepare(input); err != nil {
+		return nil, err
+	}
+	rule.Kind = input.Kind
+	rule.IngredientA = input.IngredientA
+	rule.IngredientB = input.IngredientB
+	rule.Severity = input.Severity
+	rule.Description = input.Description
+	rule.Management = input.Management
+	if err := s.db.Save(rule).Error; err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (s *Service) Delete(id uint) error {
+	if _, err := s.GetByID(id); err != nil {
+		return err
+	}
+	return s.db.Delete(&Rule{}, id).Error
+}
+
+// Import membaca CSV basis pengetahuan dengan baris pertama sebagai judul
+// kolom. Pasangan yang sudah ada diperbarui; baris yang tidak valid
+// dilewati dan dilaporkan tanpa menggagalkan baris lain.
+func (s *Service) Import(r io.Reader) (*ImportResult, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, ErrCSVHeader
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, required := range []string{"ingredient_a", "ingredient_b", "severity"} {
+		if _, ok := columns[required]; !ok {
+			return nil, ErrCSVHeader
+		}
+	}
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return record[i]
+	}
+
+	result := &ImportResult{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("baris %d: %w", line, err)
+		}
+
+		rule := Rule{
+			Kind:        field(record, "kind"),
+			IngredientA: field(record, "ingredient_a"),
+			IngredientB: field(record, "ingredient_b"),
+			Severity:    field(record, "severity"),
+			Description: strings.TrimSpace(field(record, "description")),
+			Management:  strings.TrimSpace(field(record, "management")),
+		}
+		if err := prepare(&rule); err != nil {
+			result.Skipped++
+			result.Errors = append(result.Errors, fmt.Sprintf("baris %d: %s", line, err.Error()))
+			continue
+		}
+
+		err = s.db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "kind"}, {Name: "ingredient_a"}, {Name: "ingredient_b"}},
+			DoUpdates: clause.AssignmentColumns([]string{"severity", "description", "management", "updated_at"}),
+		}).Create(&rule).Error
+		if err != nil {
+			return nil, fmt.Errorf("baris %d: %w", line, err)
+		}
+		result.Imported++
+	}
+	return result, nil
+}
+
+// Overrides mengambil catatan alasan apoteker, terbaru lebih dulu
+func (s *Service) Overrides(saleType string, saleID uint) ([]Override, error) {
+	query := s.db.Model(&Override{})
+	if saleType != "" {
+		query = query.Where("sale_type = ?", saleType)
+	}
+	if saleID != 0 {
+		query = query.Where("sale_id = ?", saleID)
+	}
+
+	var overrides []Override
+	if err := query.Order("created_at DESC").Limit(500).Find(&overrides).Error; err != nil {
+		return nil, err
+	}
+	for i := range overrides {
+		s.db.Table("users").Where("id = ?", overrides[i].UserID).Pluck("full_name", &overrides[i].UserName)
+		s.db.Table("users").Where("id = ?", overrides[i].PharmacistID).Pluck("full_name", &overrides[i].PharmacistName)
+	}
+	return overrides, nil
+}
+
+// prepare menyeragamkan penulisan dan memvalidasi aturan
+func prepare(rule *Rule) error {
+	rule.Kind = strings.ToLower(strings.TrimSpace(rule.Kind))
+	if rule.Kind == "" {
+		rule.Kind = KindInteraction
+	}
+	if rule.Kind != KindInteraction && rule.Kind != KindContraindication {
+		return ErrInvalidKind
+	}
+
+	switch strings.ToLower(strings.TrimSpace(rule.Severity)) {
+	case "ringan", "minor":
+		rule.Severity = SeverityMinor
+	case "sedang", "moderate":
+		rule.Severity = SeverityModerate
+	case "berat", "major":
+		rule.Severity = SeverityMajor
+	default:
+		return ErrInvalidSeverity
+	}
+
+	rule.IngredientA = normalize(rule.IngredientA)
+	rule.IngredientB = normalize(rule.IngredientB)
+	if rule.IngredientA == "" || rule.IngredientB == "" || rule.IngredientA == rule.IngredientB {
+		return ErrIngredients
+	}
+	if rule.Kind == KindInteraction && rule.IngredientB < rule.IngredientA {
+		rule.IngredientA, rule.IngredientB = rule.IngredientB, rule.IngredientA
+	}
+	return nil
+}
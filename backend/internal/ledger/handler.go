@@ -0,0 +1,76 @@
+package ledger
+
+import (
+	"go-gin-auth/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// List menampilkan buku besar penjualan reguler dan resep.
+// Query: page, limit, type (regular/prescription), start_date, end_date
+// (YYYY-MM-DD), shift_id, register_id, payment_method, search,
+// include_voided
+func (h *Handler) List(c *gin.Context) {
+	filter := Filter{
+		SaleType:      c.Query("type"),
+		PaymentMethod: c.Query("payment_method"),
+		Search:        c.Query("search"),
+		IncludeVoided: c.Query("include_voided") == "true",
+	}
+	filter.Page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	filter.Limit, _ = strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	if v := c.Query("start_date"); v != "" {
+		start, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			utils.Respond(c, http.StatusBadRequest, "Format start_date harus YYYY-MM-DD", err.Error(), nil)
+			return
+		}
+		filter.Start = &start
+	}
+	if v := c.Query("end_date"); v != "" {
+		end, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			utils.Respond(c, http.StatusBadRequest, "Format end_date harus YYYY-MM-DD", err.Error(), nil)
+			return
+		}
+		end = end.Add(24*time.Hour - time.Nanosecond)
+		filter.End = &end
+	}
+	if v := c.Query("shift_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			utils.Respond(c, http.StatusBadRequest, "shift_id tidak valid", err.Error(), nil)
+			return
+		}
+		shiftID := uint(id)
+		filter.ShiftID = &shiftID
+	}
+	if v := c.Query("register_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			utils.Respond(c, http.StatusBadRequest, "register_id tidak valid", err.Error(), nil)
+			return
+		}
+		registerID := uint(id)
+		filter.RegisterID = &registerID
+	}
+
+	page, err := h.service.List(filter)
+	if err != nil {
+		utils.Respond(c, http.StatusInternalServerError, "Gagal mengambil buku besar penjualan", err.Error(), nil)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Buku besar penjualan berhasil diambil", nil, page)
+}
@@ -0,0 +1,65 @@
+package ledger
+
+import "time"
+
+// Entry adalah satu baris buku besar penjualan. Penjualan reguler dan resep
+// disajikan dengan skema yang sama dan nominal dalam rupiah bulat.
+type Entry struct {
+	SaleType        string    `json:"sale_type"`
+	SaleID          uint      `json:"sale_id"`
+	Code            string    `json:"code"`
+	TransactionDate time.Time `json:"transaction_date"`
+	CustomerName    string    `json:"customer_name"`
+	CashierName     string    `json:"cashier_name"`
+	PatientID       *uint     `json:"patient_id,omitempty"`
+	ShiftID         *uint     `json:"shift_id,omitempty"`
+	RegisterID      *uint     `json:"register_id,omitempty"`
+	PaymentMethod   string    `json:"payment_method"`
+	ItemCount       int64     `json:"item_count"`
+	SubTotal        int64     `json:"sub_total"`
+	Discount        int64     `json:"discount"`
+	TaxAmount       int64     `json:"tax_amount"`
+	Total           int64     `json:"total"`
+	Status          string    `json:"status"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+func (Entry) TableName() string {
+	return "sales_ledger"
+}
+
+// Filter membatasi daftar buku besar penjualan
+type Filter struct {
+	SaleType      string
+	Start         *time.Time
+	End           *time.Time
+	ShiftID       *uint
+	RegisterID    *uint
+	PaymentMethod string
+	Search        string
+	// IncludeVoided ikut menampilkan penjualan yang dibatalkan; laporan
+	// pendapatan selalu membiarkannya false
+	IncludeVoided bool
+	Page          int
+	Limit         int
+
+	// patientIDs adalah pasien yang nama terdekripsinya cocok dengan Search
+	patientIDs []uint
+}
+
+// Totals adalah jumlah seluruh baris yang cocok dengan filter
+type Totals struct {
+	Transactions int64 `json:"transactions"`
+	SubTotal     int64 `json:"sub_total"`
+	Discount     int64 `json:"discount"`
+	TaxAmount    int64 `json:"tax_amount"`
+	Total        int64 `json:"total"`
+}
+
+// Page adalah hasil daftar buku besar beserta paginasinya
+type Page struct {
+	Entries []Entry `json:"entries"`
+	Totals  Totals  `json:"totals"`
+	Page    int     `json:"page"`
+	Limit   int     `json:"limit"`
+}
@@ -0,0 +1,14 @@
+package ledger
+
+import (
+	"go-gin-auth/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func LedgerRouter(api *gin.RouterGroup) {
+	service := NewService(config.DB)
+	handler := NewHandler(service)
+
+	api.GET("/sales", handler.List)
+}
@@ -0,0 +1,163 @@
+package ledger
+
+import (
+	"go-gin-auth/internal/payment"
+	"go-gin-auth/utils"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type Service struct {
+	db *gorm.DB
+}
+
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db}
+}
+
+// List mengambil buku besar penjualan terbaru lebih dulu beserta total
+// seluruh baris yang cocok dengan filter
+func (s *Service) List(filter Filter) (*Page, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+	if search := strings.TrimSpace(filter.Search); search != "" {
+		ids, err := s.matchPatients(search)
+		if err != nil {
+			return nil, err
+		}
+		filter.patientIDs = ids
+	}
+
+	var totals Totals
+	err := Query(s.db, filter).
+		Select(`COUNT(*) AS transactions,
+			COALESCE(SUM(sub_total), 0) AS sub_total,
+			COALESCE(SUM(discount), 0) AS discount,
+			COALESCE(SUM(tax_amount), 0) AS tax_amount,
+			COALESCE(SUM(total), 0) AS total`).
+		Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []Entry{}
+	err = Query(s.db, filter).
+		Order("transaction_date DESC, created_at DESC").
+		Limit(filter.Limit).
+		Offset((filter.Page - 1) * filter.Limit).
+		Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := s.fillPatientNames(entries); err != nil {
+		return nil, err
+	}
+
+	return &Page{Entries: entries, Totals: totals, Page: filter.Page, Limit: filter.Limit}, nil
+}
+
+// Query menyiapkan query atas view buku besar dengan filter yang diberikan.
+// Laporan lain dapat memakainya alih-alih menulis UNION sendiri.
+func Query(db *gorm.DB, filter Filter) *gorm.DB {
+	query := db.Model(&Entry{})
+	if !filter.IncludeVoided {
+		query = query.Where("status <> ?", payment.SaleVoided)
+	}
+	if filter.SaleType != "" {
+		query = query.Where("sale_type = ?", filter.SaleType)
+	}
+	if filter.Start != nil {
+		query = query.Where("transaction_date >= ?", *filter.Start)
+	}
+	if filter.End != nil {
+		query = query.Where("transaction_date <= ?", *filter.End)
+	}
+	if filter.ShiftID != nil {
+		query = query.Where("shift_id = ?", *filter.ShiftID)
+	}
+	if filter.RegisterID != nil {
+		query = query.Where("register_id = ?", *filter.RegisterID)
+	}
+	if filter.PaymentMethod != "" {
+		query = query.Where("payment_method = ?", filter.PaymentMethod)
+	}
+	if search := strings.TrimSpace(filter.Search); search != "" {
+		like := "%" + strings.ToLower(search) + "%"
+		if len(filter.patientIDs) > 0 {
+			query = query.Where("LOWER(code) LIKE ? OR LOWER(customer_name) LIKE ? OR LOWER(cashier_name) LIKE ? OR patient_id IN ?",
+				like, like, like, filter.patientIDs)
+		} else {
+			query = query.Where("LOWER(code) LIKE ? OR LOWER(customer_name) LIKE ? OR LOWER(cashier_name) LIKE ?", like, like, like)
+		}
+	}
+	return query
+}
+
+// matchPatients mencari pasien yang nama terdekripsinya memuat search.
+// Nama pasien terenkripsi sehingga pencocokan tidak bisa dilakukan di SQL.
+func (s *Service) matchPatients(search string) ([]uint, error) {
+	var patients []struct {
+		ID       uint
+		FullName string
+	}
+	if err := s.db.Table("patients").Select("id, full_name").Scan(&patients).Error; err != nil {
+		return nil, err
+	}
+	search = strings.ToLower(search)
+	var ids []uint
+	for _, p := range patients {
+		if strings.Contains(strings.ToLower(decrypt(p.FullName)), search) {
+			ids = append(ids, p.ID)
+		}
+	}
+	return ids, nil
+}
+
+// fillPatientNames mengisi nama pasien terdekripsi pada baris penjualan resep
+func (s *Service) fillPatientNames(entries []Entry) error {
+	var ids []uint
+	for _, e := range entries {
+		if e.PatientID != nil {
+			ids = append(ids, *e.PatientID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var patients []struct {
+		ID       uint
+		FullName string
+	}
+	if err := s.db.Table("patients").Where("id IN ?", ids).Select("id, full_name").Scan(&patients).Error; err != nil {
+		return err
+	}
+	names := make(map[uint]string, len(patients))
+	for _, p := range patients {
+		names[p.ID] = decrypt(p.FullName)
+	}
+	for i := range entries {
+		if entries[i].PatientID != nil {
+			entries[i].CustomerName = names[*entries[i].PatientID]
+		}
+	}
+	return nil
+}
+
+// decrypt mengembalikan teks asli; nilai lama yang belum terenkripsi
+// dikembalikan apa adanya
+func decrypt(value string) string {
+	if value == "" {
+		return ""
+	}
+	plain, err := utils.Decrypt(value)
+	if err != nil {
+		return value
+	}
+	return plain
+}
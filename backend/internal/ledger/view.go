@@ -0,0 +1,78 @@
+package ledger
+
+import "gorm.io/gorm"
+
+// viewSQL menyatukan penjualan reguler dan resep ke satu skema. Semua nominal
+// dibulatkan ke rupiah; penjualan yang dihapus tidak ikut ditampilkan,
+// sedangkan penjualan yang dibatalkan tetap ada dengan status Batal.
+// Nama pasien tersimpan terenkripsi sehingga customer_name penjualan resep
+// dikosongkan di sini dan diisi Service setelah didekripsi.
+const viewSQL = `
+CREATE OR REPLACE VIEW sales_ledger AS
+SELECT
+	'regular'::varchar AS sale_type,
+	s.id AS sale_id,
+	s.sales_code::varchar AS code,
+	s.transaction_date,
+	COALESCE(s.customer_name, '')::varchar AS customer_name,
+	s.cashier_name::varchar AS cashier_name,
+	NULL::bigint AS patient_id,
+	s.shift_id,
+	s.register_id,
+	s.payment_method::varchar AS payment_method,
+	(SELECT COUNT(*) FROM sales_regular_items i
+		WHERE i.sales_regular_id = s.id AND i.deleted_at IS NULL) AS item_count,
+	s.sub_total::bigint AS sub_total,
+	COALESCE(s.total_discount, 0)::bigint AS discount,
+	s.tax_amount::bigint AS tax_amount,
+	s.total_pay::bigint AS total,
+	s.status::varchar AS status,
+	s.created_at
+FROM sales_regulars s
+WHERE s.deleted_at IS NULL
+UNION ALL
+SELECT
+	'prescription'::varchar,
+	s.id,
+	s.transaction_code::varchar,
+	s.transaction_date,
+	''::varchar,
+	COALESCE(u.full_name, '')::varchar,
+	s.patient_id,
+	s.shift_id,
+	s.register_id,
+	s.payment_method::varchar,
+	COALESCE(i.item_count, 0),
+	ROUND(COALESCE(i.gross, 0))::bigint,
+	ROUND(s.promotion_discount
+		+ (COALESCE(i.gross, 0) - s.promotion_discount) * s.discount_percent / 100
+		+ s.discount_amount)::bigint,
+	ROUND(s.tax_amount)::bigint,
+	ROUND(s.total_amount)::bigint,
+	s.status::varchar,
+	s.created_at
+FROM prescription_sales s
+LEFT JOIN (
+	SELECT prescription_sale_id, COUNT(*) AS item_count, SUM(sub_total) AS gross
+	FROM (
+		SELECT prescription_sale_id, sub_total FROM prescription_items WHERE deleted_at IS NULL
+		UNION ALL
+		SELECT prescription_sale_id, sub_total FROM prescription_compounds WHERE deleted_at IS NULL
+	) lines
+	GROUP BY prescription_sale_id
+) i ON i.prescription_sale_id = s.id
+LEFT JOIN users u ON u.id = s.cashier_id
+WHERE s.deleted_at IS NULL
+`
+
+// DropView menghapus view sebelum AutoMigrate agar perubahan tipe kolom
+// pada tabel penjualan tidak tertahan oleh view
+func DropView(db *gorm.DB) error {
+	return db.Exec("DROP VIEW IF EXISTS sales_ledger").Error
+}
+
+// Migrate membuat ulang view buku besar penjualan. Dipanggil setelah
+// AutoMigrate agar kolom yang dirujuk sudah tersedia.
+func Migrate(db *gorm.DB) error {
+	return db.Exec(viewSQL).Error
+}
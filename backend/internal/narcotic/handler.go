@@ -0,0 +1,113 @@
+package narcotic
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go-gin-auth/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// Register menampilkan register narkotika/psikotropika.
+// Query: product_id, class, type, start_date, end_date (YYYY-MM-DD)
+func (h *Handler) Register(c *gin.Context) {
+	filter := Filter{
+		Class: c.Query("class"),
+		Type:  c.Query("type"),
+	}
+	if v := c.Query("product_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			utils.Respond(c, http.StatusBadRequest, "product_id tidak valid", err.Error(), nil)
+			return
+		}
+		filter.ProductID = uint(id)
+	}
+	if v := c.Query("start_date"); v != "" {
+		start, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			utils.Respond(c, http.StatusBadRequest, "Format start_date harus YYYY-MM-DD", err.Error(), nil)
+			return
+		}
+		filter.Start = &start
+	}
+	if v := c.Query("end_date"); v != "" {
+		end, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			utils.Respond(c, http.StatusBadRequest, "Format end_date harus YYYY-MM-DD", err.Error(), nil)
+			return
+		}
+		end = end.Add(24*time.Hour - time.Nanosecond)
+		filter.End = &end
+	}
+
+	entries, err := h.service.List(filter)
+	if err != nil {
+		utils.Respond(c, http.StatusInternalServerError, "Gagal mengambil register narkotika", err.Error(), nil)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Register narkotika berhasil diambil", nil, entries)
+}
+
+// Dispose mencatat pengembalian ke PBF atau pemusnahan
+func (h *Handler) Dispose(c *gin.Context) {
+	var req DisposalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Respond(c, http.StatusBadRequest, "Input tidak valid", err.Error(), nil)
+		return
+	}
+
+	entries, err := h.service.Dispose(req, utils.GetCurrentUserID(c))
+	if err != nil {
+		h.respondError(c, "Gagal mencatat pengeluaran narkotika", err)
+		return
+	}
+	utils.Respond(c, http.StatusCreated, "Pengeluaran narkotika berhasil dicatat", nil, entries)
+}
+
+// SIPNAP menyusun laporan bulanan SIPNAP, bawaannya bulan berjalan dan
+// golongan narkotika. Query format=csv mengunduh file untuk diunggah.
+func (h *Handler) SIPNAP(c *gin.Context) {
+	month := c.DefaultQuery("month", time.Now().Format("2006-01"))
+	class := c.DefaultQuery("class", "Narkotika")
+
+	report, err := h.service.Report(month, class)
+	if err != nil {
+		h.respondError(c, "Gagal menyusun laporan SIPNAP", err)
+		return
+	}
+
+	if c.Query("format") != "csv" {
+		utils.Respond(c, http.StatusOK, "Laporan SIPNAP berhasil disusun", nil, report)
+		return
+	}
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, report); err != nil {
+		utils.Respond(c, http.StatusInternalServerError, "Gagal menyusun laporan SIPNAP", err.Error(), nil)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=sipnap-%s-%s.csv", class, month))
+	c.Data(http.StatusOK, "text/csv", buf.Bytes())
+}
+
+func (h *Handler) respondError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidMonth), errors.Is(err, ErrInvalidClass),
+		errors.Is(err, ErrNotControlled), errors.Is(err, ErrInsufficientQty):
+		utils.Respond(c, http.StatusBadRequest, err.Error(), err.Error(), nil)
+	default:
+		utils.Respond(c, http.StatusInternalServerError, message, err.Error(), nil)
+	}
+}
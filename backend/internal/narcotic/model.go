@@ -0,0 +1,112 @@
+package narcotic
+
+import "time"
+
+// Jenis mutasi pada register narkotika/psikotropika
+const (
+	TypeReceipt     = "Penerimaan"
+	TypeDispensing  = "Penyerahan"
+	TypeReturn      = "Pengembalian"
+	TypeDestruction = "Pemusnahan"
+	// TypeCancellation membalik mutasi sebelumnya, misalnya penjualan resep
+	// yang dibatalkan atau diubah dan penerimaan yang dihapus
+	TypeCancellation = "Pembatalan"
+	TypeCorrection   = "Koreksi"
+)
+
+// Entry adalah satu baris register. Balance adalah saldo stok produk
+// setelah mutasi dicatat sehingga register dapat dicocokkan dengan stok.
+type Entry struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	ProductID   uint      `gorm:"not null;index:idx_narcotic_register_product" json:"product_id"`
+	ProductCode string    `gorm:"type:varchar(50)" json:"product_code"`
+	ProductName string    `gorm:"type:varchar(255)" json:"product_name"`
+	Class       string    `gorm:"type:varchar(20);not null;index" json:"class"`
+	Date        time.Time `gorm:"not null;index" json:"date"`
+	Type        string    `gorm:"type:varchar(20);not null" json:"type"`
+	RefType     string    `gorm:"type:varchar(30)" json:"ref_type"`
+	RefID       uint      `json:"ref_id"`
+	RefCode     string    `gorm:"type:varchar(100)" json:"ref_code"`
+	// Party adalah pemasok, pasien atau pihak lain pada mutasi; Doctor
+	// diisi untuk penyerahan dengan resep
+	Party     string    `gorm:"type:varchar(255)" json:"party"`
+	Doctor    string    `gorm:"type:varchar(255)" json:"doctor,omitempty"`
+	QtyIn     int       `gorm:"not null;default:0" json:"qty_in"`
+	QtyOut    int       `gorm:"not null;default:0" json:"qty_out"`
+	Balance   int       `gorm:"not null" json:"balance"`
+	Note      string    `gorm:"type:text" json:"note,omitempty"`
+	UserID    uint      `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (Entry) TableName() string {
+	return "narcotic_register"
+}
+
+// Line adalah jumlah masuk atau keluar satu produk pada suatu mutasi
+type Line struct {
+	ProductID uint
+	QtyIn     int
+	QtyOut    int
+}
+
+// Movement adalah mutasi stok yang diteruskan ke register. Baris produk
+// yang bukan narkotika/psikotropika diabaikan.
+type Movement struct {
+	Type    string
+	RefType string
+	RefID   uint
+	RefCode string
+	Date    time.Time
+	Party   string
+	Doctor  string
+	Note    string
+	UserID  uint
+	Lines   []Line
+}
+
+// Filter membatasi daftar register
+type Filter struct {
+	ProductID uint
+	Class     string
+	Type      string
+	Start     *time.Time
+	End       *time.Time
+}
+
+// DisposalRequest adalah pengembalian ke PBF atau pemusnahan yang
+// dicatat langsung dari register. DocumentNo adalah nomor faktur retur atau
+// berita acara pemusnahan.
+type DisposalRequest struct {
+	ProductID  uint      `json:"product_id" binding:"required"`
+	Quantity   int       `json:"quantity" binding:"required,min=1"`
+	Type       string    `json:"type" binding:"required,oneof=Pengembalian Pemusnahan"`
+	Date       time.Time `json:"date"`
+	DocumentNo string    `json:"document_no" binding:"required"`
+	Party      string    `json:"party" binding:"required"`
+	Note       string    `json:"note"`
+}
+
+// ReportRow adalah satu produk pada laporan bulanan SIPNAP
+type ReportRow struct {
+	ProductID    uint   `json:"product_id"`
+	ProductCode  string `json:"product_code"`
+	ProductName  string `json:"product_name"`
+	Unit         string `json:"unit"`
+	Class        string `json:"class"`
+	Opening      int    `json:"opening"`
+	Received     int    `json:"received"`
+	ReceivedFrom string `json:"received_from"`
+	Dispensed    int    `json:"dispensed"`
+	Returned     int    `json:"returned"`
+	Destroyed    int    `json:"destroyed"`
+	Adjusted     int    `json:"adjusted"`
+	Closing      int    `json:"closing"`
+}
+
+// Report adalah laporan bulanan SIPNAP satu golongan
+type Report struct {
+	Period string      `json:"period"`
+	Class  string      `json:"class"`
+	Rows   []ReportRow `json:"rows"`
+}
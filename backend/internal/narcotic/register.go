@@ -0,0 +1,143 @@
+package narcotic
+
+import (
+	"errors"
+	"fmt"
+	"go-gin-auth/internal/drug_category"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrPrescriptionRequired dikembalikan bila narkotika/psikotropika dijual
+// tanpa resep dokter
+var ErrPrescriptionRequired = errors.New("narkotika dan psikotropika hanya dapat diserahkan melalui penjualan resep dengan dokter")
+
+// ControlledProduct adalah produk narkotika/psikotropika beserta golongannya
+type ControlledProduct struct {
+	ID    uint
+	Code  string
+	Name  string
+	Class string
+}
+
+// Controlled mengembalikan produk narkotika/psikotropika di antara ids
+// beserta golongannya
+func Controlled(db *gorm.DB, ids []uint) (map[uint]ControlledProduct, error) {
+	result := make(map[uint]ControlledProduct)
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	var rows []struct {
+		ID           uint
+		Code         string
+		Name         string
+		Category     string
+		ControlClass string
+	}
+	err := db.Table("products p").
+		Select("p.id, p.code, p.name, COALESCE(dc.name, '') AS category, COALESCE(dc.control_class, '') AS control_class").
+		Joins("LEFT JOIN drug_categories dc ON dc.id = p.drug_category_id").
+		Where("p.id IN ?", ids).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		class := drug_category.DrugCategory{Name: r.Category, ControlClass: r.ControlClass}.Controlled()
+		if class != "" {
+			result[r.ID] = ControlledProduct{ID: r.ID, Code: r.Code, Name: r.Name, Class: class}
+		}
+	}
+	return result, nil
+}
+
+// EnsureNotControlled menolak penjualan bebas yang memuat narkotika atau
+// psikotropika
+func EnsureNotControlled(db *gorm.DB, ids []uint) error {
+	controlled, err := Controlled(db, ids)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if p, ok := controlled[id]; ok {
+			return fmt.Errorf("%w: %s (%s)", ErrPrescriptionRequired, p.Name, p.Class)
+		}
+	}
+	return nil
+}
+
+// Record mencatat mutasi narkotika/psikotropika ke register. Dipanggil di
+// dalam transaksi yang sama setelah stok diperbarui, karena saldo diambil
+// dari total stok produk saat itu.
+func Record(tx *gorm.DB, m Movement) error {
+	_, err := record(tx, m)
+	return err
+}
+
+func record(tx *gorm.DB, m Movement) ([]Entry, error) {
+	// baris produk yang sama digabung agar saldo tiap baris register
+	// tetap sesuai dengan stok setelah mutasi
+	var ids []uint
+	lines := make(map[uint]*Line)
+	for _, l := range m.Lines {
+		if existing, ok := lines[l.ProductID]; ok {
+			existing.QtyIn += l.QtyIn
+			existing.QtyOut += l.QtyOut
+			continue
+		}
+		line := l
+		lines[l.ProductID] = &line
+		ids = append(ids, l.ProductID)
+	}
+	controlled, err := Controlled(tx, ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(controlled) == 0 {
+		return nil, nil
+	}
+
+	date := m.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+	var entries []Entry
+	for _, id := range ids {
+		p, ok := controlled[id]
+		l := lines[id]
+		if !ok || (l.QtyIn == 0 && l.QtyOut == 0) {
+			continue
+		}
+
+		var balance int
+		if err := tx.Table("stocks").Where("product_id = ?", id).
+			Select("COALESCE(SUM(quantity), 0)").Scan(&balance).Error; err != nil {
+			return nil, err
+		}
+		entry := Entry{
+			ProductID:   p.ID,
+			ProductCode: p.Code,
+			ProductName: p.Name,
+			Class:       p.Class,
+			Date:        date,
+			Type:        m.Type,
+			RefType:     m.RefType,
+			RefID:       m.RefID,
+			RefCode:     m.RefCode,
+			Party:       m.Party,
+			Doctor:      m.Doctor,
+			QtyIn:       l.QtyIn,
+			QtyOut:      l.QtyOut,
+			Balance:     balance,
+			Note:        m.Note,
+			UserID:      m.UserID,
+		}
+		if err := tx.Create(&entry).Error; err != nil {
+			return nil, fmt.Errorf("failed to record narcotic register: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
@@ -0,0 +1,19 @@
+package narcotic
+
+import (
+	"go-gin-auth/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func NarcoticRouter(api *gin.RouterGroup) {
+	service := NewService(config.DB)
+	handler := NewHandler(service)
+
+	narcotics := api.Group("/narcotics")
+	{
+		narcotics.GET("/register", handler.Register)
+		narcotics.POST("/disposals", handler.Dispose)
+		narcotics.GET("/sipnap", handler.SIPNAP)
+	}
+}
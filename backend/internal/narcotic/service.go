@@ -0,0 +1,264 @@
+package narcotic
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"go-gin-auth/internal/drug_category"
+	"go-gin-auth/internal/opname"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidMonth    = errors.New("format bulan harus YYYY-MM")
+	ErrInvalidClass    = errors.New("golongan harus Narkotika atau Psikotropika")
+	ErrNotControlled   = errors.New("produk bukan narkotika atau psikotropika")
+	ErrInsufficientQty = errors.New("stok tidak mencukupi")
+)
+
+type Service struct {
+	db *gorm.DB
+}
+
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db}
+}
+
+// List mengambil register sesuai filter, urut sesuai waktu pencatatan
+func (s *Service) List(f Filter) ([]Entry, error) {
+	query := s.db.Model(&Entry{})
+	if f.ProductID != 0 {
+		query = query.Where("product_id = ?", f.ProductID)
+	}
+	if f.Class != "" {
+		query = query.Where("class = ?", f.Class)
+	}
+	if f.Type != "" {
+		query = query.Where("type = ?", f.Type)
+	}
+	if f.Start != nil {
+		query = query.Where("date >= ?", *f.Start)
+	}
+	if f.End != nil {
+		query = query.Where("date <= ?", *f.End)
+	}
+
+	var entries []Entry
+	err := query.Order("id").Find(&entries).Error
+	return entries, err
+}
+
+// Dispose mencatat pengembalian ke PBF atau pemusnahan: stok dikurangi
+// dari batch yang paling cepat kedaluwarsa lalu dicatat ke register
+func (s *Service) Dispose(req DisposalRequest, userID uint) ([]Entry, error) {
+	controlled, err := Controlled(s.db, []uint{req.ProductID})
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := controlled[req.ProductID]; !ok {
+		return nil, ErrNotControlled
+	}
+	if req.Date.IsZero() {
+		req.Date = time.Now()
+	}
+
+	var entries []Entry
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := opname.EnsureNotFrozen(tx, []uint{req.ProductID}); err != nil {
+			return err
+		}
+		var batches []struct {
+			ID       uint
+			Quantity int
+		}
+		if err := tx.Table("stocks").Select("id, quantity").
+			Where("product_id = ? AND quantity > 0", req.ProductID).
+			Order("expiry_date ASC NULLS LAST, id").
+			Scan(&batches).Error; err != nil {
+			return err
+		}
+
+		remaining := req.Quantity
+		for _, b := range batches {
+			if remaining == 0 {
+				break
+			}
+			take := b.Quantity
+			if take > remaining {
+				take = remaining
+			}
+			if err := tx.Table("stocks").Where("id = ?", b.ID).
+				Update("quantity", gorm.Expr("quantity - ?", take)).Error; err != nil {
+				return err
+			}
+			remaining -= take
+		}
+		if remaining > 0 {
+			return fmt.Errorf("%w: kurang %d", ErrInsufficientQty, remaining)
+		}
+
+		var err error
+		entries, err = record(tx, Movement{
+			Type:    req.Type,
+			RefType: "narcotic_disposal",
+			RefCode: req.DocumentNo,
+			Date:    req.Date,
+			Party:   req.Party,
+			Note:    req.Note,
+			UserID:  userID,
+			Lines:   []Line{{ProductID: req.ProductID, QtyOut: req.Quantity}},
+		})
+		return err
+	})
+	return entries, err
+}
+
+// Report menyusun laporan bulanan SIPNAP untuk golongan class pada bulan
+// month (format 2006-01). Produk diawasi yang tidak bermutasi tetap
+// dilaporkan dengan saldo awal sama dengan saldo akhir.
+func (s *Service) Report(month, class string) (*Report, error) {
+	start, err := time.ParseInLocation("2006-01", month, time.Local)
+	if err != nil {
+		return nil, ErrInvalidMonth
+	}
+	end := start.AddDate(0, 1, 0)
+	if class != drug_category.ClassNarcotic && class != drug_category.ClassPsychotropic {
+		return nil, ErrInvalidClass
+	}
+
+	var products []struct {
+		ID           uint
+		Code         string
+		Name         string
+		Unit         string
+		Category     string
+		ControlClass string
+		OnHand       int
+	}
+	err = s.db.Raw(`
+		SELECT p.id, p.code, p.name, COALESCE(u.name, '') AS unit,
+			COALESCE(dc.name, '') AS category, COALESCE(dc.control_class, '') AS control_class,
+			COALESCE((SELECT SUM(quantity) FROM stocks WHERE product_id = p.id), 0) AS on_hand
+		FROM products p
+		LEFT JOIN units u ON u.id = p.unit_id
+		LEFT JOIN drug_categories dc ON dc.id = p.drug_category_id
+		WHERE p.deleted_at IS NULL
+		ORDER BY p.name
+	`).Scan(&products).Error
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Period: month, Class: class, Rows: []ReportRow{}}
+	for _, p := range products {
+		if (drug_category.DrugCategory{Name: p.Category, ControlClass: p.ControlClass}).Controlled() != class {
+			continue
+		}
+		row, err := s.reportRow(p.ID, start, end, p.OnHand)
+		if err != nil {
+			return nil, err
+		}
+		row.ProductCode = p.Code
+		row.ProductName = p.Name
+		row.Unit = p.Unit
+		row.Class = class
+		report.Rows = append(report.Rows, *row)
+	}
+	return report, nil
+}
+
+// reportRow menghitung saldo awal, mutasi dan saldo akhir satu produk.
+// Pembatalan dikurangkan dari kolom mutasi asalnya agar laporan memuat
+// jumlah bersih.
+func (s *Service) reportRow(productID uint, start, end time.Time, onHand int) (*ReportRow, error) {
+	row := &ReportRow{ProductID: productID}
+
+	var before Entry
+	err := s.db.Where("product_id = ? AND date < ?", productID, start).Order("date DESC, id DESC").Limit(1).Find(&before).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	if err := s.db.Where("product_id = ? AND date >= ? AND date < ?", productID, start, end).
+		Order("date, id").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	switch {
+	case before.ID != 0:
+		row.Opening = before.Balance
+	case len(entries) > 0:
+		row.Opening = entries[0].Balance - entries[0].QtyIn + entries[0].QtyOut
+	default:
+		// belum pernah tercatat: stok saat ini belum pernah bermutasi
+		row.Opening = onHand
+	}
+
+	sources := map[string]bool{}
+	for _, e := range entries {
+		switch e.Type {
+		case TypeReceipt:
+			row.Received += e.QtyIn - e.QtyOut
+			if e.Party != "" {
+				sources[e.Party] = true
+			}
+		case TypeDispensing:
+			row.Dispensed += e.QtyOut - e.QtyIn
+		case TypeCancellation:
+			// pembatalan penyerahan mengembalikan stok, pembatalan
+			// penerimaan mengeluarkannya
+			row.Dispensed -= e.QtyIn
+			row.Received -= e.QtyOut
+		case TypeReturn:
+			row.Returned += e.QtyOut - e.QtyIn
+		case TypeDestruction:
+			row.Destroyed += e.QtyOut - e.QtyIn
+		default:
+			row.Adjusted += e.QtyIn - e.QtyOut
+		}
+	}
+	row.Closing = row.Opening
+	if len(entries) > 0 {
+		row.Closing = entries[len(entries)-1].Balance
+	}
+
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	row.ReceivedFrom = strings.Join(names, "; ")
+	return row, nil
+}
+
+// WriteCSV menulis laporan dengan urutan kolom formulir SIPNAP
+func WriteCSV(w io.Writer, report *Report) error {
+	writer := csv.NewWriter(w)
+	header := []string{
+		"No", "Kode Obat", "Nama Obat", "Satuan", "Stok Awal", "Pemasukan", "Pemasukan Dari",
+		"Pengeluaran Resep", "Pengeluaran Lainnya (Retur)", "Pemusnahan", "Penyesuaian", "Stok Akhir",
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for i, r := range report.Rows {
+		record := []string{
+			strconv.Itoa(i + 1), r.ProductCode, r.ProductName, r.Unit,
+			strconv.Itoa(r.Opening), strconv.Itoa(r.Received), r.ReceivedFrom,
+			strconv.Itoa(r.Dispensed), strconv.Itoa(r.Returned), strconv.Itoa(r.Destroyed),
+			strconv.Itoa(r.Adjusted), strconv.Itoa(r.Closing),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
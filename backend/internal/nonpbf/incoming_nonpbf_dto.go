@@ -3,18 +3,20 @@ package nonpbf
 import "time"
 
 type CreateIncomingNonPBFRequest struct {
-	OrderNumber     string                        `json:"order_number" binding:"required"`
-	OrderDate       time.Time                     `json:"order_date" binding:"required"`
-	IncomingDate    time.Time                     `json:"incoming_date" binding:"required"`
-	SupplierName    string                        `json:"supplier_name" binding:"required"`
-	InvoiceNumber   string                        `json:"invoice_number" binding:"required"`
-	TransactionType string                        `json:"transaction_type" binding:"required,oneof=Cash Kredit Konsinyasi"`
-	PaymentDueDate  *time.Time                    `json:"payment_due_date"`
-	OfficerName     string                        `json:"officer_name" binding:"required"`
-	AdditionalNotes string                        `json:"additional_notes"`
-	PaymentStatus   string                        `json:"payment_status" binding:"omitempty,oneof=Lunas 'Belum Lunas'"`
-	UserID          uint                          `json:"user_id" binding:"required"`
-	Details         []CreateIncomingDetailRequest `json:"details" binding:"required,dive"`
+	OrderNumber     string     `json:"order_number" binding:"required"`
+	OrderDate       time.Time  `json:"order_date" binding:"required"`
+	IncomingDate    time.Time  `json:"incoming_date" binding:"required"`
+	SupplierName    string     `json:"supplier_name" binding:"required"`
+	InvoiceNumber   string     `json:"invoice_number" binding:"required"`
+	TransactionType string     `json:"transaction_type" binding:"required,oneof=Cash Kredit Konsinyasi"`
+	PaymentDueDate  *time.Time `json:"payment_due_date"`
+	OfficerName     string     `json:"officer_name" binding:"required"`
+	AdditionalNotes string     `json:"additional_notes"`
+	PaymentStatus   string     `json:"payment_status" binding:"omitempty,oneof=Lunas 'Belum Lunas'"`
+	UserID          uint       `json:"user_id" binding:"required"`
+	// PricesIncludeTax diisi bila harga beli pada faktur sudah termasuk PPN
+	PricesIncludeTax bool                          `json:"prices_include_tax"`
+	Details          []CreateIncomingDetailRequest `json:"details" binding:"required,dive"`
 }
 
 type CreateIncomingDetailRequest struct {
@@ -29,15 +31,17 @@ type CreateIncomingDetailRequest struct {
 }
 
 type UpdateIncomingNonPBFRequest struct {
-	OrderNumber     string                        `json:"order_number"`
-	OrderDate       time.Time                     `json:"order_date"`
-	IncomingDate    time.Time                     `json:"incoming_date"`
-	SupplierName    string                        `json:"supplier_name"`
-	InvoiceNumber   string                        `json:"invoice_number"`
-	TransactionType string                        `json:"transaction_type" binding:"omitempty,oneof=Cash Kredit Konsinyasi"`
-	PaymentDueDate  *time.Time                    `json:"payment_due_date"`
-	OfficerName     string                        `json:"officer_name"`
-	AdditionalNotes string                        `json:"additional_notes"`
-	PaymentStatus   string                        `json:"payment_status" binding:"omitempty,oneof=Lunas 'Belum Lunas'"`
-	Details         []CreateIncomingDetailRequest `json:"details" binding:"dive"`
+	OrderNumber     string     `json:"order_number"`
+	OrderDate       time.Time  `json:"order_date"`
+	IncomingDate    time.Time  `json:"incoming_date"`
+	SupplierName    string     `json:"supplier_name"`
+	InvoiceNumber   string     `json:"invoice_number"`
+	TransactionType string     `json:"transaction_type" binding:"omitempty,oneof=Cash Kredit Konsinyasi"`
+	PaymentDueDate  *time.Time `json:"payment_due_date"`
+	OfficerName     string     `json:"officer_name"`
+	AdditionalNotes string     `json:"additional_notes"`
+	PaymentStatus   string     `json:"payment_status" binding:"omitempty,oneof=Lunas 'Belum Lunas'"`
+	// PricesIncludeTax diisi bila harga beli pada faktur sudah termasuk PPN
+	PricesIncludeTax bool                          `json:"prices_include_tax"`
+	Details          []CreateIncomingDetailRequest `json:"details" binding:"dive"`
 }
@@ -3,7 +3,11 @@ package nonpbf
 import (
 	"errors"
 	"fmt"
+	"go-gin-auth/internal/narcotic"
+	"go-gin-auth/internal/numbering"
+	"go-gin-auth/internal/opname"
 	"go-gin-auth/internal/stock"
+	"go-gin-auth/internal/tax"
 	"time"
 
 	"gorm.io/gorm"
@@ -60,8 +64,11 @@ func (s *IncomingNonPBFService) GetByID(id uint) (*IncomingNonPBF, error) {
 func (s *IncomingNonPBFService) Create(req CreateIncomingNonPBFRequest) (*IncomingNonPBF, error) {
 	tx := s.db.Begin()
 
-	// Generate transaction code
-	transactionCode := s.generateTransactionCode()
+	transactionCode, err := numbering.Next(tx, numbering.IncomingNonPBF, req.IncomingDate)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
 
 	// Calculate total purchase
 	var totalPurchase float64
@@ -69,6 +76,15 @@ func (s *IncomingNonPBFService) Create(req CreateIncomingNonPBFRequest) (*Incomi
 		totalPurchase += detail.PurchasePrice * float64(detail.IncomingQuantity)
 	}
 
+	taxes, taxTotals, err := s.calculateTax(tx, req.Details, req.PricesIncludeTax)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if !req.PricesIncludeTax {
+		totalPurchase += taxTotals.Tax
+	}
+
 	// Set default payment status
 	paymentStatus := req.PaymentStatus
 	if paymentStatus == "" {
@@ -87,6 +103,7 @@ func (s *IncomingNonPBFService) Create(req CreateIncomingNonPBFRequest) (*Incomi
 		OfficerName:     req.OfficerName,
 		AdditionalNotes: req.AdditionalNotes,
 		TotalPurchase:   totalPurchase,
+		TaxAmount:       taxTotals.Tax,
 		PaymentStatus:   paymentStatus,
 		UserID:          req.UserID,
 	}
@@ -97,7 +114,7 @@ func (s *IncomingNonPBFService) Create(req CreateIncomingNonPBFRequest) (*Incomi
 	}
 
 	// Create details
-	for _, detailReq := range req.Details {
+	for i, detailReq := range req.Details {
 		detail := IncomingNonPBFDetail{
 			IncomingNonPBFID: incoming.ID,
 			ProductCode:      detailReq.ProductCode,
@@ -106,6 +123,9 @@ func (s *IncomingNonPBFService) Create(req CreateIncomingNonPBFRequest) (*Incomi
 			IncomingQuantity: detailReq.IncomingQuantity,
 			PurchasePrice:    detailReq.PurchasePrice,
 			TotalPurchase:    detailReq.PurchasePrice * float64(detailReq.IncomingQuantity),
+			TaxRate:          taxes[i].Percent,
+			TaxBase:          taxes[i].Base,
+			TaxAmount:        taxes[i].Tax,
 			BatchNumber:      detailReq.BatchNumber,
 			ExpiryDate:       detailReq.ExpiryDate,
 			ProductID:        detailReq.ProductID,
@@ -117,7 +137,7 @@ func (s *IncomingNonPBFService) Create(req CreateIncomingNonPBFRequest) (*Incomi
 		}
 
 		// **UPDATE STOCK - TAMBAH STOK MASUK**
-		if err := s.updateStock(tx, detailReq, "ADD"); err != nil {
+		if err := s.updateStock(tx, incoming.ID, detailReq, "ADD"); err != nil {
 			tx.Rollback()
 			return nil, fmt.Errorf("failed to update stock: %v", err)
 		}
@@ -159,7 +179,7 @@ func (s *IncomingNonPBFService) Update(id uint, req UpdateIncomingNonPBFRequest)
 			BatchNumber:      oldDetail.BatchNumber,
 			ExpiryDate:       oldDetail.ExpiryDate,
 		}
-		if err := s.updateStock(tx, detailReq, "SUBTRACT"); err != nil {
+		if err := s.updateStock(tx, incoming.ID, detailReq, "SUBTRACT"); err != nil {
 			tx.Rollback()
 			return nil, fmt.Errorf("failed to revert stock: %v", err)
 		}
@@ -170,9 +190,19 @@ func (s *IncomingNonPBFService) Update(id uint, req UpdateIncomingNonPBFRequest)
 		totalPurchase += detail.PurchasePrice * float64(detail.IncomingQuantity)
 	}
 
+	taxes, taxTotals, err := s.calculateTax(tx, req.Details, req.PricesIncludeTax)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if !req.PricesIncludeTax {
+		totalPurchase += taxTotals.Tax
+	}
+
 	// Update main record
 	updates := map[string]interface{}{
 		"total_purchase": totalPurchase,
+		"tax_amount":     taxTotals.Tax,
 	}
 
 	if req.OrderNumber != "" {
@@ -218,7 +248,7 @@ func (s *IncomingNonPBFService) Update(id uint, req UpdateIncomingNonPBFRequest)
 	}
 
 	// Create new details
-	for _, detailReq := range req.Details {
+	for i, detailReq := range req.Details {
 		detail := IncomingNonPBFDetail{
 			IncomingNonPBFID: incoming.ID,
 			ProductCode:      detailReq.ProductCode,
@@ -227,6 +257,9 @@ func (s *IncomingNonPBFService) Update(id uint, req UpdateIncomingNonPBFRequest)
 			IncomingQuantity: detailReq.IncomingQuantity,
 			PurchasePrice:    detailReq.PurchasePrice,
 			TotalPurchase:    detailReq.PurchasePrice * float64(detailReq.IncomingQuantity),
+			TaxRate:          taxes[i].Percent,
+			TaxBase:          taxes[i].Base,
+			TaxAmount:        taxes[i].Tax,
 			BatchNumber:      detailReq.BatchNumber,
 			ExpiryDate:       detailReq.ExpiryDate,
 			ProductID:        detailReq.ProductID,
@@ -237,7 +270,7 @@ func (s *IncomingNonPBFService) Update(id uint, req UpdateIncomingNonPBFRequest)
 			return nil, err
 		}
 		// **UPDATE STOCK - TAMBAH STOK BARU**
-		if err := s.updateStock(tx, detailReq, "ADD"); err != nil {
+		if err := s.updateStock(tx, incoming.ID, detailReq, "ADD"); err != nil {
 			tx.Rollback()
 			return nil, fmt.Errorf("failed to update stock: %v", err)
 		}
@@ -277,7 +310,7 @@ func (s *IncomingNonPBFService) Delete(id uint) error {
 			BatchNumber:      detail.BatchNumber,
 			ExpiryDate:       detail.ExpiryDate,
 		}
-		if err := s.updateStock(tx, detailReq, "SUBTRACT"); err != nil {
+		if err := s.updateStock(tx, incoming.ID, detailReq, "SUBTRACT"); err != nil {
 			tx.Rollback()
 			return fmt.Errorf("failed to revert stock: %v", err)
 		}
@@ -299,7 +332,13 @@ func (s *IncomingNonPBFService) Delete(id uint) error {
 }
 
 // **FUNGSI UTAMA UNTUK UPDATE STOCK**
-func (s *IncomingNonPBFService) updateStock(tx *gorm.DB, detail CreateIncomingDetailRequest, operation string) error {
+func (s *IncomingNonPBFService) updateStock(tx *gorm.DB, incomingID uint, detail CreateIncomingDetailRequest, operation string) error {
+	if detail.ProductID != nil {
+		if err := opname.EnsureNotFrozen(tx, []uint{*detail.ProductID}); err != nil {
+			return err
+		}
+	}
+
 	var stockdata stock.Stock
 
 	// Cari stok berdasarkan ProductID
@@ -316,7 +355,10 @@ func (s *IncomingNonPBFService) updateStock(tx *gorm.DB, detail CreateIncomingDe
 					ExpiryDate:   detail.ExpiryDate,
 					MinimumStock: 10, // Atur sesuai kebutuhan
 				}
-				return tx.Create(&newStock).Error
+				if err := tx.Create(&newStock).Error; err != nil {
+					return err
+				}
+				return s.recordNarcotic(tx, incomingID, detail, operation)
 			}
 			// Jika operation SUBTRACT tapi stock tidak ada, return error
 			return fmt.Errorf("stock not found for product %s batch %s", detail.ProductCode, detail.BatchNumber)
@@ -337,12 +379,66 @@ func (s *IncomingNonPBFService) updateStock(tx *gorm.DB, detail CreateIncomingDe
 	}
 
 	// ✅ Update quantity dan expired-nya
-	return tx.Model(&stockdata).Updates(map[string]interface{}{
+	if err := tx.Model(&stockdata).Updates(map[string]interface{}{
 		"quantity":    newQuantity,
 		"expiry_date": detail.ExpiryDate,
-	}).Error
+	}).Error; err != nil {
+		return err
+	}
+	return s.recordNarcotic(tx, incomingID, detail, operation)
 }
-func (s *IncomingNonPBFService) generateTransactionCode() string {
-	now := time.Now()
-	return fmt.Sprintf("NONPBF-%s-%d", now.Format("20060102"), now.Unix())
+
+// recordNarcotic meneruskan penerimaan narkotika/psikotropika ke register.
+// Pengurangan stok karena data diubah atau dihapus dicatat sebagai
+// pembatalan penerimaan.
+func (s *IncomingNonPBFService) recordNarcotic(tx *gorm.DB, incomingID uint, detail CreateIncomingDetailRequest, operation string) error {
+	if detail.ProductID == nil {
+		return nil
+	}
+	var header IncomingNonPBF
+	if err := tx.First(&header, incomingID).Error; err != nil {
+		return err
+	}
+
+	movement := narcotic.Movement{
+		Type:    narcotic.TypeReceipt,
+		RefType: "incoming_non_pbf",
+		RefID:   header.ID,
+		RefCode: header.InvoiceNumber,
+		Date:    header.IncomingDate,
+		Party:   header.SupplierName,
+		UserID:  header.UserID,
+		Lines:   []narcotic.Line{{ProductID: *detail.ProductID, QtyIn: detail.IncomingQuantity}},
+	}
+	if operation == "SUBTRACT" {
+		movement.Type = narcotic.TypeCancellation
+		movement.Date = time.Now()
+		movement.Lines = []narcotic.Line{{ProductID: *detail.ProductID, QtyOut: detail.IncomingQuantity}}
+	}
+	return narcotic.Record(tx, movement)
+}
+
+// calculateTax menghitung DPP dan PPN masukan setiap detail. Detail tanpa
+// ProductID dianggap bukan objek pajak.
+func (s *IncomingNonPBFService) calculateTax(tx *gorm.DB, details []CreateIncomingDetailRequest, inclusive bool) ([]tax.LineTax, tax.Totals, error) {
+	var productIDs []uint
+	for _, d := range details {
+		if d.ProductID != nil {
+			productIDs = append(productIDs, *d.ProductID)
+		}
+	}
+	rates, err := tax.ProductRates(tx, productIDs)
+	if err != nil {
+		return nil, tax.Totals{}, err
+	}
+
+	lines := make([]tax.Line, len(details))
+	for i, d := range details {
+		lines[i].Amount = d.PurchasePrice * float64(d.IncomingQuantity)
+		if d.ProductID != nil {
+			lines[i].Percent = rates[*d.ProductID]
+		}
+	}
+	taxes, totals := tax.Allocate(lines, 0, inclusive)
+	return taxes, totals, nil
 }
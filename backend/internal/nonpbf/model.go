@@ -21,7 +21,8 @@ type IncomingNonPBF struct {
 	OfficerName     string         `json:"officer_name" gorm:"size:255;not null"`
 	AdditionalNotes string         `json:"additional_notes" gorm:"type:text"`
 	TotalPurchase   float64        `json:"total_purchase" gorm:"type:decimal(15,2);not null"`
-	PaymentStatus   string         `json:"payment_status" gorm:"size:20;default:'Belum Lunas'"` // Lunas/Belum Lunas
+	TaxAmount       float64        `json:"tax_amount" gorm:"type:decimal(15,2);not null;default:0"` // PPN masukan
+	PaymentStatus   string         `json:"payment_status" gorm:"size:20;default:'Belum Lunas'"`     // Lunas/Belum Lunas
 	UserID          uint           `json:"user_id" gorm:"not null"`
 	CreatedAt       time.Time      `json:"created_at"`
 	UpdatedAt       time.Time      `json:"updated_at"`
@@ -41,6 +42,9 @@ type IncomingNonPBFDetail struct {
 	IncomingQuantity int            `json:"incoming_quantity" gorm:"not null"`
 	PurchasePrice    float64        `json:"purchase_price" gorm:"type:decimal(15,2);not null"`
 	TotalPurchase    float64        `json:"total_purchase" gorm:"type:decimal(15,2);not null"`
+	TaxRate          float64        `json:"tax_rate" gorm:"type:decimal(5,2);not null;default:0"`
+	TaxBase          float64        `json:"tax_base" gorm:"type:decimal(15,2);not null;default:0"`
+	TaxAmount        float64        `json:"tax_amount" gorm:"type:decimal(15,2);not null;default:0"`
 	BatchNumber      string         `json:"batch_number" gorm:"size:100"`
 	ExpiryDate       *time.Time     `json:"expiry_date"`
 	ProductID        *uint          `json:"product_id"`
@@ -0,0 +1,72 @@
+package numbering
+
+import (
+	"errors"
+	"go-gin-auth/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) GetAll(c *gin.Context) {
+	sequences, err := h.service.GetAll()
+	if err != nil {
+		utils.Respond(c, http.StatusInternalServerError, "Gagal mengambil format nomor dokumen", err.Error(), nil)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Format nomor dokumen berhasil diambil", nil, sequences)
+}
+
+func (h *Handler) GetByType(c *gin.Context) {
+	seq, err := h.service.Get(c.Param("type"))
+	if err != nil {
+		h.respondError(c, "Gagal mengambil format nomor dokumen", err)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Format nomor dokumen berhasil diambil", nil, seq)
+}
+
+func (h *Handler) Update(c *gin.Context) {
+	var input Sequence
+	if err := c.ShouldBind(&input); err != nil {
+		utils.Respond(c, http.StatusBadRequest, "Input tidak valid", err.Error(), nil)
+		return
+	}
+	seq, err := h.service.Update(c.Param("type"), &input)
+	if err != nil {
+		h.respondError(c, "Gagal memperbarui format nomor dokumen", err)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Format nomor dokumen berhasil diperbarui", nil, seq)
+}
+
+func (h *Handler) Preview(c *gin.Context) {
+	number, err := h.service.Preview(c.Param("type"), time.Now())
+	if err != nil {
+		h.respondError(c, "Gagal menampilkan nomor berikutnya", err)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Nomor berikutnya", nil, gin.H{"next": number})
+}
+
+func (h *Handler) respondError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, ErrUnknownType):
+		utils.Respond(c, http.StatusNotFound, err.Error(), err.Error(), nil)
+	case errors.Is(err, ErrInvalidFormat), errors.Is(err, ErrDateRequired):
+		utils.Respond(c, http.StatusBadRequest, err.Error(), err.Error(), nil)
+	case errors.Is(err, ErrResetLocked):
+		utils.Respond(c, http.StatusConflict, err.Error(), err.Error(), nil)
+	default:
+		utils.Respond(c, http.StatusInternalServerError, message, err.Error(), nil)
+	}
+}
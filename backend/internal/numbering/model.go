@@ -0,0 +1,113 @@
+package numbering
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Jenis dokumen yang nomornya diterbitkan lewat sequence
+const (
+	SalesRegular    = "sales_regular"
+	Prescription    = "prescription"
+	IncomingPBF     = "incoming_pbf"
+	IncomingNonPBF  = "incoming_nonpbf"
+	Transaksi       = "transaksi"
+	StockOpname     = "stock_opname"
+	StockAdjustment = "stock_adjustment"
+	HeldSale        = "held_sale"
+)
+
+// Periode reset nomor urut
+const (
+	ResetNever   = "never"
+	ResetYearly  = "yearly"
+	ResetMonthly = "monthly"
+)
+
+// Format bagian tanggal pada nomor dokumen
+var dateFormats = map[string]string{
+	"":         "",
+	"YYYY":     "2006",
+	"YYMM":     "0601",
+	"YYYYMM":   "200601",
+	"YYYYMMDD": "20060102",
+}
+
+// Sequence adalah format nomor satu jenis dokumen, contoh dengan prefix SR,
+// DateFormat YYYYMM, Padding 5: SR-202601-00001
+type Sequence struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	DocType    string    `gorm:"type:varchar(50);not null;uniqueIndex" json:"doc_type"`
+	Name       string    `gorm:"type:varchar(100);not null" json:"name"`
+	Prefix     string    `gorm:"type:varchar(20);not null" json:"prefix" form:"prefix"`
+	DateFormat string    `gorm:"type:varchar(10)" json:"date_format" form:"date_format"`
+	Separator  string    `gorm:"type:varchar(3)" json:"separator" form:"separator"`
+	Padding    int       `gorm:"not null" json:"padding" form:"padding"`
+	Reset      string    `gorm:"type:varchar(10);not null" json:"reset" form:"reset"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (Sequence) TableName() string {
+	return "document_sequences"
+}
+
+// Counter menyimpan nomor urut terakhir per jenis dokumen dan periode.
+// Baris ini dikunci selama transaksi pembuat dokumen berjalan sehingga
+// nomor yang batal dipakai ikut dikembalikan saat rollback.
+type Counter struct {
+	DocType   string    `gorm:"type:varchar(50);primaryKey" json:"doc_type"`
+	Period    string    `gorm:"type:varchar(8);primaryKey" json:"period"`
+	Value     int64     `gorm:"not null" json:"value"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Counter) TableName() string {
+	return "document_counters"
+}
+
+// defaults dipakai selama format belum diubah pengguna
+var defaults = []Sequence{
+	{DocType: SalesRegular, Name: "Penjualan bebas", Prefix: "SR", DateFormat: "YYYYMM", Separator: "-", Padding: 5, Reset: ResetMonthly},
+	{DocType: Prescription, Name: "Penjualan resep", Prefix: "RSP", DateFormat: "YYYYMM", Separator: "-", Padding: 5, Reset: ResetMonthly},
+	{DocType: IncomingPBF, Name: "Barang masuk PBF", Prefix: "PBF", DateFormat: "YYYYMM", Separator: "-", Padding: 4, Reset: ResetMonthly},
+	{DocType: IncomingNonPBF, Name: "Barang masuk non-PBF", Prefix: "NONPBF", DateFormat: "YYYYMM", Separator: "-", Padding: 4, Reset: ResetMonthly},
+	{DocType: Transaksi, Name: "Transaksi obat", Prefix: "INV", DateFormat: "YYYYMM", Separator: "-", Padding: 5, Reset: ResetMonthly},
+	{DocType: StockOpname, Name: "Stok opname", Prefix: "OPN", DateFormat: "YYYY", Separator: "-", Padding: 4, Reset: ResetYearly},
+	{DocType: StockAdjustment, Name: "Penyesuaian stok", Prefix: "ADJ", DateFormat: "YYYY", Separator: "-", Padding: 5, Reset: ResetYearly},
+	{DocType: HeldSale, Name: "Transaksi tertahan", Prefix: "HOLD", DateFormat: "YYYYMMDD", Separator: "-", Padding: 4, Reset: ResetMonthly},
+}
+
+func defaultFor(docType string) (Sequence, bool) {
+	for _, d := range defaults {
+		if d.DocType == docType {
+			return d, true
+		}
+	}
+	return Sequence{}, false
+}
+
+// period adalah kunci counter untuk tanggal dokumen sesuai aturan reset
+func (s Sequence) period(at time.Time) string {
+	switch s.Reset {
+	case ResetYearly:
+		return at.Format("2006")
+	case ResetMonthly:
+		return at.Format("200601")
+	default:
+		return ""
+	}
+}
+
+// Format menyusun nomor dokumen dari tanggal dan nomor urut
+func (s Sequence) Format(at time.Time, value int64) string {
+	parts := []string{}
+	if s.Prefix != "" {
+		parts = append(parts, s.Prefix)
+	}
+	if layout := dateFormats[s.DateFormat]; layout != "" {
+		parts = append(parts, at.Format(layout))
+	}
+	parts = append(parts, fmt.Sprintf("%0*d", s.Padding, value))
+	return strings.Join(parts, s.Separator)
+}
@@ -0,0 +1,56 @@
+package numbering
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSequenceFormat(t *testing.T) {
+	at := time.Date(2026, time.March, 7, 10, 30, 0, 0, time.Local)
+	tests := []struct {
+		name  string
+		seq   Sequence
+		value int64
+		want  string
+	}{
+		{"bawaan penjualan bebas", Sequence{Prefix: "SR", DateFormat: "YYYYMM", Separator: "-", Padding: 5}, 1, "SR-202603-00001"},
+		{"tahun saja", Sequence{Prefix: "OPN", DateFormat: "YYYY", Separator: "-", Padding: 4}, 12, "OPN-2026-0012"},
+		{"tahun dua digit", Sequence{Prefix: "INV", DateFormat: "YYMM", Separator: "/", Padding: 3}, 7, "INV/2603/007"},
+		{"tanggal lengkap", Sequence{Prefix: "HOLD", DateFormat: "YYYYMMDD", Separator: "-", Padding: 4}, 3, "HOLD-20260307-0003"},
+		{"tanpa tanggal", Sequence{Prefix: "ADJ", Separator: "-", Padding: 5}, 42, "ADJ-00042"},
+		{"tanpa prefix", Sequence{DateFormat: "YYYYMM", Separator: "-", Padding: 4}, 5, "202603-0005"},
+		{"tanpa separator", Sequence{Prefix: "SR", DateFormat: "YYYYMM", Padding: 3}, 9, "SR202603009"},
+		{"nomor melebihi padding", Sequence{Prefix: "SR", Separator: "-", Padding: 2}, 1234, "SR-1234"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.seq.Format(at, tt.value); got != tt.want {
+				t.Errorf("Format() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSequencePeriod(t *testing.T) {
+	tests := []struct {
+		name  string
+		reset string
+		at    time.Time
+		want  string
+	}{
+		{"bulanan", ResetMonthly, time.Date(2026, time.January, 31, 23, 59, 0, 0, time.Local), "202601"},
+		{"bulanan awal bulan berikutnya", ResetMonthly, time.Date(2026, time.February, 1, 0, 0, 0, 0, time.Local), "202602"},
+		{"tahunan", ResetYearly, time.Date(2026, time.December, 31, 0, 0, 0, 0, time.Local), "2026"},
+		{"tidak pernah", ResetNever, time.Date(2026, time.June, 1, 0, 0, 0, 0, time.Local), ""},
+		{"tidak dikenal dianggap tidak pernah", "weekly", time.Date(2026, time.June, 1, 0, 0, 0, 0, time.Local), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (Sequence{Reset: tt.reset}).period(tt.at); got != tt.want {
+				t.Errorf("period() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
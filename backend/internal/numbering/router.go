@@ -0,0 +1,20 @@
+package numbering
+
+import (
+	"go-gin-auth/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func NumberingRouter(api *gin.RouterGroup) {
+	service := NewService(config.DB)
+	handler := NewHandler(service)
+
+	sequences := api.Group("/document-sequences")
+	{
+		sequences.GET("", handler.GetAll)
+		sequences.GET("/:type", handler.GetByType)
+		sequences.GET("/:type/preview", handler.Preview)
+		sequences.PUT("/:type", handler.Update)
+	}
+}
@@ -0,0 +1,154 @@
+package numbering
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnknownType   = errors.New("jenis dokumen tidak dikenal")
+	ErrInvalidFormat = errors.New("format nomor tidak valid")
+	ErrDateRequired  = errors.New("format tanggal harus memuat tahun untuk reset tahunan dan bulan untuk reset bulanan agar nomor tidak berulang")
+	ErrResetLocked   = errors.New("aturan reset tidak dapat diubah setelah nomor diterbitkan karena nomor urut akan mulai ulang dan berulang")
+)
+
+// Next menerbitkan nomor dokumen berikutnya di dalam transaksi pembuat
+// dokumen. Counter dinaikkan dengan upsert sehingga baris counter terkunci
+// sampai transaksi selesai; bila transaksi dibatalkan nomor ikut kembali,
+// sehingga nomor yang tersimpan tidak berlubang.
+func Next(tx *gorm.DB, docType string, at time.Time) (string, error) {
+	seq, err := load(tx, docType)
+	if err != nil {
+		return "", err
+	}
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	var value int64
+	err = tx.Raw(`
+		INSERT INTO document_counters (doc_type, period, value, updated_at)
+		VALUES (?, ?, 1, ?)
+		ON CONFLICT (doc_type, period)
+		DO UPDATE SET value = document_counters.value + 1, updated_at = EXCLUDED.updated_at
+		RETURNING value`, docType, seq.period(at), time.Now()).Scan(&value).Error
+	if err != nil {
+		return "", err
+	}
+	return seq.Format(at, value), nil
+}
+
+func load(db *gorm.DB, docType string) (Sequence, error) {
+	def, ok := defaultFor(docType)
+	if !ok {
+		return Sequence{}, ErrUnknownType
+	}
+	var seq Sequence
+	err := db.Where("doc_type = ?", docType).First(&seq).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return def, nil
+	}
+	return seq, err
+}
+
+type Service struct {
+	db *gorm.DB
+}
+
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db}
+}
+
+// GetAll mengambil format semua jenis dokumen, termasuk yang masih bawaan
+func (s *Service) GetAll() ([]Sequence, error) {
+	sequences := make([]Sequence, 0, len(defaults))
+	for _, d := range defaults {
+		seq, err := load(s.db, d.DocType)
+		if err != nil {
+			return nil, err
+		}
+		sequences = append(sequences, seq)
+	}
+	return sequences, nil
+}
+
+func (s *Service) Get(docType string) (*Sequence, error) {
+	seq, err := load(s.db, docType)
+	if err != nil {
+		return nil, err
+	}
+	return &seq, nil
+}
+
+// Update mengubah format nomor. Nomor urut periode berjalan tetap
+// dilanjutkan agar tidak ada nomor ganda. Aturan reset menentukan kunci
+// periode counter, sehingga hanya boleh diubah selama belum ada nomor yang
+// diterbitkan.
+func (s *Service) Update(docType string, input *Sequence) (*Sequence, error) {
+	existing, err := load(s.db, docType)
+	if err != nil {
+		return nil, err
+	}
+	if err := validate(input); err != nil {
+		return nil, err
+	}
+	if input.Reset != existing.Reset {
+		var issued int64
+		if err := s.db.Model(&Counter{}).Where("doc_type = ?", docType).Count(&issued).Error; err != nil {
+			return nil, err
+		}
+		if issued > 0 {
+			return nil, ErrResetLocked
+		}
+	}
+
+	existing.Prefix = strings.TrimSpace(input.Prefix)
+	existing.DateFormat = input.DateFormat
+	existing.Separator = input.Separator
+	existing.Padding = input.Padding
+	existing.Reset = input.Reset
+	if err := s.db.Save(&existing).Error; err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+// Preview menampilkan nomor berikutnya tanpa menerbitkannya
+func (s *Service) Preview(docType string, at time.Time) (string, error) {
+	seq, err := load(s.db, docType)
+	if err != nil {
+		return "", err
+	}
+	var counter Counter
+	err = s.db.Where("doc_type = ? AND period = ?", docType, seq.period(at)).First(&counter).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+	return seq.Format(at, counter.Value+1), nil
+}
+
+func validate(input *Sequence) error {
+	if _, ok := dateFormats[input.DateFormat]; !ok {
+		return ErrInvalidFormat
+	}
+	if input.Padding < 1 || input.Padding > 10 || len(input.Separator) > 3 {
+		return ErrInvalidFormat
+	}
+	switch input.Reset {
+	case ResetNever:
+	case ResetYearly:
+		if !strings.Contains(input.DateFormat, "YY") {
+			return ErrDateRequired
+		}
+	case ResetMonthly:
+		if !strings.Contains(input.DateFormat, "MM") {
+			return ErrDateRequired
+		}
+	default:
+		return ErrInvalidFormat
+	}
+	return nil
+}
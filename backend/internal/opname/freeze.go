@@ -0,0 +1,34 @@
+package opname
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// EnsureNotFrozen menolak mutasi stok untuk produk yang sedang dihitung pada
+// opname in_progress dengan kebijakan Freeze.
+func EnsureNotFrozen(db *gorm.DB, productIDs []uint) error {
+	if len(productIDs) == 0 {
+		return nil
+	}
+
+	var frozen []struct {
+		ProductID uint
+		OpnameID  string
+	}
+	err := db.Table("stock_opname_details d").
+		Select("d.product_id, d.opname_id").
+		Joins("JOIN stock_opnames o ON o.opname_id = d.opname_id").
+		Where("o.status = ? AND o.movement_policy = ?", InProgress, Freeze).
+		Where("d.product_id IN ?", productIDs).
+		Scan(&frozen).Error
+	if err != nil {
+		return err
+	}
+
+	if len(frozen) > 0 {
+		return fmt.Errorf("produk ID %d sedang dalam stok opname %s, mutasi stok dibekukan", frozen[0].ProductID, frozen[0].OpnameID)
+	}
+	return nil
+}
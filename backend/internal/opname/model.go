@@ -15,6 +15,13 @@ type StockOpnameDetail struct {
 	Discrepancy           int             `json:"discrepancy" gorm:"not null"`
 	DiscrepancyPercentage float64         `json:"discrepancy_percentage" gorm:"not null"`
 	AdjustmentNote        string          `json:"adjustment_note"`
+	SnapshotAt            *time.Time      `json:"snapshot_at"`
+	MovementSinceSnapshot int             `json:"movement_since_snapshot" gorm:"default:0"`
+	FinalStock            int             `json:"final_stock" gorm:"default:0"`
+	CostPrice             float64         `json:"cost_price" gorm:"type:decimal(15,2);default:0"`
+	SellingPrice          float64         `json:"selling_price" gorm:"type:decimal(15,2);default:0"`
+	VarianceCost          float64         `json:"variance_cost" gorm:"type:decimal(15,2);default:0"`
+	VarianceSelling       float64         `json:"variance_selling" gorm:"type:decimal(15,2);default:0"`
 	PerformedBy           string          `json:"performed_by" gorm:"not null"`
 	PerformedAt           time.Time       `json:"performed_at" gorm:"autoCreateTime"`
 	Product               product.Product `json:"product" gorm:"foreignKey:ProductID;references:ID"`
@@ -33,6 +40,21 @@ func (d *StockOpnameDetail) CalculateDiscrepancy() {
 	}
 }
 
+// ApplyMovement menghitung stok akhir dari hasil hitung fisik ditambah mutasi
+// yang terjadi sejak snapshot SystemStock diambil.
+func (d *StockOpnameDetail) ApplyMovement(currentStock int) {
+	d.MovementSinceSnapshot = currentStock - d.SystemStock
+	d.FinalStock = d.ActualStock + d.MovementSinceSnapshot
+}
+
+// Valuate menilai selisih hitung dengan harga pokok dan harga jual produk
+func (d *StockOpnameDetail) Valuate(costPrice, sellingPrice float64) {
+	d.CostPrice = costPrice
+	d.SellingPrice = sellingPrice
+	d.VarianceCost = float64(d.Discrepancy) * costPrice
+	d.VarianceSelling = float64(d.Discrepancy) * sellingPrice
+}
+
 type StockOpnameStatus string
 
 const (
@@ -42,6 +64,17 @@ const (
 	Canceled   StockOpnameStatus = "canceled"
 )
 
+// MovementPolicy menentukan perlakuan terhadap mutasi stok (penjualan, koreksi)
+// yang terjadi selama opname berstatus in_progress.
+type MovementPolicy string
+
+const (
+	// Reconcile: mutasi tetap berjalan, penyesuaian dihitung dari mutasi sejak snapshot.
+	Reconcile MovementPolicy = "reconcile"
+	// Freeze: mutasi pada produk yang sedang dihitung ditolak sampai opname selesai.
+	Freeze MovementPolicy = "freeze"
+)
+
 type JenisStokOpname string
 
 const (
@@ -54,16 +87,39 @@ type StockOpname struct {
 	// UserID    uint                `gorm:"not null" json:"user_id"`
 	// CreatedAt time.Time           `gorm:"autoCreateTime" json:"created_at"`
 	// Details   []StockOpnameDetail `gorm:"foreignKey:StockOpnameID;constraint:OnDelete:CASCADE;" json:"details,omitempty"`
-	OpnameID   string              `json:"opname_id" gorm:"primaryKey"`
-	OpnameDate time.Time           `json:"opname_date" gorm:"not null"`
-	StartTime  time.Time           `json:"start_time"`
-	EndTime    time.Time           `json:"end_time"`
-	Status     StockOpnameStatus   `json:"status" gorm:"default:'draft'"`
-	Notes      string              `json:"notes"`
-	Jenis      JenisStokOpname     `gorm:"column:jenis_stok_opname;type:varchar(20);not null" json:"jenis_stok_opname"`
-	FlagActive bool                `gorm:"column:flag_active;default:true"`
-	CreatedBy  string              `json:"created_by" gorm:"not null"`
-	CreatedAt  time.Time           `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt  time.Time           `json:"updated_at" gorm:"autoUpdateTime"`
-	Details    []StockOpnameDetail `json:"details" gorm:"foreignKey:OpnameID"`
+	OpnameID             string              `json:"opname_id" gorm:"primaryKey"`
+	OpnameDate           time.Time           `json:"opname_date" gorm:"not null"`
+	StartTime            time.Time           `json:"start_time"`
+	EndTime              time.Time           `json:"end_time"`
+	Status               StockOpnameStatus   `json:"status" gorm:"default:'draft'"`
+	Notes                string              `json:"notes"`
+	Jenis                JenisStokOpname     `gorm:"column:jenis_stok_opname;type:varchar(20);not null" json:"jenis_stok_opname"`
+	Policy               MovementPolicy      `gorm:"column:movement_policy;type:varchar(20);default:'reconcile'" json:"movement_policy"`
+	FlagActive           bool                `gorm:"column:flag_active;default:true"`
+	CreatedBy            string              `json:"created_by" gorm:"not null"`
+	CompletedBy          string              `json:"completed_by"`
+	TotalVarianceCost    float64             `gorm:"type:decimal(15,2);default:0" json:"total_variance_cost"`
+	TotalVarianceSelling float64             `gorm:"type:decimal(15,2);default:0" json:"total_variance_selling"`
+	ShrinkagePercent     float64             `gorm:"default:0" json:"shrinkage_percent"`
+	CreatedAt            time.Time           `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt            time.Time           `json:"updated_at" gorm:"autoUpdateTime"`
+	Details              []StockOpnameDetail `json:"details" gorm:"foreignKey:OpnameID"`
+}
+
+// Summarize menjumlahkan nilai selisih seluruh detail. ShrinkagePercent bernilai
+// positif bila terjadi kehilangan, dihitung terhadap nilai stok sistem pada harga pokok.
+func (o *StockOpname) Summarize() {
+	var systemValue float64
+	o.TotalVarianceCost = 0
+	o.TotalVarianceSelling = 0
+	for _, d := range o.Details {
+		o.TotalVarianceCost += d.VarianceCost
+		o.TotalVarianceSelling += d.VarianceSelling
+		systemValue += float64(d.SystemStock) * d.CostPrice
+	}
+
+	o.ShrinkagePercent = 0
+	if systemValue != 0 {
+		o.ShrinkagePercent = -o.TotalVarianceCost * 100.0 / systemValue
+	}
 }
@@ -0,0 +1,60 @@
+package opname
+
+import "testing"
+
+func TestApplyMovement(t *testing.T) {
+	tests := []struct {
+		name         string
+		system       int
+		actual       int
+		current      int
+		wantMovement int
+		wantFinal    int
+	}{
+		{"tanpa mutasi", 100, 95, 100, 0, 95},
+		{"terjual selama hitung", 100, 95, 90, -10, 85},
+		{"barang masuk selama hitung", 100, 95, 120, 20, 115},
+		{"hitung fisik sesuai, ada penjualan", 50, 50, 47, -3, 47},
+		{"stok habis terjual", 10, 8, 0, -10, -2},
+		{"produk baru tanpa stok sistem", 0, 5, 12, 12, 17},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := StockOpnameDetail{SystemStock: tt.system, ActualStock: tt.actual}
+			d.ApplyMovement(tt.current)
+			if d.MovementSinceSnapshot != tt.wantMovement {
+				t.Errorf("MovementSinceSnapshot = %d, want %d", d.MovementSinceSnapshot, tt.wantMovement)
+			}
+			if d.FinalStock != tt.wantFinal {
+				t.Errorf("FinalStock = %d, want %d", d.FinalStock, tt.wantFinal)
+			}
+		})
+	}
+}
+
+func TestCalculateDiscrepancy(t *testing.T) {
+	tests := []struct {
+		name        string
+		system      int
+		actual      int
+		discrepancy int
+		percentage  float64
+	}{
+		{"sesuai", 40, 40, 0, 0},
+		{"kurang", 40, 30, -10, -25},
+		{"lebih", 40, 50, 10, 25},
+		{"stok sistem nol, fisik ada", 0, 7, 7, 100},
+		{"stok sistem dan fisik nol", 0, 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := StockOpnameDetail{SystemStock: tt.system, ActualStock: tt.actual}
+			d.CalculateDiscrepancy()
+			if d.Discrepancy != tt.discrepancy || d.DiscrepancyPercentage != tt.percentage {
+				t.Errorf("got (%d, %v), want (%d, %v)", d.Discrepancy, d.DiscrepancyPercentage, tt.discrepancy, tt.percentage)
+			}
+		})
+	}
+}
@@ -2,6 +2,9 @@ package outgoingProducts
 
 import (
 	"errors"
+	"go-gin-auth/config"
+	"go-gin-auth/internal/narcotic"
+	"go-gin-auth/internal/opname"
 	"go-gin-auth/internal/stock"
 )
 
@@ -42,6 +45,14 @@ func (s *service) CreateOutgoingProduct(outgoingProduct *OutgoingProduct, detail
 		return errors.New("detail produk keluar tidak boleh kosong")
 	}
 
+	productIDs := detailProductIDs(details)
+	if err := narcotic.EnsureNotControlled(config.DB, productIDs); err != nil {
+		return err
+	}
+	if err := opname.EnsureNotFrozen(config.DB, productIDs); err != nil {
+		return err
+	}
+
 	// Hitung total setiap detail
 	for i := range details {
 		if details[i].ProductID == 0 {
@@ -102,6 +113,9 @@ func (s *service) UpdateOutgoingProductDetails(details []OutgoingProductDetail)
 	if len(details) == 0 {
 		return errors.New("detail produk keluar tidak boleh kosong")
 	}
+	if err := opname.EnsureNotFrozen(config.DB, detailProductIDs(details)); err != nil {
+		return err
+	}
 
 	// Hitung total setiap detail
 	for i := range details {
@@ -144,6 +158,9 @@ func (s *service) DeleteOutgoingProduct(id uint) error {
 	if err != nil {
 		return err
 	}
+	if err := opname.EnsureNotFrozen(config.DB, detailProductIDs(details)); err != nil {
+		return err
+	}
 
 	for _, detail := range details {
 		// Kembalikan stok saat menghapus produk keluar (true untuk menambah)
@@ -154,3 +171,11 @@ func (s *service) DeleteOutgoingProduct(id uint) error {
 
 	return s.repository.Delete(id)
 }
+
+func detailProductIDs(details []OutgoingProductDetail) []uint {
+	ids := make([]uint, 0, len(details))
+	for _, detail := range details {
+		ids = append(ids, detail.ProductID)
+	}
+	return ids
+}
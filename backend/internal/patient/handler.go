@@ -105,3 +105,23 @@ func (h *Handler) DeletePatient(c *gin.Context) {
 	}
 	utils.Respond(c, http.StatusOK, "Pasien berhasil dinonaktifkan", nil, nil)
 }
+
+// GetMedications menampilkan riwayat obat pasien dari resep, racikan dan
+// penjualan bebas beserta obat rutin dan perkiraan tanggal tebus ulang
+func (h *Handler) GetMedications(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.Respond(c, http.StatusBadRequest, "Parameter ID tidak valid", err.Error(), nil)
+		return
+	}
+	profile, err := h.service.GetMedicationProfile(uint(id))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			utils.Respond(c, http.StatusNotFound, err.Error(), err.Error(), nil)
+		} else {
+			utils.Respond(c, http.StatusInternalServerError, "Gagal mengambil riwayat obat pasien", err.Error(), nil)
+		}
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Riwayat obat pasien berhasil diambil", nil, profile)
+}
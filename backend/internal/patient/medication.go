@@ -0,0 +1,192 @@
+package patient
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sumber riwayat obat pasien
+const (
+	SourcePrescription = "resep"
+	SourceCompound     = "racikan"
+	SourceRegular      = "reguler"
+)
+
+const (
+	// ChronicWindowDays adalah rentang riwayat yang dipakai untuk mengenali
+	// obat rutin
+	ChronicWindowDays = 180
+	// ChronicMinSpanDays adalah jarak minimum penyerahan pertama dan
+	// terakhir agar obat yang diserahkan berulang dianggap rutin
+	ChronicMinSpanDays = 21
+)
+
+// Medication adalah satu obat yang pernah diterima pasien, baik lewat resep,
+// racikan maupun penjualan bebas kepada pelanggan yang terhubung ke pasien
+type Medication struct {
+	Date           time.Time `json:"date"`
+	Source         string    `json:"source"`
+	SaleID         uint      `json:"sale_id"`
+	SaleCode       string    `json:"sale_code"`
+	PrescriptionNo string    `json:"prescription_no,omitempty"`
+	Doctor         string    `json:"doctor,omitempty"`
+	Diagnosis      string    `json:"diagnosis,omitempty"`
+	ProductID      uint      `json:"product_id"`
+	ProductCode    string    `json:"product_code"`
+	ProductName    string    `json:"product_name"`
+	Quantity       float64   `json:"quantity"`
+	Unit           string    `json:"unit"`
+	Signa          string    `json:"signa,omitempty"`
+	Iter           int       `json:"iter,omitempty"`
+	// CompoundName diisi untuk komponen racikan
+	CompoundName string `json:"compound_name,omitempty"`
+	// SupplyQuantity adalah jumlah yang dimaksud signa, yaitu jumlah item
+	// atau jumlah sediaan racikan
+	SupplyQuantity float64 `json:"-"`
+}
+
+// ChronicMedication adalah obat yang diterima pasien berulang kali beserta
+// perkiraan tanggal obat habis dan perlu ditebus kembali
+type ChronicMedication struct {
+	ProductID      uint      `json:"product_id"`
+	ProductCode    string    `json:"product_code"`
+	ProductName    string    `json:"product_name"`
+	Dispensings    int       `json:"dispensings"`
+	FirstDispensed time.Time `json:"first_dispensed"`
+	LastDispensed  time.Time `json:"last_dispensed"`
+	LastQuantity   float64   `json:"last_quantity"`
+	Unit           string    `json:"unit"`
+	Signa          string    `json:"signa,omitempty"`
+	// DailyDose dihitung dari signa terakhir; DaysSupply dari signa atau
+	// rata-rata jarak penyerahan bila signa tidak terbaca
+	DailyDose      float64    `json:"daily_dose,omitempty"`
+	DaysSupply     int        `json:"days_supply,omitempty"`
+	ExpectedRefill *time.Time `json:"expected_refill,omitempty"`
+	Overdue        bool       `json:"overdue"`
+}
+
+// MedicationProfile adalah riwayat obat pasien untuk konseling dan
+// pemeriksaan interaksi
+type MedicationProfile struct {
+	Patient     *Patient            `json:"patient"`
+	Medications []Medication        `json:"medications"`
+	Chronic     []ChronicMedication `json:"chronic"`
+}
+
+var signaPattern = regexp.MustCompile(`(\d+)\s*(?:dd|d\.d\.|x|×)\s*(\d+(?:[.,]\d+)?(?:/\d+)?)`)
+
+// dailyDose membaca jumlah pemakaian per hari dari signa seperti "3 dd 1",
+// "2x1/2" atau "S 3 dd tab 1". Nilai 0 berarti signa tidak terbaca.
+func dailyDose(signa string) float64 {
+	signa = strings.ToLower(signa)
+	signa = strings.NewReplacer("tab", "", "caps", "", "cth", "", "c.", "").Replace(signa)
+	match := signaPattern.FindStringSubmatch(signa)
+	if match == nil {
+		return 0
+	}
+	frequency, _ := strconv.ParseFloat(match[1], 64)
+
+	dose := 0.0
+	amount := strings.ReplaceAll(match[2], ",", ".")
+	if parts := strings.SplitN(amount, "/", 2); len(parts) == 2 {
+		numerator, _ := strconv.ParseFloat(parts[0], 64)
+		denominator, _ := strconv.ParseFloat(parts[1], 64)
+		if denominator > 0 {
+			dose = numerator / denominator
+		}
+	} else {
+		dose, _ = strconv.ParseFloat(amount, 64)
+	}
+	return frequency * dose
+}
+
+// chronicMedications mengenali obat rutin dari riwayat yang sudah urut
+// tanggal: obat yang diresepkan dengan iter, atau diserahkan minimal dua
+// kali dengan jarak ChronicMinSpanDays hari dalam ChronicWindowDays terakhir
+func chronicMedications(medications []Medication, now time.Time) []ChronicMedication {
+	since := now.AddDate(0, 0, -ChronicWindowDays)
+
+	var order []uint
+	byProduct := make(map[uint][]Medication)
+	for _, m := range medications {
+		if m.Date.Before(since) {
+			continue
+		}
+		if _, ok := byProduct[m.ProductID]; !ok {
+			order = append(order, m.ProductID)
+		}
+		byProduct[m.ProductID] = append(byProduct[m.ProductID], m)
+	}
+
+	chronic := []ChronicMedication{}
+	for _, id := range order {
+		history := dispensings(byProduct[id])
+		first, last := history[0], history[len(history)-1]
+		iter := false
+		for _, m := range history {
+			iter = iter || m.Iter > 0
+		}
+		span := last.Date.Sub(first.Date).Hours() / 24
+		if !iter && (len(history) < 2 || span < ChronicMinSpanDays) {
+			continue
+		}
+
+		item := ChronicMedication{
+			ProductID:      id,
+			ProductCode:    last.ProductCode,
+			ProductName:    last.ProductName,
+			Dispensings:    len(history),
+			FirstDispensed: first.Date,
+			LastDispensed:  last.Date,
+			LastQuantity:   last.Quantity,
+			Unit:           last.Unit,
+			Signa:          last.Signa,
+			DailyDose:      dailyDose(last.Signa),
+		}
+		switch {
+		case item.DailyDose > 0 && last.SupplyQuantity > 0:
+			item.DaysSupply = int(math.Ceil(last.SupplyQuantity / item.DailyDose))
+		case len(history) > 1:
+			item.DaysSupply = int(math.Round(span / float64(len(history)-1)))
+		}
+		if item.DaysSupply > 0 {
+			refill := last.Date.AddDate(0, 0, item.DaysSupply)
+			item.ExpectedRefill = &refill
+			item.Overdue = refill.Before(now)
+		}
+		chronic = append(chronic, item)
+	}
+
+	sort.SliceStable(chronic, func(i, j int) bool {
+		if chronic[i].ExpectedRefill == nil || chronic[j].ExpectedRefill == nil {
+			return chronic[j].ExpectedRefill == nil && chronic[i].ExpectedRefill != nil
+		}
+		return chronic[i].ExpectedRefill.Before(*chronic[j].ExpectedRefill)
+	})
+	return chronic
+}
+
+// dispensings menggabungkan baris produk yang sama pada satu transaksi,
+// misalnya komponen racikan yang juga diresepkan sebagai item
+func dispensings(history []Medication) []Medication {
+	merged := make([]Medication, 0, len(history))
+	index := make(map[string]int)
+	for _, m := range history {
+		key := m.Source + ":" + strconv.FormatUint(uint64(m.SaleID), 10)
+		if m.Source == SourceCompound {
+			key = SourcePrescription + ":" + strconv.FormatUint(uint64(m.SaleID), 10)
+		}
+		if i, ok := index[key]; ok {
+			merged[i].Quantity += m.Quantity
+			merged[i].SupplyQuantity += m.SupplyQuantity
+			continue
+		}
+		index[key] = len(merged)
+		merged = append(merged, m)
+	}
+	return merged
+}
@@ -0,0 +1,98 @@
+package patient
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDailyDose(t *testing.T) {
+	tests := []struct {
+		signa string
+		want  float64
+	}{
+		{"3 dd 1", 3},
+		{"2x1/2", 1},
+		{"S 3 dd tab 1", 3},
+		{"s 2 dd caps 1", 2},
+		{"3 X 2", 6},
+		{"1 x 1,5", 1.5},
+		{"1 d.d. 1", 1},
+		{"2 × 1", 2},
+		{"2x1/0", 0},
+		{"prn", 0},
+		{"", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.signa, func(t *testing.T) {
+			if got := dailyDose(tt.signa); got != tt.want {
+				t.Errorf("dailyDose(%q) = %v, want %v", tt.signa, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChronicMedications(t *testing.T) {
+	day := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 0, 0, 0, 0, time.Local)
+	}
+	now := day(2026, time.March, 1)
+	medications := []Medication{
+		{Date: day(2025, time.June, 1), Source: SourceRegular, SaleID: 1, ProductID: 4, Quantity: 10},
+		{Date: day(2025, time.December, 1), Source: SourceRegular, SaleID: 2, ProductID: 5, Quantity: 10},
+		{Date: day(2025, time.December, 16), Source: SourceRegular, SaleID: 3, ProductID: 5, Quantity: 10},
+		{Date: day(2025, time.December, 31), Source: SourceRegular, SaleID: 4, ProductID: 5, Quantity: 10},
+		{Date: day(2026, time.January, 1), Source: SourcePrescription, SaleID: 5, ProductID: 1, Quantity: 30, SupplyQuantity: 30, Signa: "1 dd 1"},
+		{Date: day(2026, time.January, 1), Source: SourcePrescription, SaleID: 5, ProductID: 6, Quantity: 10, SupplyQuantity: 10, Signa: "2 dd 1"},
+		{Date: day(2026, time.January, 1), Source: SourceCompound, SaleID: 5, ProductID: 6, Quantity: 5, SupplyQuantity: 5},
+		{Date: day(2026, time.February, 1), Source: SourcePrescription, SaleID: 6, ProductID: 1, Quantity: 30, SupplyQuantity: 30, Signa: "1 dd 1"},
+		{Date: day(2026, time.February, 1), Source: SourcePrescription, SaleID: 6, ProductID: 6, Quantity: 20, SupplyQuantity: 20, Signa: "2 dd 1"},
+		{Date: day(2026, time.February, 10), Source: SourcePrescription, SaleID: 7, ProductID: 2, Quantity: 10, SupplyQuantity: 10, Signa: "prn", Iter: 1},
+		{Date: day(2026, time.February, 15), Source: SourceRegular, SaleID: 8, ProductID: 3, Quantity: 10},
+		{Date: day(2026, time.February, 20), Source: SourceRegular, SaleID: 9, ProductID: 3, Quantity: 10},
+		{Date: day(2026, time.February, 25), Source: SourceRegular, SaleID: 10, ProductID: 4, Quantity: 10},
+	}
+
+	date := func(t time.Time) *time.Time { return &t }
+	want := []struct {
+		productID   uint
+		dispensings int
+		daysSupply  int
+		refill      *time.Time
+		overdue     bool
+	}{
+		// tanpa signa, jumlah hari dari rata-rata jarak penyerahan
+		{5, 3, 15, date(day(2026, time.January, 15)), true},
+		// komponen racikan pada transaksi yang sama digabung
+		{6, 2, 10, date(day(2026, time.February, 11)), true},
+		{1, 2, 30, date(day(2026, time.March, 3)), false},
+		// iter tanpa signa terbaca tidak punya perkiraan tebus ulang
+		{2, 1, 0, nil, false},
+	}
+
+	got := chronicMedications(medications, now)
+	if len(got) != len(want) {
+		t.Fatalf("got %d chronic medications, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.ProductID != w.productID {
+			t.Fatalf("chronic[%d].ProductID = %d, want %d", i, g.ProductID, w.productID)
+		}
+		if g.Dispensings != w.dispensings {
+			t.Errorf("product %d dispensings = %d, want %d", w.productID, g.Dispensings, w.dispensings)
+		}
+		if g.DaysSupply != w.daysSupply {
+			t.Errorf("product %d days supply = %d, want %d", w.productID, g.DaysSupply, w.daysSupply)
+		}
+		switch {
+		case w.refill == nil && g.ExpectedRefill != nil:
+			t.Errorf("product %d expected refill = %v, want none", w.productID, *g.ExpectedRefill)
+		case w.refill != nil && (g.ExpectedRefill == nil || !g.ExpectedRefill.Equal(*w.refill)):
+			t.Errorf("product %d expected refill = %v, want %v", w.productID, g.ExpectedRefill, *w.refill)
+		}
+		if g.Overdue != w.overdue {
+			t.Errorf("product %d overdue = %v, want %v", w.productID, g.Overdue, w.overdue)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package patient
 
 import (
 	"errors"
+	"go-gin-auth/internal/payment"
 	"strings"
 
 	"gorm.io/gorm"
@@ -14,6 +15,7 @@ type Repository interface {
 	Update(id uint, patient *Patient) (*Patient, error)
 	Delete(id uint) error
 	FindActiveByIdentityNumber(identityNumber string) (*Patient, error)
+	GetMedications(patientID uint) ([]Medication, error)
 }
 
 type repository struct {
@@ -86,3 +88,53 @@ func (r *repository) Delete(id uint) error {
 	}
 	return nil
 }
+
+// GetMedications mengambil obat yang diterima pasien dari item resep,
+// komponen racikan dan penjualan bebas pelanggan yang terhubung ke pasien,
+// urut dari yang paling lama. Transaksi yang dibatalkan tidak disertakan.
+// Nama dokter masih terenkripsi.
+func (r *repository) GetMedications(patientID uint) ([]Medication, error) {
+	var medications []Medication
+	err := r.db.Raw(`
+		SELECT * FROM (
+			SELECT s.transaction_date AS date, CAST(? AS varchar) AS source, s.id AS sale_id, s.transaction_code AS sale_code,
+				s.prescription_no, COALESCE(d.full_name, '') AS doctor, COALESCE(s.diagnosis, '') AS diagnosis,
+				st.product_id, i.item_code AS product_code, i.item_name AS product_name,
+				i.quantity, i.unit, COALESCE(i.signa, '') AS signa, i.iter, '' AS compound_name,
+				i.quantity AS supply_quantity
+			FROM prescription_items i
+			JOIN prescription_sales s ON s.id = i.prescription_sale_id
+			JOIN stocks st ON st.id = i.stock_id
+			LEFT JOIN doctors d ON d.id = s.doctor_id
+			WHERE s.patient_id = ? AND s.status <> ? AND s.deleted_at IS NULL AND i.deleted_at IS NULL
+			UNION ALL
+			SELECT s.transaction_date, CAST(? AS varchar), s.id, s.transaction_code,
+				s.prescription_no, COALESCE(d.full_name, ''), COALESCE(s.diagnosis, ''),
+				k.product_id, k.item_code, k.item_name,
+				k.quantity, k.unit, COALESCE(c.signa, ''), 0, c.name,
+				c.quantity
+			FROM prescription_compound_components k
+			JOIN prescription_compounds c ON c.id = k.compound_id
+			JOIN prescription_sales s ON s.id = c.prescription_sale_id
+			LEFT JOIN doctors d ON d.id = s.doctor_id
+			WHERE s.patient_id = ? AND s.status <> ? AND s.deleted_at IS NULL
+			AND c.deleted_at IS NULL AND k.deleted_at IS NULL
+			UNION ALL
+			SELECT s.transaction_date, CAST(? AS varchar), s.id, s.sales_code,
+				'', '', '',
+				i.product_id, i.product_code, i.product_name,
+				i.qty, i.unit, '', 0, '',
+				i.qty
+			FROM sales_regular_items i
+			JOIN sales_regulars s ON s.id = i.sales_regular_id
+			JOIN customers cu ON cu.id = s.customer_id
+			WHERE cu.patient_id = ? AND s.status <> ? AND s.deleted_at IS NULL AND i.deleted_at IS NULL
+		) h
+		ORDER BY date, sale_id
+	`, SourcePrescription, patientID, payment.SaleVoided, SourceCompound, patientID, payment.SaleVoided,
+		SourceRegular, patientID, payment.SaleVoided).Scan(&medications).Error
+	if err != nil {
+		return nil, err
+	}
+	return medications, nil
+}
@@ -18,6 +18,7 @@ func PatientRouter(api *gin.RouterGroup) {
 		patientGroup.POST("/", handler.CreatePatient)
 		patientGroup.GET("/", handler.GetAllPatients)
 		patientGroup.GET("/:id", handler.GetPatientByID)
+		patientGroup.GET("/:id/medications", handler.GetMedications)
 		patientGroup.PUT("/:id", handler.UpdatePatient)
 		patientGroup.DELETE("/:id", handler.DeletePatient)
 	}
@@ -19,6 +19,7 @@ type Service interface {
 	GetPatientByID(id uint) (*Patient, error)
 	UpdatePatient(id uint, input *Patient) (*Patient, error)
 	DeletePatient(id uint) error
+	GetMedicationProfile(id uint) (*MedicationProfile, error)
 }
 
 type service struct {
@@ -194,3 +195,34 @@ func (s *service) DeletePatient(id uint) error {
 	}
 	return s.repository.Delete(id)
 }
+
+// GetMedicationProfile menyusun riwayat obat pasien beserta obat rutin dan
+// perkiraan tanggal tebus ulangnya
+func (s *service) GetMedicationProfile(id uint) (*MedicationProfile, error) {
+	patient, err := s.GetPatientByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	medications, err := s.repository.GetMedications(id)
+	if err != nil {
+		return nil, err
+	}
+	for i := range medications {
+		if medications[i].Doctor == "" {
+			continue
+		}
+		if name, err := utils.Decrypt(medications[i].Doctor); err == nil {
+			medications[i].Doctor = name
+		}
+	}
+	if medications == nil {
+		medications = []Medication{}
+	}
+
+	return &MedicationProfile{
+		Patient:     patient,
+		Medications: medications,
+		Chronic:     chronicMedications(medications, time.Now()),
+	}, nil
+}
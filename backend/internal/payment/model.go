@@ -0,0 +1,74 @@
+package payment
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Jenis transaksi pemilik baris pembayaran
+const (
+	SaleRegular      = "regular"
+	SalePrescription = "prescription"
+)
+
+// Status penjualan. Penjualan yang dibatalkan (void) tetap tersimpan namun
+// tidak dihitung sebagai pendapatan.
+const (
+	SaleCompleted = "Selesai"
+	SaleVoided    = "Batal"
+)
+
+// Metode pembayaran yang diterima kasir
+const (
+	Cash     = "Tunai"
+	Transfer = "Transfer"
+	QRIS     = "QRIS"
+	Debit    = "Debit"
+	Credit   = "Kredit"
+	BPJS     = "BPJS"
+	Jaminan  = "Jaminan"
+
+	// Mixed dipakai sebagai ringkasan payment_method pada header penjualan
+	// bila pembayaran dipecah ke lebih dari satu metode
+	Mixed = "Campuran"
+)
+
+var methods = []string{Cash, Transfer, QRIS, Debit, Credit, BPJS, Jaminan}
+
+// OnAccount menandai metode yang tidak diterima saat transaksi dan dicatat
+// sebagai piutang pelanggan atau penjamin
+func OnAccount(method string) bool {
+	return method == Credit || method == Jaminan
+}
+
+// Payment adalah satu baris pembayaran pada penjualan reguler maupun resep
+type Payment struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	SaleType    string         `gorm:"type:varchar(20);not null;index:idx_sale_payment" json:"sale_type"`
+	SaleID      uint           `gorm:"not null;index:idx_sale_payment" json:"sale_id"`
+	Method      string         `gorm:"type:varchar(30);not null" json:"method"`
+	Amount      float64        `gorm:"type:decimal(15,2);not null" json:"amount"`
+	ReferenceNo string         `gorm:"type:varchar(100)" json:"reference_no,omitempty"`
+	ChangeGiven float64        `gorm:"type:decimal(15,2);not null;default:0" json:"change_given"`
+	CreatedAt   time.Time      `json:"created_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+func (Payment) TableName() string {
+	return "sale_payments"
+}
+
+type PaymentRequest struct {
+	Method      string  `json:"method" binding:"required"`
+	Amount      float64 `json:"amount" binding:"required,gt=0"`
+	ReferenceNo string  `json:"reference_no"`
+}
+
+// MethodTotal adalah rekap pendapatan per metode pembayaran; Amount sudah
+// dikurangi kembalian
+type MethodTotal struct {
+	Method       string  `json:"method"`
+	Amount       float64 `json:"amount"`
+	Transactions int     `json:"transactions"`
+}
@@ -0,0 +1,167 @@
+package payment
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNoPayment      = errors.New("minimal satu pembayaran wajib diisi")
+	ErrSaleVoided     = errors.New("penjualan sudah dibatalkan (void)")
+	ErrUnderpaid      = errors.New("total pembayaran kurang dari total penjualan")
+	ErrNonCashExceeds = errors.New("pembayaran non-tunai melebihi total penjualan, kembalian hanya dari pembayaran tunai")
+)
+
+// Build memvalidasi baris pembayaran terhadap total penjualan dan menghitung
+// kembalian. Bila reqs kosong, fallbackMethod dipakai sebagai satu baris
+// pembayaran sebesar total agar klien lama tetap berjalan. Nilai kedua adalah
+// ringkasan metode untuk kolom payment_method pada header penjualan.
+func Build(reqs []PaymentRequest, fallbackMethod string, total float64) ([]Payment, string, error) {
+	if len(reqs) == 0 {
+		if fallbackMethod == "" {
+			return nil, "", ErrNoPayment
+		}
+		reqs = []PaymentRequest{{Method: fallbackMethod, Amount: total}}
+	}
+
+	var paid, nonCash float64
+	lastCash := -1
+	lines := make([]Payment, 0, len(reqs))
+	for i, r := range reqs {
+		method, ok := normalizeMethod(r.Method)
+		if !ok {
+			return nil, "", fmt.Errorf("metode pembayaran %q tidak dikenal", r.Method)
+		}
+		if r.Amount <= 0 {
+			return nil, "", fmt.Errorf("nominal pembayaran ke-%d harus lebih dari 0", i+1)
+		}
+
+		paid += r.Amount
+		if method == Cash {
+			lastCash = len(lines)
+		} else {
+			nonCash += r.Amount
+		}
+		lines = append(lines, Payment{Method: method, Amount: r.Amount, ReferenceNo: r.ReferenceNo})
+	}
+
+	if roundCent(paid) < roundCent(total) {
+		return nil, "", ErrUnderpaid
+	}
+	if roundCent(nonCash) > roundCent(total) {
+		return nil, "", ErrNonCashExceeds
+	}
+	// kembalian dihitung dari nilai yang sudah dibulatkan agar sama dengan
+	// pemeriksaan di atas; tanpa baris tunai tidak ada kembalian yang diberikan
+	if change := roundCent(paid) - roundCent(total); change > 0 {
+		if lastCash < 0 {
+			return nil, "", ErrNonCashExceeds
+		}
+		lines[lastCash].ChangeGiven = roundCent(change)
+	}
+
+	return lines, summarize(lines), nil
+}
+
+// Replace menghapus baris pembayaran lama milik penjualan lalu menyimpan yang baru
+func Replace(tx *gorm.DB, saleType string, saleID uint, lines []Payment) error {
+	if err := Remove(tx, saleType, saleID); err != nil {
+		return err
+	}
+	for i := range lines {
+		lines[i].ID = 0
+		lines[i].SaleType = saleType
+		lines[i].SaleID = saleID
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return tx.Create(&lines).Error
+}
+
+// Remove menghapus (soft delete) semua baris pembayaran milik penjualan
+func Remove(tx *gorm.DB, saleType string, saleID uint) error {
+	return tx.Where("sale_type = ? AND sale_id = ?", saleType, saleID).Delete(&Payment{}).Error
+}
+
+// BreakdownByShift merekap pendapatan per metode pembayaran dalam satu shift
+func BreakdownByShift(db *gorm.DB, shiftID uint) ([]MethodTotal, error) {
+	return breakdown(db, "s.shift_id = ?", shiftID)
+}
+
+// BreakdownByPeriod merekap pendapatan per metode pembayaran pada rentang tanggal transaksi
+func BreakdownByPeriod(db *gorm.DB, start, end time.Time) ([]MethodTotal, error) {
+	return breakdown(db, "s.transaction_date >= ? AND s.transaction_date <= ?", start, end)
+}
+
+// breakdown menggabungkan baris pembayaran dengan penjualan lama yang belum
+// memiliki baris pembayaran (memakai kolom payment_method di header)
+func breakdown(db *gorm.DB, filter string, args ...interface{}) ([]MethodTotal, error) {
+	query := fmt.Sprintf(`
+		SELECT method, SUM(amount) AS amount, COUNT(DISTINCT sale_key) AS transactions
+		FROM (
+			SELECT p.method, p.amount - p.change_given AS amount, 'regular-' || s.id AS sale_key
+			FROM sale_payments p
+			JOIN sales_regulars s ON s.id = p.sale_id AND p.sale_type = 'regular'
+			WHERE p.deleted_at IS NULL AND s.deleted_at IS NULL AND s.status <> 'Batal' AND %[1]s
+			UNION ALL
+			SELECT p.method, p.amount - p.change_given, 'prescription-' || s.id
+			FROM sale_payments p
+			JOIN prescription_sales s ON s.id = p.sale_id AND p.sale_type = 'prescription'
+			WHERE p.deleted_at IS NULL AND s.deleted_at IS NULL AND s.status <> 'Batal' AND %[1]s
+			UNION ALL
+			SELECT s.payment_method, s.total_pay, 'regular-' || s.id
+			FROM sales_regulars s
+			WHERE s.deleted_at IS NULL AND s.status <> 'Batal' AND %[1]s AND NOT EXISTS (
+				SELECT 1 FROM sale_payments p
+				WHERE p.sale_type = 'regular' AND p.sale_id = s.id AND p.deleted_at IS NULL)
+			UNION ALL
+			SELECT s.payment_method, s.total_amount, 'prescription-' || s.id
+			FROM prescription_sales s
+			WHERE s.deleted_at IS NULL AND s.status <> 'Batal' AND %[1]s AND NOT EXISTS (
+				SELECT 1 FROM sale_payments p
+				WHERE p.sale_type = 'prescription' AND p.sale_id = s.id AND p.deleted_at IS NULL)
+		) t
+		GROUP BY method
+		ORDER BY amount DESC
+	`, filter)
+
+	var params []interface{}
+	for i := 0; i < 4; i++ {
+		params = append(params, args...)
+	}
+
+	var totals []MethodTotal
+	if err := db.Raw(query, params...).Scan(&totals).Error; err != nil {
+		return nil, err
+	}
+	return totals, nil
+}
+
+func normalizeMethod(method string) (string, bool) {
+	for _, m := range methods {
+		if strings.EqualFold(m, strings.TrimSpace(method)) {
+			return m, true
+		}
+	}
+	return "", false
+}
+
+func summarize(lines []Payment) string {
+	method := lines[0].Method
+	for _, l := range lines[1:] {
+		if l.Method != method {
+			return Mixed
+		}
+	}
+	return method
+}
+
+func roundCent(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,122 @@
+package payment
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name     string
+		reqs     []PaymentRequest
+		fallback string
+		total    float64
+		method   string
+		change   []float64
+		err      error
+	}{
+		{
+			name:     "tanpa baris memakai metode lama",
+			fallback: "tunai",
+			total:    15000,
+			method:   Cash,
+			change:   []float64{0},
+		},
+		{
+			name:  "tanpa baris dan metode",
+			total: 15000,
+			err:   ErrNoPayment,
+		},
+		{
+			name:   "tunai dengan kembalian",
+			reqs:   []PaymentRequest{{Method: Cash, Amount: 20000}},
+			total:  15000,
+			method: Cash,
+			change: []float64{5000},
+		},
+		{
+			name:   "campuran, kembalian pada baris tunai",
+			reqs:   []PaymentRequest{{Method: Cash, Amount: 10000}, {Method: QRIS, Amount: 8000}},
+			total:  15000,
+			method: Mixed,
+			change: []float64{3000, 0},
+		},
+		{
+			name:   "kembalian pada baris tunai terakhir",
+			reqs:   []PaymentRequest{{Method: Cash, Amount: 5000}, {Method: Debit, Amount: 5000}, {Method: Cash, Amount: 10000}},
+			total:  15000,
+			method: Mixed,
+			change: []float64{0, 0, 5000},
+		},
+		{
+			name:   "pembulatan sen tidak dianggap kurang bayar",
+			reqs:   []PaymentRequest{{Method: Transfer, Amount: 10000.004}},
+			total:  10000,
+			method: Transfer,
+			change: []float64{0},
+		},
+		{
+			name:  "kurang bayar",
+			reqs:  []PaymentRequest{{Method: Cash, Amount: 10000}},
+			total: 15000,
+			err:   ErrUnderpaid,
+		},
+		{
+			name:  "non-tunai melebihi total",
+			reqs:  []PaymentRequest{{Method: Cash, Amount: 5000}, {Method: QRIS, Amount: 20000}},
+			total: 15000,
+			err:   ErrNonCashExceeds,
+		},
+		{
+			name:  "lebih bayar tanpa tunai",
+			reqs:  []PaymentRequest{{Method: Debit, Amount: 10000}, {Method: QRIS, Amount: 10000}},
+			total: 15000,
+			err:   ErrNonCashExceeds,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, method, err := Build(tt.reqs, tt.fallback, tt.total)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("err = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if method != tt.method {
+				t.Errorf("method = %q, want %q", method, tt.method)
+			}
+			if len(lines) != len(tt.change) {
+				t.Fatalf("got %d lines, want %d", len(lines), len(tt.change))
+			}
+			for i, want := range tt.change {
+				if lines[i].ChangeGiven != want {
+					t.Errorf("line %d change = %v, want %v", i, lines[i].ChangeGiven, want)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildRejectsInvalidLines(t *testing.T) {
+	tests := []struct {
+		name string
+		reqs []PaymentRequest
+	}{
+		{"metode tidak dikenal", []PaymentRequest{{Method: "Cek", Amount: 10000}}},
+		{"nominal nol", []PaymentRequest{{Method: Cash, Amount: 0}}},
+		{"nominal negatif", []PaymentRequest{{Method: Cash, Amount: -5000}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := Build(tt.reqs, "", 10000); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
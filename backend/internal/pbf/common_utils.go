@@ -1,16 +1,14 @@
 package pbf
 
 import (
-	"fmt"
+	"go-gin-auth/internal/tax"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // Utility functions
 
-func generateTransactionCode() string {
-	return fmt.Sprintf("TRX%d", time.Now().Unix())
-}
-
 func parseDate(dateStr string) (time.Time, error) {
 	return time.Parse("2006-01-02", dateStr)
 }
@@ -18,3 +16,28 @@ func parseDate(dateStr string) (time.Time, error) {
 func parseDateTime(dateTimeStr string) (time.Time, error) {
 	return time.Parse("2006-01-02 15:04:05", dateTimeStr)
 }
+
+// applyTax mengisi DPP dan PPN masukan setiap detail berdasarkan tarif
+// pajak produk, lalu mengembalikan total PPN
+func applyTax(db *gorm.DB, details []IncomingPBFDetail, inclusive bool) (float64, error) {
+	productIDs := make([]uint, len(details))
+	for i, d := range details {
+		productIDs[i] = d.ProductID
+	}
+	rates, err := tax.ProductRates(db, productIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	lines := make([]tax.Line, len(details))
+	for i, d := range details {
+		lines[i] = tax.Line{Amount: d.TotalPrice, Percent: rates[d.ProductID]}
+	}
+	taxes, totals := tax.Allocate(lines, 0, inclusive)
+	for i := range details {
+		details[i].TaxRate = taxes[i].Percent
+		details[i].TaxBase = taxes[i].Base
+		details[i].TaxAmount = taxes[i].Tax
+	}
+	return totals.Tax, nil
+}
@@ -3,6 +3,9 @@ package pbf
 import (
 	"fmt"
 	"go-gin-auth/config"
+	"go-gin-auth/internal/narcotic"
+	"go-gin-auth/internal/numbering"
+	"go-gin-auth/internal/opname"
 	"go-gin-auth/internal/product"
 	"go-gin-auth/internal/stock"
 	"go-gin-auth/internal/unit"
@@ -138,6 +141,22 @@ func CreateIncomingPBF(c *gin.Context) {
 		details = append(details, detail)
 	}
 
+	taxAmount, err := applyTax(config.DB, details, req.PricesIncludeTax)
+	if err != nil {
+		utils.Respond(c, http.StatusInternalServerError, "Failed to calculate tax", err.Error(), nil)
+		return
+	}
+	if !req.PricesIncludeTax {
+		totalPurchase += taxAmount
+	}
+
+	transactionCode, err := numbering.Next(tx, numbering.IncomingPBF, receiptDate)
+	if err != nil {
+		tx.Rollback()
+		utils.Respond(c, http.StatusInternalServerError, "Failed to generate transaction code", err.Error(), nil)
+		return
+	}
+
 	// Set default payment status if not provided
 	paymentStatus := req.PaymentStatus
 	if paymentStatus == "" {
@@ -149,7 +168,7 @@ func CreateIncomingPBF(c *gin.Context) {
 		OrderNumber:     req.OrderNumber,
 		OrderDate:       orderDate,
 		ReceiptDate:     receiptDate,
-		TransactionCode: generateTransactionCode(),
+		TransactionCode: transactionCode,
 		SupplierID:      req.SupplierID,
 		InvoiceNumber:   req.InvoiceNumber,
 		TransactionType: req.TransactionType,
@@ -157,12 +176,14 @@ func CreateIncomingPBF(c *gin.Context) {
 		UserID:          req.UserID,
 		AdditionalNotes: req.AdditionalNotes,
 		TotalPurchase:   totalPurchase,
+		TaxAmount:       taxAmount,
 		PaymentStatus:   paymentStatus,
 		Details:         details,
 	}
 
 	// Save to database
-	if err := config.DB.Create(&incomingPBF).Error; err != nil {
+	if err := tx.Create(&incomingPBF).Error; err != nil {
+		tx.Rollback()
 		utils.Respond(c, http.StatusInternalServerError, "Failed to create incoming PBF record", err.Error(), nil)
 		return
 	}
@@ -304,6 +325,15 @@ func UpdateIncomingPBF(c *gin.Context) {
 		details = append(details, detail)
 	}
 
+	taxAmount, err := applyTax(config.DB, details, req.PricesIncludeTax)
+	if err != nil {
+		utils.Respond(c, http.StatusInternalServerError, "Failed to calculate tax", err.Error(), nil)
+		return
+	}
+	if !req.PricesIncludeTax {
+		totalPurchase += taxAmount
+	}
+
 	// Update main record
 	updates := map[string]interface{}{
 		"order_number":     req.OrderNumber,
@@ -316,6 +346,7 @@ func UpdateIncomingPBF(c *gin.Context) {
 		"user_id":          req.UserID,
 		"additional_notes": req.AdditionalNotes,
 		"total_purchase":   totalPurchase,
+		"tax_amount":       taxAmount,
 		"payment_status":   req.PaymentStatus,
 	}
 
@@ -414,6 +445,10 @@ func DeleteIncomingPBF(c *gin.Context) {
 
 // **FUNGSI UTAMA UNTUK UPDATE STOCK**
 func updateStock(tx *gorm.DB, detail IncomingPBFDetail, operation string) error {
+	if err := opname.EnsureNotFrozen(tx, []uint{detail.ProductID}); err != nil {
+		return err
+	}
+
 	var stockdata stock.Stock
 
 	err := tx.Where("product_id = ?", detail.ProductID).
@@ -429,7 +464,10 @@ func updateStock(tx *gorm.DB, detail IncomingPBFDetail, operation string) error
 					ExpiryDate:   detail.ExpiryDate,
 					MinimumStock: 10, // Atur sesuai kebutuhan
 				}
-				return tx.Create(&newStock).Error
+				if err := tx.Create(&newStock).Error; err != nil {
+					return err
+				}
+				return recordNarcotic(tx, detail, operation)
 			}
 			// Jika operation SUBTRACT tapi stock tidak ada, return error
 			return fmt.Errorf("stock not found for product %s batch %s", detail.ProductCode, detail.BatchNumber)
@@ -449,8 +487,38 @@ func updateStock(tx *gorm.DB, detail IncomingPBFDetail, operation string) error
 		}
 	}
 
-	return tx.Model(&stockdata).Updates(map[string]interface{}{
+	if err := tx.Model(&stockdata).Updates(map[string]interface{}{
 		"Quantity":   newQuantity,
 		"ExpiryDate": detail.ExpiryDate,
-	}).Error
+	}).Error; err != nil {
+		return err
+	}
+	return recordNarcotic(tx, detail, operation)
+}
+
+// recordNarcotic meneruskan penerimaan narkotika/psikotropika ke register.
+// Pengurangan stok karena faktur diubah atau dihapus dicatat sebagai
+// pembatalan penerimaan.
+func recordNarcotic(tx *gorm.DB, detail IncomingPBFDetail, operation string) error {
+	var header IncomingPBF
+	if err := tx.Preload("Supplier").First(&header, detail.IncomingPBFID).Error; err != nil {
+		return err
+	}
+
+	movement := narcotic.Movement{
+		Type:    narcotic.TypeReceipt,
+		RefType: "incoming_pbf",
+		RefID:   header.ID,
+		RefCode: header.InvoiceNumber,
+		Date:    header.ReceiptDate,
+		Party:   header.Supplier.Name,
+		UserID:  header.UserID,
+		Lines:   []narcotic.Line{{ProductID: detail.ProductID, QtyIn: detail.Quantity}},
+	}
+	if operation == "SUBTRACT" {
+		movement.Type = narcotic.TypeCancellation
+		movement.Date = time.Now()
+		movement.Lines = []narcotic.Line{{ProductID: detail.ProductID, QtyOut: detail.Quantity}}
+	}
+	return narcotic.Record(tx, movement)
 }
@@ -9,24 +9,26 @@ import (
 )
 
 type IncomingPBF struct {
-	ID              uint                `json:"id" gorm:"primaryKey"`
-	OrderNumber     string              `json:"order_number" gorm:"not null"`
-	OrderDate       time.Time           `json:"order_date" gorm:"not null"`
-	ReceiptDate     time.Time           `json:"receipt_date" gorm:"not null"`
-	TransactionCode string              `json:"transaction_code" gorm:"unique;not null"`
-	SupplierID      uint                `json:"supplier_id" gorm:"not null"`
-	InvoiceNumber   string              `json:"invoice_number" gorm:"not null"`
-	TransactionType string              `json:"transaction_type" gorm:"type:varchar(20);default:'Cash'"`
-	PaymentDueDate  *time.Time          `json:"payment_due_date"`
-	UserID          uint                `json:"user_id" gorm:"not null"`
-	AdditionalNotes string              `json:"additional_notes"`
-	TotalPurchase   float64             `json:"total_purchase" gorm:"not null"`
-	PaymentStatus   string              `json:"payment_status" gorm:"type:varchar(20);default:'Belum Lunas'"`
-	CreatedAt       time.Time           `json:"created_at"`
-	UpdatedAt       time.Time           `json:"updated_at"`
-	Details         []IncomingPBFDetail `json:"details" gorm:"foreignKey:IncomingPBFID"`
-	Supplier        supplier.Supplier   `json:"supplier" gorm:"foreignKey:SupplierID"`
-	User            model.User          `json:"user" gorm:"foreignKey:UserID"`
+	ID              uint       `json:"id" gorm:"primaryKey"`
+	OrderNumber     string     `json:"order_number" gorm:"not null"`
+	OrderDate       time.Time  `json:"order_date" gorm:"not null"`
+	ReceiptDate     time.Time  `json:"receipt_date" gorm:"not null"`
+	TransactionCode string     `json:"transaction_code" gorm:"unique;not null"`
+	SupplierID      uint       `json:"supplier_id" gorm:"not null"`
+	InvoiceNumber   string     `json:"invoice_number" gorm:"not null"`
+	TransactionType string     `json:"transaction_type" gorm:"type:varchar(20);default:'Cash'"`
+	PaymentDueDate  *time.Time `json:"payment_due_date"`
+	UserID          uint       `json:"user_id" gorm:"not null"`
+	AdditionalNotes string     `json:"additional_notes"`
+	TotalPurchase   float64    `json:"total_purchase" gorm:"not null"`
+	// TaxAmount adalah PPN masukan seluruh detail faktur
+	TaxAmount     float64             `json:"tax_amount" gorm:"type:decimal(15,2);not null;default:0"`
+	PaymentStatus string              `json:"payment_status" gorm:"type:varchar(20);default:'Belum Lunas'"`
+	CreatedAt     time.Time           `json:"created_at"`
+	UpdatedAt     time.Time           `json:"updated_at"`
+	Details       []IncomingPBFDetail `json:"details" gorm:"foreignKey:IncomingPBFID"`
+	Supplier      supplier.Supplier   `json:"supplier" gorm:"foreignKey:SupplierID"`
+	User          model.User          `json:"user" gorm:"foreignKey:UserID"`
 }
 
 type IncomingPBFDetail struct {
@@ -39,6 +41,9 @@ type IncomingPBFDetail struct {
 	Quantity      int             `json:"quantity" gorm:"not null"`
 	PurchasePrice float64         `json:"purchase_price" gorm:"not null"`
 	TotalPrice    float64         `json:"total_price" gorm:"not null"`
+	TaxRate       float64         `json:"tax_rate" gorm:"type:decimal(5,2);not null;default:0"`
+	TaxBase       float64         `json:"tax_base" gorm:"type:decimal(15,2);not null;default:0"`
+	TaxAmount     float64         `json:"tax_amount" gorm:"type:decimal(15,2);not null;default:0"`
 	BatchNumber   string          `json:"batch_number"`
 	ExpiryDate    *time.Time      `json:"expiry_date"`
 	CreatedAt     time.Time       `json:"created_at"`
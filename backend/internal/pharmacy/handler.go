@@ -0,0 +1,47 @@
+package pharmacy
+
+import (
+	"errors"
+	"go-gin-auth/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type Handler struct {
+	db *gorm.DB
+}
+
+func NewHandler(db *gorm.DB) *Handler {
+	return &Handler{db: db}
+}
+
+func (h *Handler) GetProfile(c *gin.Context) {
+	profile, err := Get(h.db)
+	if err != nil {
+		utils.Respond(c, http.StatusInternalServerError, "Gagal mengambil profil apotek", err.Error(), nil)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Profil apotek berhasil diambil", nil, profile)
+}
+
+func (h *Handler) UpdateProfile(c *gin.Context) {
+	var input Profile
+	if err := c.ShouldBind(&input); err != nil {
+		utils.Respond(c, http.StatusBadRequest, "Input tidak valid", err.Error(), nil)
+		return
+	}
+	input.UpdatedBy = utils.GetCurrentUserID(c)
+
+	profile, err := Save(h.db, input)
+	if err != nil {
+		if errors.Is(err, ErrNameRequired) {
+			utils.Respond(c, http.StatusBadRequest, err.Error(), err.Error(), nil)
+		} else {
+			utils.Respond(c, http.StatusInternalServerError, "Gagal menyimpan profil apotek", err.Error(), nil)
+		}
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Profil apotek berhasil disimpan", nil, profile)
+}
@@ -0,0 +1,36 @@
+package pharmacy
+
+import "time"
+
+// Profile menyimpan identitas apotek yang dicetak pada struk, faktur,
+// salinan resep dan etiket. Hanya ada satu baris profil.
+type Profile struct {
+	ID             uint   `gorm:"primaryKey" json:"id"`
+	Name           string `gorm:"type:varchar(255);not null" json:"name" form:"name"`
+	Address        string `gorm:"type:text" json:"address" form:"address"`
+	Phone          string `gorm:"type:varchar(50)" json:"phone" form:"phone"`
+	SIANumber      string `gorm:"type:varchar(100);comment:Nomor Surat Izin Apotek" json:"sia_number" form:"sia_number"`
+	PharmacistName string `gorm:"type:varchar(255);comment:Apoteker Penanggung Jawab" json:"pharmacist_name" form:"pharmacist_name"`
+	SIPANumber     string `gorm:"type:varchar(100);comment:Nomor SIPA Apoteker" json:"sipa_number" form:"sipa_number"`
+	ReceiptFooter  string `gorm:"type:text" json:"receipt_footer" form:"receipt_footer"`
+	// PricesIncludeTax menandai harga jual produk sudah termasuk PPN
+	PricesIncludeTax bool `gorm:"not null;default:true" json:"prices_include_tax" form:"prices_include_tax"`
+	// PointEarnAmount adalah belanja (rupiah) untuk setiap 1 poin; 0 berarti
+	// pelanggan tidak mendapat poin
+	PointEarnAmount int `gorm:"not null;default:0" json:"point_earn_amount" form:"point_earn_amount"`
+	// PointValue adalah potongan (rupiah) untuk setiap poin yang ditukar
+	PointValue int `gorm:"not null;default:0" json:"point_value" form:"point_value"`
+	// PointExpiryMonths adalah masa berlaku poin sejak diperoleh; 0 berarti
+	// poin tidak kedaluwarsa
+	PointExpiryMonths int `gorm:"not null;default:0" json:"point_expiry_months" form:"point_expiry_months"`
+	// CompoundFee adalah biaya tuslah (rupiah) untuk setiap racikan dan
+	// CompoundUnitFee biaya embalase untuk setiap kapsul/bungkus racikan
+	CompoundFee     int       `gorm:"not null;default:0" json:"compound_fee" form:"compound_fee"`
+	CompoundUnitFee int       `gorm:"not null;default:0" json:"compound_unit_fee" form:"compound_unit_fee"`
+	UpdatedBy       uint      `json:"updated_by"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+func (Profile) TableName() string {
+	return "pharmacy_profiles"
+}
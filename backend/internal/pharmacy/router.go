@@ -0,0 +1,18 @@
+package pharmacy
+
+import (
+	"go-gin-auth/config"
+	"go-gin-auth/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func PharmacyRouter(api *gin.RouterGroup) {
+	handler := NewHandler(config.DB)
+
+	profile := api.Group("/pharmacy-profile")
+	{
+		profile.GET("", middleware.AuthMiddleware(), handler.GetProfile)
+		profile.PUT("", middleware.AuthAdminMiddleware(), handler.UpdateProfile)
+	}
+}
@@ -0,0 +1,51 @@
+package pharmacy
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+var ErrNameRequired = errors.New("nama apotek wajib diisi")
+
+// defaultProfile dipakai selama profil apotek belum pernah disimpan
+var defaultProfile = Profile{
+	Name:             "Apotek",
+	ReceiptFooter:    "Terima kasih, semoga lekas sembuh",
+	PricesIncludeTax: true,
+}
+
+// Get mengambil profil apotek, atau profil bawaan bila belum diatur
+func Get(db *gorm.DB) (Profile, error) {
+	var profile Profile
+	err := db.Order("id").First(&profile).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return defaultProfile, nil
+	}
+	return profile, err
+}
+
+// Save menyimpan profil apotek sebagai satu-satunya baris profil
+func Save(db *gorm.DB, input Profile) (Profile, error) {
+	if input.Name == "" {
+		return input, ErrNameRequired
+	}
+
+	var existing Profile
+	err := db.Order("id").First(&existing).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return input, err
+	}
+	input.ID = existing.ID
+
+	if err := db.Save(&input).Error; err != nil {
+		return input, err
+	}
+	// kolom ber-default diabaikan GORM saat insert bila bernilai false
+	if existing.ID == 0 && !input.PricesIncludeTax {
+		if err := db.Model(&input).Update("prices_include_tax", false).Error; err != nil {
+			return input, err
+		}
+	}
+	return input, nil
+}
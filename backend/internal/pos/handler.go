@@ -0,0 +1,40 @@
+package pos
+
+import (
+	"errors"
+	"go-gin-auth/utils"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// Lookup menerima hasil scan barcode, kode produk, atau awalan nama
+func (h *Handler) Lookup(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	items, err := h.service.Lookup(query, limit)
+	if err != nil {
+		if errors.Is(err, ErrEmptyQuery) {
+			utils.Respond(c, http.StatusBadRequest, err.Error(), err.Error(), nil)
+			return
+		}
+		utils.Respond(c, http.StatusInternalServerError, "Gagal mencari produk", err.Error(), nil)
+		return
+	}
+	if len(items) == 0 {
+		utils.Respond(c, http.StatusNotFound, "Produk tidak ditemukan", nil, items)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Produk ditemukan", nil, items)
+}
@@ -0,0 +1,18 @@
+package pos
+
+import "time"
+
+// LookupItem adalah data satu produk yang dibutuhkan layar kasir saat scan
+type LookupItem struct {
+	ProductID            uint       `json:"product_id"`
+	Code                 string     `json:"code"`
+	Barcode              string     `json:"barcode"`
+	Name                 string     `json:"name"`
+	UnitID               uint       `json:"unit_id"`
+	UnitName             string     `json:"unit_name"`
+	SellingPrice         float64    `json:"selling_price"`
+	OnHand               int        `json:"on_hand"`
+	NearestExpiry        *time.Time `json:"nearest_expiry"`
+	DrugCategory         string     `json:"drug_category"`
+	RequiresPrescription bool       `json:"requires_prescription"`
+}
@@ -0,0 +1,36 @@
+package pos
+
+import (
+	"go-gin-auth/config"
+	"go-gin-auth/internal/sales"
+	"go-gin-auth/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func PosRouter(api *gin.RouterGroup) {
+	service := NewService(config.DB)
+	handler := NewHandler(service)
+
+	posGroup := api.Group("/pos")
+	posGroup.Use(middleware.AuthMiddleware())
+	{
+		posGroup.GET("/lookup", handler.Lookup)
+	}
+}
+
+// SyncRouter mendaftarkan protokol sinkronisasi POS offline. Penjualan
+// dibuat melalui service penjualan reguler agar harga, pajak, poin dan
+// stok dihitung sama seperti penjualan online.
+func SyncRouter(api *gin.RouterGroup, salesService sales.SalesRegularService) {
+	service := NewSyncService(config.DB, salesService)
+	handler := NewSyncHandler(service)
+
+	syncGroup := api.Group("/pos/sync")
+	{
+		syncGroup.GET("/catalog", handler.Catalog)
+		syncGroup.GET("/sales", handler.Status)
+		syncGroup.POST("/sales", handler.Upload)
+		syncGroup.POST("/sales/:id/review", handler.Review)
+	}
+}
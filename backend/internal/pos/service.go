@@ -0,0 +1,107 @@
+package pos
+
+import (
+	"errors"
+	"go-gin-auth/internal/drug_category"
+	"go-gin-auth/internal/product"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrEmptyQuery = errors.New("parameter q wajib diisi")
+
+const (
+	defaultLookupLimit = 10
+	maxLookupLimit     = 50
+)
+
+type Service struct {
+	db *gorm.DB
+}
+
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db}
+}
+
+// Lookup mencari produk dari indeks memori lalu melengkapinya dengan stok,
+// satuan dan golongan obat dalam satu query
+func (s *Service) Lookup(query string, limit int) ([]LookupItem, error) {
+	if query == "" {
+		return nil, ErrEmptyQuery
+	}
+	if limit <= 0 {
+		limit = defaultLookupLimit
+	}
+	if limit > maxLookupLimit {
+		limit = maxLookupLimit
+	}
+
+	products, err := product.ProductIndex().Lookup(s.db, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(products) == 0 {
+		return []LookupItem{}, nil
+	}
+
+	ids := make([]uint, len(products))
+	for i, p := range products {
+		ids[i] = p.ID
+	}
+
+	type row struct {
+		ProductID     uint
+		UnitName      string
+		DrugCategory  string
+		OnHand        int
+		NearestExpiry *time.Time
+	}
+	var rows []row
+	err = s.db.Raw(`
+		SELECT p.id AS product_id,
+			COALESCE(u.name, '') AS unit_name,
+			COALESCE(dc.name, '') AS drug_category,
+			COALESCE(st.on_hand, 0) AS on_hand,
+			st.nearest_expiry
+		FROM products p
+		LEFT JOIN units u ON u.id = p.unit_id
+		LEFT JOIN drug_categories dc ON dc.id = p.drug_category_id
+		LEFT JOIN (
+			SELECT product_id,
+				SUM(quantity) AS on_hand,
+				MIN(expiry_date) FILTER (WHERE quantity > 0) AS nearest_expiry
+			FROM stocks
+			WHERE product_id IN ?
+			GROUP BY product_id
+		) st ON st.product_id = p.id
+		WHERE p.id IN ?
+	`, ids, ids).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	extra := make(map[uint]row, len(rows))
+	for _, r := range rows {
+		extra[r.ProductID] = r
+	}
+
+	items := make([]LookupItem, 0, len(products))
+	for _, p := range products {
+		r := extra[p.ID]
+		items = append(items, LookupItem{
+			ProductID:            p.ID,
+			Code:                 p.Code,
+			Barcode:              p.Barcode,
+			Name:                 p.Name,
+			UnitID:               p.UnitID,
+			UnitName:             r.UnitName,
+			SellingPrice:         p.SellingPrice,
+			OnHand:               r.OnHand,
+			NearestExpiry:        r.NearestExpiry,
+			DrugCategory:         r.DrugCategory,
+			RequiresPrescription: drug_category.DrugCategory{Name: r.DrugCategory}.RequiresPrescription(),
+		})
+	}
+	return items, nil
+}
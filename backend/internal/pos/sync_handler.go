@@ -0,0 +1,110 @@
+package pos
+
+import (
+	"errors"
+	"go-gin-auth/service"
+	"go-gin-auth/utils"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SyncHandler struct {
+	service *SyncService
+}
+
+func NewSyncHandler(service *SyncService) *SyncHandler {
+	return &SyncHandler{service: service}
+}
+
+// Catalog mengirim perubahan produk dan harga sejak versi ?since= milik klien
+func (h *SyncHandler) Catalog(c *gin.Context) {
+	var since int64
+	if raw := c.Query("since"); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || parsed < 0 {
+			utils.Respond(c, http.StatusBadRequest, "Parameter since tidak valid", "since harus berupa versi katalog", nil)
+			return
+		}
+		since = parsed
+	}
+
+	catalog, err := h.service.Catalog(since)
+	if err != nil {
+		utils.Respond(c, http.StatusInternalServerError, "Gagal mengambil katalog", err.Error(), nil)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Katalog berhasil diambil", nil, catalog)
+}
+
+// Upload menerima batch penjualan offline. Hasil setiap penjualan ada pada
+// status masing-masing sehingga respons tetap 200 walau ada yang ditolak.
+func (h *SyncHandler) Upload(c *gin.Context) {
+	var req UploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Respond(c, http.StatusBadRequest, "Payload tidak valid", err.Error(), nil)
+		return
+	}
+
+	results, err := h.service.Upload(req, utils.GetCurrentUserID(c))
+	if err != nil {
+		utils.Respond(c, http.StatusInternalServerError, "Gagal memproses penjualan offline", err.Error(), nil)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Penjualan offline berhasil diproses", nil, results)
+}
+
+// Status menampilkan hasil unggah penjualan offline per perangkat atau per
+// daftar client_ids yang dipisahkan koma
+func (h *SyncHandler) Status(c *gin.Context) {
+	var clientIDs []string
+	for _, id := range strings.Split(c.Query("client_ids"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			clientIDs = append(clientIDs, id)
+		}
+	}
+
+	records, err := h.service.Status(strings.TrimSpace(c.Query("device_id")), clientIDs, c.Query("status"))
+	if err != nil {
+		if errors.Is(err, ErrEmptyDevice) {
+			utils.Respond(c, http.StatusBadRequest, err.Error(), err.Error(), nil)
+			return
+		}
+		utils.Respond(c, http.StatusInternalServerError, "Gagal mengambil status sinkronisasi", err.Error(), nil)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Status sinkronisasi berhasil diambil", nil, records)
+}
+
+// Review membukukan penjualan offline Perlu Ditinjau ke shift yang dipilih
+// supervisor atau menolaknya
+func (h *SyncHandler) Review(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.Respond(c, http.StatusBadRequest, "ID tidak valid", err.Error(), nil)
+		return
+	}
+	var req ReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Respond(c, http.StatusBadRequest, "Payload tidak valid", err.Error(), nil)
+		return
+	}
+
+	record, err := h.service.Review(uint(id), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrOfflineNotFound):
+			utils.Respond(c, http.StatusNotFound, err.Error(), err.Error(), nil)
+		case errors.Is(err, service.ErrInvalidApprover), errors.Is(err, service.ErrApproverLocked), errors.Is(err, ErrSelfReview):
+			utils.Respond(c, http.StatusForbidden, err.Error(), err.Error(), nil)
+		case errors.Is(err, ErrNotInReview), errors.Is(err, ErrNoPayload):
+			utils.Respond(c, http.StatusConflict, err.Error(), err.Error(), nil)
+		default:
+			utils.Respond(c, http.StatusInternalServerError, "Gagal meninjau penjualan offline", err.Error(), nil)
+		}
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Penjualan offline berhasil ditinjau", nil, record)
+}
@@ -0,0 +1,155 @@
+package pos
+
+import (
+	"go-gin-auth/internal/payment"
+	"time"
+)
+
+// Status penjualan offline setelah diunggah
+const (
+	SyncProcessing = "Diproses"
+	SyncAccepted   = "Diterima"
+	SyncAdjusted   = "Disesuaikan"
+	SyncRejected   = "Ditolak"
+	// SyncReview menandai penjualan yang shift-nya saat dibuat offline sudah
+	// ditutup atau tidak ditemukan; penjualan tidak dibuat dan unggahan
+	// ulang tidak diproses sampai ditinjau supervisor
+	SyncReview = "Perlu Ditinjau"
+)
+
+// staleProcessing adalah batas umur record Diproses tanpa penjualan yang
+// dianggap tertinggal karena proses unggah terhenti dan boleh diambil alih
+const staleProcessing = 5 * time.Minute
+
+// Penanganan stok yang tidak mencukupi saat penjualan offline diunggah
+const (
+	// ShortageReject menolak penjualan agar diselesaikan supervisor
+	ShortageReject = "reject"
+	// ShortageAccept tetap mencatat penjualan meski stok menjadi minus;
+	// penjualan ditandai Disesuaikan dan selisihnya menunggu stok opname
+	ShortageAccept = "accept"
+)
+
+// OfflineSale mencatat hasil unggah satu penjualan yang dibuat kasir saat
+// offline. ClientID dibuat oleh klien POS sehingga unggahan ulang tidak
+// membuat penjualan ganda.
+type OfflineSale struct {
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	ClientID        string     `gorm:"type:varchar(36);not null;uniqueIndex" json:"client_id"`
+	DeviceID        string     `gorm:"type:varchar(100);not null;index" json:"device_id"`
+	CashierID       uint       `gorm:"not null" json:"cashier_id"`
+	ClientCreatedAt time.Time  `gorm:"not null" json:"client_created_at"`
+	Status          string     `gorm:"type:varchar(20);not null;index" json:"status"`
+	SaleID          *uint      `json:"sale_id,omitempty"`
+	SaleCode        string     `gorm:"type:varchar(50)" json:"sale_code,omitempty"`
+	ClientTotal     int        `gorm:"not null;default:0" json:"client_total"`
+	ServerTotal     int        `gorm:"not null;default:0" json:"server_total"`
+	Message         string     `gorm:"type:text" json:"message,omitempty"`
+	Conflicts       []Conflict `gorm:"serializer:json;type:text" json:"conflicts,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+
+	// Payload adalah penjualan offline yang diunggah, disimpan agar
+	// penjualan Perlu Ditinjau dapat dibukukan supervisor tanpa unggah ulang
+	Payload    *OfflineSaleRequest `gorm:"serializer:json;type:text" json:"-"`
+	ReviewedBy *uint               `json:"reviewed_by,omitempty"`
+	ReviewedAt *time.Time          `json:"reviewed_at,omitempty"`
+	ReviewNote string              `gorm:"type:text" json:"review_note,omitempty"`
+}
+
+func (OfflineSale) TableName() string {
+	return "pos_offline_sales"
+}
+
+// Keputusan supervisor atas penjualan offline Perlu Ditinjau
+const (
+	ReviewBook   = "book"
+	ReviewReject = "reject"
+)
+
+// ReviewRequest memuat keputusan dan login ulang supervisor. ShiftID wajib
+// untuk keputusan book dan harus shift yang masih Buka.
+type ReviewRequest struct {
+	Action   string `json:"action" binding:"required,oneof=book reject"`
+	ShiftID  uint   `json:"shift_id" binding:"required_if=Action book"`
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	Note     string `json:"note"`
+}
+
+// Conflict adalah produk yang stoknya tidak mencukupi saat diunggah
+type Conflict struct {
+	ProductID uint   `json:"product_id"`
+	Name      string `json:"name"`
+	Requested int    `json:"requested"`
+	Available int    `json:"available"`
+}
+
+type UploadRequest struct {
+	DeviceID   string               `json:"device_id" binding:"required,max=100"`
+	OnShortage string               `json:"on_shortage" binding:"omitempty,oneof=reject accept"`
+	Sales      []OfflineSaleRequest `json:"sales" binding:"required,min=1,max=200,dive"`
+}
+
+type OfflineSaleRequest struct {
+	ClientID      string                   `json:"client_id" binding:"required,uuid"`
+	CreatedAt     time.Time                `json:"created_at" binding:"required"`
+	CustomerID    *uint                    `json:"customer_id"`
+	Description   *string                  `json:"description"`
+	TotalDiscount *int                     `json:"total_discount"`
+	TotalPay      int                      `json:"total_pay"`
+	PaymentMethod string                   `json:"payment_method"`
+	Payments      []payment.PaymentRequest `json:"payments"`
+	Items         []OfflineItemRequest     `json:"items" binding:"required,min=1,dive"`
+	// PharmacistOverrideReason diteruskan ke penjualan bebas untuk obat
+	// keras yang diserahkan apoteker tanpa resep
+	PharmacistOverrideReason string `json:"pharmacist_override_reason"`
+	// PharmacistEmail dan PharmacistPassword adalah login apoteker yang
+	// menyetujui; password tidak ikut disimpan pada Payload
+	PharmacistEmail    string `json:"pharmacist_email"`
+	PharmacistPassword string `json:"pharmacist_password"`
+}
+
+// stored adalah salinan penjualan untuk disimpan pada Payload tanpa
+// password apoteker
+func (r OfflineSaleRequest) stored() *OfflineSaleRequest {
+	r.PharmacistPassword = ""
+	return &r
+}
+
+type OfflineItemRequest struct {
+	ProductID uint `json:"product_id" binding:"required"`
+	Qty       int  `json:"qty" binding:"required,min=1"`
+	UnitPrice int  `json:"unit_price" binding:"min=0"`
+}
+
+// CatalogItem adalah data produk yang disimpan klien POS untuk berjualan
+// offline. Deleted menandai produk yang harus dihapus dari katalog lokal.
+type CatalogItem struct {
+	ProductID            uint    `json:"product_id"`
+	Code                 string  `json:"code"`
+	Barcode              string  `json:"barcode"`
+	Name                 string  `json:"name"`
+	UnitID               uint    `json:"unit_id"`
+	UnitName             string  `json:"unit_name"`
+	SellingPrice         float64 `json:"selling_price"`
+	TaxPercent           float64 `json:"tax_percent"`
+	OnHand               int     `json:"on_hand"`
+	DrugCategory         string  `json:"drug_category"`
+	RequiresPrescription bool    `json:"requires_prescription"`
+	Deleted              bool    `json:"deleted"`
+
+	// Classification dan OWALimit dipakai klien untuk menahan obat keras
+	// dan jumlah OWA sebelum penjualan offline diunggah
+	Classification string `json:"classification"`
+	OWALimit       int    `json:"owa_limit,omitempty"`
+}
+
+// Catalog adalah perubahan katalog sejak versi yang dimiliki klien. Version
+// dikirim kembali oleh klien pada penarikan berikutnya.
+type Catalog struct {
+	Version          int64         `json:"version"`
+	Full             bool          `json:"full"`
+	PricesIncludeTax bool          `json:"prices_include_tax"`
+	Items            []CatalogItem `json:"items"`
+}
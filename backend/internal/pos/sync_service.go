@@ -0,0 +1,570 @@
+package pos
+
+import (
+	"errors"
+	"fmt"
+	"go-gin-auth/internal/drug_category"
+	"go-gin-auth/internal/payment"
+	"go-gin-auth/internal/product"
+	"go-gin-auth/internal/sales"
+	"go-gin-auth/internal/shift"
+	"go-gin-auth/internal/tax"
+	"go-gin-auth/model"
+	"go-gin-auth/service"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrEmptyDevice       = errors.New("parameter device_id wajib diisi")
+	ErrSaleInProgress    = errors.New("penjualan offline ini sedang diproses oleh unggahan lain")
+	ErrInsufficientStock = errors.New("stok tidak mencukupi untuk penjualan offline")
+	ErrProductNotFound   = errors.New("produk tidak ditemukan")
+	ErrNoShiftAtTime     = errors.New("tidak ada shift kasir yang terbuka pada waktu penjualan offline dibuat")
+	ErrShiftClosed       = errors.New("shift kasir pada waktu penjualan offline dibuat sudah ditutup")
+	ErrOfflineNotFound   = errors.New("penjualan offline tidak ditemukan")
+	ErrNotInReview       = errors.New("penjualan offline tidak dalam status Perlu Ditinjau")
+	ErrSelfReview        = errors.New("penjualan offline harus ditinjau oleh supervisor lain")
+	ErrNoPayload         = errors.New("data penjualan offline tidak tersimpan, minta kasir mengunggah ulang")
+)
+
+// SyncService menerima penjualan yang dibuat kasir saat offline dan
+// menyediakan katalog produk untuk disimpan di klien POS
+type SyncService struct {
+	db    *gorm.DB
+	sales sales.SalesRegularService
+}
+
+func NewSyncService(db *gorm.DB, salesService sales.SalesRegularService) *SyncService {
+	return &SyncService{db: db, sales: salesService}
+}
+
+// Catalog mengambil produk yang berubah atau dihapus sejak versi since
+// (milidetik Unix). since 0 berarti katalog lengkap tanpa produk terhapus.
+// Versi dibandingkan dengan >= agar perubahan pada milidetik yang sama
+// tidak terlewat; klien cukup menimpa data lokal berdasarkan product_id.
+// Stok hanya gambaran saat penarikan dan tidak memengaruhi versi.
+func (s *SyncService) Catalog(since int64) (*Catalog, error) {
+	inclusive, err := tax.PricesIncludeTax(s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	query := s.db.Unscoped().Model(&product.Product{}).
+		Select("id, code, barcode, name, unit_id, selling_price, drug_category_id, updated_at, deleted_at")
+	if since > 0 {
+		at := time.UnixMilli(since)
+		query = query.Where("updated_at >= ? OR deleted_at >= ?", at, at)
+	} else {
+		query = query.Where("deleted_at IS NULL")
+	}
+
+	var products []product.Product
+	if err := query.Order("id").Find(&products).Error; err != nil {
+		return nil, err
+	}
+
+	catalog := &Catalog{Version: since, Full: since <= 0, PricesIncludeTax: inclusive, Items: []CatalogItem{}}
+	if len(products) == 0 {
+		return catalog, nil
+	}
+
+	ids := make([]uint, len(products))
+	for i, p := range products {
+		ids[i] = p.ID
+	}
+	rates, err := tax.ProductRates(s.db, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	type row struct {
+		ProductID    uint
+		UnitName     string
+		DrugCategory string
+		ControlClass string
+		OWALimit     int
+		OnHand       int
+	}
+	var rows []row
+	err = s.db.Raw(`
+		SELECT p.id AS product_id,
+			COALESCE(u.name, '') AS unit_name,
+			COALESCE(dc.name, '') AS drug_category,
+			COALESCE(dc.control_class, '') AS control_class,
+			COALESCE(dc.owa_limit, 0) AS owa_limit,
+			COALESCE((SELECT SUM(quantity) FROM stocks WHERE product_id = p.id), 0) AS on_hand
+		FROM products p
+		LEFT JOIN units u ON u.id = p.unit_id
+		LEFT JOIN drug_categories dc ON dc.id = p.drug_category_id
+		WHERE p.id IN ?
+	`, ids).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	extra := make(map[uint]row, len(rows))
+	for _, r := range rows {
+		extra[r.ProductID] = r
+	}
+
+	for _, p := range products {
+		version := p.UpdatedAt.UnixMilli()
+		if p.DeletedAt.Valid && p.DeletedAt.Time.UnixMilli() > version {
+			version = p.DeletedAt.Time.UnixMilli()
+		}
+		if version > catalog.Version {
+			catalog.Version = version
+		}
+
+		r := extra[p.ID]
+		category := drug_category.DrugCategory{Name: r.DrugCategory, ControlClass: r.ControlClass, OWALimit: r.OWALimit}
+		catalog.Items = append(catalog.Items, CatalogItem{
+			ProductID:            p.ID,
+			Code:                 p.Code,
+			Barcode:              p.Barcode,
+			Name:                 p.Name,
+			UnitID:               p.UnitID,
+			UnitName:             r.UnitName,
+			SellingPrice:         p.SellingPrice,
+			TaxPercent:           rates[p.ID],
+			OnHand:               r.OnHand,
+			DrugCategory:         r.DrugCategory,
+			RequiresPrescription: category.RequiresPrescription(),
+			Deleted:              p.DeletedAt.Valid,
+			Classification:       category.Classification(),
+			OWALimit:             r.OWALimit,
+		})
+	}
+	return catalog, nil
+}
+
+// Upload memproses penjualan offline satu per satu sesuai urutan kiriman.
+// Setiap penjualan berdiri sendiri: penolakan satu penjualan tidak
+// membatalkan penjualan lain dalam batch yang sama. Penjualan dengan
+// client_id yang sudah diterima tidak dibuat ulang dan hasil sebelumnya
+// dikembalikan, sedangkan yang pernah ditolak boleh diunggah kembali.
+// Unggahan tidak memerlukan shift aktif: penjualan yang shift-nya sudah
+// ditutup dicatat Perlu Ditinjau, bukan ditolak seluruh batch.
+func (s *SyncService) Upload(req UploadRequest, cashierID uint) ([]OfflineSale, error) {
+	var cashier model.User
+	if err := s.db.Select("full_name").First(&cashier, cashierID).Error; err != nil {
+		return nil, err
+	}
+
+	onShortage := req.OnShortage
+	if onShortage == "" {
+		onShortage = ShortageReject
+	}
+
+	results := make([]OfflineSale, 0, len(req.Sales))
+	for _, sale := range req.Sales {
+		record, err := s.claim(req.DeviceID, cashierID, sale)
+		if err != nil {
+			return nil, err
+		}
+		if record.Status != SyncProcessing {
+			results = append(results, *record)
+			continue
+		}
+
+		s.process(record, sale, cashier.FullName, cashierID, onShortage)
+		if err := s.db.Save(record).Error; err != nil {
+			return nil, err
+		}
+		results = append(results, *record)
+	}
+	return results, nil
+}
+
+// claim menandai client_id sebagai sedang diproses. Bila client_id sudah
+// tercatat, record lama dikembalikan apa adanya kecuali statusnya Ditolak
+// atau Diproses tanpa penjualan lebih lama dari staleProcessing. Penjualan
+// dan status Diterima disimpan dalam satu transaksi, sehingga record
+// Diproses tanpa sale_id pasti belum menghasilkan penjualan.
+func (s *SyncService) claim(deviceID string, cashierID uint, sale OfflineSaleRequest) (*OfflineSale, error) {
+	record := &OfflineSale{
+		ClientID:        sale.ClientID,
+		DeviceID:        deviceID,
+		CashierID:       cashierID,
+		ClientCreatedAt: sale.CreatedAt,
+		Status:          SyncProcessing,
+		ClientTotal:     sale.TotalPay,
+		Payload:         sale.stored(),
+	}
+	created := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if created.Error != nil {
+		return nil, created.Error
+	}
+	if created.RowsAffected == 1 {
+		return record, nil
+	}
+
+	var existing OfflineSale
+	if err := s.db.Where("client_id = ?", sale.ClientID).First(&existing).Error; err != nil {
+		return nil, err
+	}
+	cutoff := time.Now().Add(-staleProcessing)
+	stale := existing.Status == SyncProcessing && existing.SaleID == nil && existing.UpdatedAt.Before(cutoff)
+	if existing.Status != SyncRejected && !stale {
+		if existing.Status == SyncProcessing {
+			existing.Message = ErrSaleInProgress.Error()
+		}
+		return &existing, nil
+	}
+
+	// ambil alih penjualan yang pernah ditolak atau tertinggal hanya bila
+	// belum diambil alih unggahan lain
+	reclaimed := s.db.Model(&OfflineSale{}).
+		Where("id = ? AND sale_id IS NULL AND (status = ? OR (status = ? AND updated_at < ?))",
+			existing.ID, SyncRejected, SyncProcessing, cutoff).
+		Updates(map[string]interface{}{
+			"status":            SyncProcessing,
+			"device_id":         deviceID,
+			"cashier_id":        cashierID,
+			"client_created_at": sale.CreatedAt,
+			"client_total":      sale.TotalPay,
+		})
+	if reclaimed.Error != nil {
+		return nil, reclaimed.Error
+	}
+	if reclaimed.RowsAffected == 0 {
+		existing.Status = SyncProcessing
+		existing.Message = ErrSaleInProgress.Error()
+		return &existing, nil
+	}
+	existing.Status = SyncProcessing
+	existing.DeviceID = deviceID
+	existing.CashierID = cashierID
+	existing.ClientCreatedAt = sale.CreatedAt
+	existing.ClientTotal = sale.TotalPay
+	existing.Payload = sale.stored()
+	existing.Message = ""
+	existing.Conflicts = nil
+	return &existing, nil
+}
+
+// process membuat penjualan reguler dari penjualan offline lalu mengisi
+// status record. Penjualan dibukukan ke shift yang terbuka saat penjualan
+// dibuat offline; bila shift itu sudah ditutup penjualan ditandai Perlu
+// Ditinjau agar rekap kas kedua shift tidak berubah.
+func (s *SyncService) process(record *OfflineSale, sale OfflineSaleRequest, cashierName string, cashierID uint, onShortage string) {
+	booked, err := s.shiftAt(cashierID, sale.CreatedAt)
+	if err != nil {
+		failed(record, err)
+		return
+	}
+	s.book(record, sale, cashierName, cashierID, onShortage, booked.ID, nil)
+}
+
+// book membuat penjualan reguler pada shift shiftID. Penjualan ditandai
+// Disesuaikan bila stok menjadi minus, pembayaran harus diganti, total
+// server berbeda dari total klien, atau ada catatan tambahan pada notes.
+func (s *SyncService) book(record *OfflineSale, sale OfflineSaleRequest, cashierName string, cashierID uint, onShortage string, shiftID uint, notes []string) {
+	conflicts, err := s.shortages(sale.Items)
+	if err != nil {
+		failed(record, err)
+		return
+	}
+	record.Conflicts = conflicts
+	if len(conflicts) > 0 && onShortage == ShortageReject {
+		failed(record, ErrInsufficientStock)
+		return
+	}
+
+	req, err := s.request(sale, cashierName, cashierID)
+	if err != nil {
+		failed(record, err)
+		return
+	}
+	if len(conflicts) > 0 {
+		notes = append(notes, "stok menjadi minus, selisih diselesaikan melalui stok opname")
+	}
+
+	// status record disimpan di transaksi penjualan agar penjualan tidak
+	// pernah tercipta tanpa record Diterima
+	accept := func(tx *gorm.DB, created *sales.SalesRegular) error {
+		accepted(record, sale, created, notes)
+		return tx.Save(record).Error
+	}
+	req.OnCreated = accept
+	req.ShiftID = &shiftID
+
+	_, err = s.sales.Create(req)
+	if errors.Is(err, payment.ErrUnderpaid) || errors.Is(err, payment.ErrNonCashExceeds) {
+		// pembayaran offline tidak cocok dengan total server, misalnya karena
+		// promosi berubah; catat sebagai satu pembayaran dengan metode pertama
+		req, err = s.request(sale, cashierName, cashierID)
+		if err != nil {
+			failed(record, err)
+			return
+		}
+		req.Payments = nil
+		req.PaymentMethod = firstMethod(sale)
+		req.OnCreated = accept
+		req.ShiftID = &shiftID
+		notes = append(notes, "pembayaran disesuaikan dengan total server")
+		_, err = s.sales.Create(req)
+	}
+	if err != nil {
+		failed(record, err)
+	}
+}
+
+// failed menandai record gagal diproses. Penjualan yang shift-nya tidak
+// dapat dipakai ditandai Perlu Ditinjau, selain itu Ditolak.
+func failed(record *OfflineSale, err error) {
+	record.Status = SyncRejected
+	if errors.Is(err, ErrNoShiftAtTime) || errors.Is(err, ErrShiftClosed) || errors.Is(err, shift.ErrShiftNotOpen) {
+		record.Status = SyncReview
+	}
+	record.Message = err.Error()
+	record.SaleID = nil
+	record.SaleCode = ""
+	record.ServerTotal = 0
+}
+
+// accepted mengisi record dengan penjualan yang berhasil dibuat
+func accepted(record *OfflineSale, sale OfflineSaleRequest, created *sales.SalesRegular, notes []string) {
+	record.SaleID = &created.ID
+	record.SaleCode = created.SalesCode
+	record.ServerTotal = created.TotalPay
+	if created.TotalPay != sale.TotalPay {
+		notes = append(notes, fmt.Sprintf("total server Rp%d berbeda dari total klien Rp%d", created.TotalPay, sale.TotalPay))
+	}
+
+	record.Status = SyncAccepted
+	record.Message = ""
+	if len(notes) > 0 {
+		record.Status = SyncAdjusted
+		for i, note := range notes {
+			if i > 0 {
+				record.Message += "; "
+			}
+			record.Message += note
+		}
+	}
+}
+
+// Review menyelesaikan penjualan offline Perlu Ditinjau atas keputusan
+// supervisor yang login ulang: dibukukan ke shift Buka yang dipilih atau
+// ditolak. Penjualan yang sudah dibayar pelanggan tetap dibukukan meski
+// stok menjadi minus.
+func (s *SyncService) Review(id uint, req ReviewRequest) (*OfflineSale, error) {
+	approver, err := service.VerifyApprover(s.db, req.Email, req.Password, "admin")
+	if err != nil {
+		return nil, err
+	}
+
+	var record OfflineSale
+	if err := s.db.First(&record, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOfflineNotFound
+		}
+		return nil, err
+	}
+	if record.Status != SyncReview {
+		return nil, ErrNotInReview
+	}
+	if approver.ID == record.CashierID {
+		return nil, ErrSelfReview
+	}
+	if req.Action == ReviewBook && record.Payload == nil {
+		return nil, ErrNoPayload
+	}
+
+	// klaim record agar dua keputusan bersamaan tidak membukukan dua kali
+	claimed := s.db.Model(&OfflineSale{}).
+		Where("id = ? AND status = ?", id, SyncReview).
+		Update("status", SyncProcessing)
+	if claimed.Error != nil {
+		return nil, claimed.Error
+	}
+	if claimed.RowsAffected == 0 {
+		return nil, ErrNotInReview
+	}
+
+	now := time.Now()
+	record.Status = SyncProcessing
+	record.ReviewedBy = &approver.ID
+	record.ReviewedAt = &now
+	record.ReviewNote = req.Note
+
+	if req.Action == ReviewReject {
+		record.Status = SyncRejected
+		record.Message = "ditolak supervisor " + approver.FullName
+	} else {
+		var cashier model.User
+		if err := s.db.Select("full_name").First(&cashier, record.CashierID).Error; err != nil {
+			return nil, err
+		}
+		// password apoteker tidak disimpan, sehingga obat keras pada
+		// penjualan ini disetujui dengan login supervisor yang meninjau
+		sale := *record.Payload
+		if sale.PharmacistOverrideReason != "" {
+			sale.PharmacistEmail = req.Email
+			sale.PharmacistPassword = req.Password
+		}
+		note := fmt.Sprintf("dibukukan supervisor %s ke shift %d", approver.FullName, req.ShiftID)
+		s.book(&record, sale, cashier.FullName, record.CashierID, ShortageAccept, req.ShiftID, []string{note})
+	}
+
+	if err := s.db.Save(&record).Error; err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// shiftAt mencari shift kasir yang terbuka pada waktu penjualan offline
+// dibuat. Shift yang sudah ditutup tidak dipakai karena rekap kasnya sudah
+// direkonsiliasi.
+func (s *SyncService) shiftAt(cashierID uint, at time.Time) (*shift.Shift, error) {
+	var found shift.Shift
+	err := s.db.Where("opening_officer_id = ? AND opening_time <= ? AND (closing_time IS NULL OR closing_time >= ?)", cashierID, at, at).
+		Order("opening_time DESC").
+		First(&found).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNoShiftAtTime
+	}
+	if err != nil {
+		return nil, err
+	}
+	if found.Status != "Buka" {
+		return nil, ErrShiftClosed
+	}
+	return &found, nil
+}
+
+// shortages membandingkan jumlah per produk dengan total stok di server
+func (s *SyncService) shortages(items []OfflineItemRequest) ([]Conflict, error) {
+	requested := make(map[uint]int)
+	order := []uint{}
+	for _, item := range items {
+		if _, ok := requested[item.ProductID]; !ok {
+			order = append(order, item.ProductID)
+		}
+		requested[item.ProductID] += item.Qty
+	}
+
+	var rows []struct {
+		ID        uint
+		Name      string
+		Available int
+	}
+	err := s.db.Raw(`
+		SELECT p.id, p.name,
+			COALESCE((SELECT SUM(quantity) FROM stocks WHERE product_id = p.id), 0) AS available
+		FROM products p
+		WHERE p.id IN ?
+	`, order).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	conflicts := []Conflict{}
+	for _, r := range rows {
+		if requested[r.ID] > r.Available {
+			conflicts = append(conflicts, Conflict{
+				ProductID: r.ID,
+				Name:      r.Name,
+				Requested: requested[r.ID],
+				Available: r.Available,
+			})
+		}
+	}
+	return conflicts, nil
+}
+
+// request menyusun request penjualan reguler baru dari penjualan offline.
+// Kode, nama dan satuan produk diambil dari master, sedangkan harga satuan
+// mengikuti harga yang berlaku di klien saat transaksi terjadi. Request
+// selalu dibuat ulang karena perhitungan harga mengubah isinya.
+func (s *SyncService) request(sale OfflineSaleRequest, cashierName string, cashierID uint) (*sales.SalesRegularRequest, error) {
+	ids := make([]uint, len(sale.Items))
+	for i, item := range sale.Items {
+		ids[i] = item.ProductID
+	}
+
+	var rows []struct {
+		ID       uint
+		Code     string
+		Name     string
+		UnitName string
+	}
+	err := s.db.Raw(`
+		SELECT p.id, p.code, p.name, COALESCE(u.name, '') AS unit_name
+		FROM products p
+		LEFT JOIN units u ON u.id = p.unit_id
+		WHERE p.id IN ?
+	`, ids).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	products := make(map[uint]int, len(rows))
+	for i, r := range rows {
+		products[r.ID] = i
+	}
+
+	req := &sales.SalesRegularRequest{
+		TransactionDate: sale.CreatedAt,
+		CashierName:     cashierName,
+		CustomerID:      sale.CustomerID,
+		Description:     sale.Description,
+		PaymentMethod:   sale.PaymentMethod,
+		Payments:        append([]payment.PaymentRequest(nil), sale.Payments...),
+		CashierID:       cashierID,
+
+		PharmacistOverrideReason: sale.PharmacistOverrideReason,
+		PharmacistEmail:          sale.PharmacistEmail,
+		PharmacistPassword:       sale.PharmacistPassword,
+	}
+	if sale.TotalDiscount != nil {
+		discount := *sale.TotalDiscount
+		req.TotalDiscount = &discount
+	}
+	for _, item := range sale.Items {
+		i, ok := products[item.ProductID]
+		if !ok {
+			return nil, fmt.Errorf("%w: ID %d", ErrProductNotFound, item.ProductID)
+		}
+		req.Items = append(req.Items, sales.SalesRegularItemRequest{
+			ProductID:   item.ProductID,
+			ProductCode: rows[i].Code,
+			ProductName: rows[i].Name,
+			Qty:         item.Qty,
+			Unit:        rows[i].UnitName,
+			UnitPrice:   item.UnitPrice,
+		})
+	}
+	return req, nil
+}
+
+func firstMethod(sale OfflineSaleRequest) string {
+	if len(sale.Payments) > 0 {
+		return sale.Payments[0].Method
+	}
+	return sale.PaymentMethod
+}
+
+// Status mengambil hasil unggah penjualan offline milik perangkat. Bila
+// clientIDs kosong, seluruh penjualan perangkat dikembalikan dari yang terbaru.
+func (s *SyncService) Status(deviceID string, clientIDs []string, status string) ([]OfflineSale, error) {
+	if deviceID == "" && len(clientIDs) == 0 {
+		return nil, ErrEmptyDevice
+	}
+	query := s.db.Model(&OfflineSale{})
+	if deviceID != "" {
+		query = query.Where("device_id = ?", deviceID)
+	}
+	if len(clientIDs) > 0 {
+		query = query.Where("client_id IN ?", clientIDs)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var records []OfflineSale
+	err := query.Order("client_created_at DESC").Limit(500).Find(&records).Error
+	return records, err
+}
@@ -0,0 +1,179 @@
+package prescription
+
+import (
+	"errors"
+	"fmt"
+	"go-gin-auth/internal/pharmacy"
+	"go-gin-auth/internal/stock"
+	"math"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// DosageForms adalah bentuk sediaan racikan yang dilayani apotek
+var DosageForms = []string{"Kapsul", "Puyer", "Salep", "Krim", "Sirup"}
+
+var (
+	ErrEmptyPrescription = errors.New("resep wajib berisi minimal satu item atau racikan")
+	ErrInvalidDosageForm = errors.New("bentuk sediaan racikan harus salah satu dari: " + strings.Join(DosageForms, ", "))
+)
+
+func validDosageForm(form string) bool {
+	for _, f := range DosageForms {
+		if f == form {
+			return true
+		}
+	}
+	return false
+}
+
+// buildCompounds menyusun racikan dari request: harga komponen diambil dari
+// harga jual produk, jumlah stok dibulatkan ke atas, dan biaya racikan
+// memakai tuslah per racikan ditambah embalase per kapsul/bungkus dari
+// profil apotek kecuali diisi pada request. Pajak diisi oleh calculateTotal.
+func buildCompounds(tx *gorm.DB, reqs []CreateCompoundRequest) ([]PrescriptionCompound, error) {
+	if len(reqs) == 0 {
+		return nil, nil
+	}
+
+	profile, err := pharmacy.Get(tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pharmacy profile: %w", err)
+	}
+
+	var ids []uint
+	for _, req := range reqs {
+		if !validDosageForm(req.DosageForm) {
+			return nil, ErrInvalidDosageForm
+		}
+		for _, component := range req.Components {
+			ids = append(ids, component.ProductID)
+		}
+	}
+
+	var rows []struct {
+		ID           uint
+		Code         string
+		Name         string
+		UnitName     string
+		SellingPrice float64
+	}
+	err = tx.Raw(`
+		SELECT p.id, p.code, p.name, COALESCE(u.name, '') AS unit_name, p.selling_price
+		FROM products p
+		LEFT JOIN units u ON u.id = p.unit_id
+		WHERE p.id IN ? AND p.deleted_at IS NULL
+	`, ids).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load compound components: %w", err)
+	}
+	products := make(map[uint]int, len(rows))
+	for i, r := range rows {
+		products[r.ID] = i
+	}
+
+	compounds := make([]PrescriptionCompound, 0, len(reqs))
+	for _, req := range reqs {
+		compound := PrescriptionCompound{
+			Name:        req.Name,
+			DosageForm:  req.DosageForm,
+			Quantity:    req.Quantity,
+			Signa:       req.Signa,
+			Instruction: req.Instruction,
+			LabelType:   compoundLabelType(req),
+		}
+		for _, input := range req.Components {
+			i, ok := products[input.ProductID]
+			if !ok {
+				return nil, fmt.Errorf("product ID %d not found for compound %s", input.ProductID, req.Name)
+			}
+			var stockItem stock.Stock
+			if err := tx.Where("product_id = ?", input.ProductID).First(&stockItem).Error; err != nil {
+				return nil, fmt.Errorf("stock not found for product ID %d: %w", input.ProductID, err)
+			}
+
+			// toleransi kecil agar 2.0000001 hasil pembagian tidak menjadi 3
+			stockQty := int(math.Ceil(input.Quantity - 1e-6))
+			if stockQty < 1 {
+				stockQty = 1
+			}
+			component := PrescriptionCompoundComponent{
+				ProductID:     input.ProductID,
+				StockID:       stockItem.ID,
+				ItemCode:      rows[i].Code,
+				ItemName:      rows[i].Name,
+				Unit:          rows[i].UnitName,
+				Quantity:      input.Quantity,
+				StockQuantity: stockQty,
+				Price:         rows[i].SellingPrice,
+				SubTotal:      rows[i].SellingPrice * float64(stockQty),
+			}
+			compound.ComponentTotal += component.SubTotal
+			compound.Components = append(compound.Components, component)
+		}
+
+		if req.Fee != nil {
+			compound.Fee = *req.Fee
+		} else {
+			compound.Fee = float64(profile.CompoundFee + profile.CompoundUnitFee*req.Quantity)
+		}
+		compound.SubTotal = compound.ComponentTotal + compound.Fee
+		compound.Price = math.Round(compound.SubTotal/float64(compound.Quantity)*100) / 100
+		compounds = append(compounds, compound)
+	}
+	return compounds, nil
+}
+
+// saveCompounds menyimpan racikan beserta komponennya lalu mengurangi stok
+// setiap komponen
+func saveCompounds(tx *gorm.DB, saleID uint, compounds []PrescriptionCompound) error {
+	for i := range compounds {
+		compounds[i].PrescriptionSaleID = saleID
+		if err := tx.Create(&compounds[i]).Error; err != nil {
+			return fmt.Errorf("failed to create compound: %w", err)
+		}
+
+		for _, component := range compounds[i].Components {
+			result := tx.Model(&stock.Stock{}).
+				Where("id = ? AND quantity >= ?", component.StockID, component.StockQuantity).
+				Update("quantity", gorm.Expr("quantity - ?", component.StockQuantity))
+			if result.Error != nil {
+				return fmt.Errorf("failed to update stock quantity: %w", result.Error)
+			}
+			if result.RowsAffected == 0 {
+				return fmt.Errorf("insufficient stock for product ID %d in compound %s", component.ProductID, compounds[i].Name)
+			}
+		}
+	}
+	return nil
+}
+
+// restoreCompounds mengembalikan stok komponen racikan milik penjualan.
+// Bila remove true, racikan dan komponennya ikut dihapus (soft delete)
+// seperti item biasa saat penjualan diubah.
+func restoreCompounds(tx *gorm.DB, saleID uint, remove bool) error {
+	var compounds []PrescriptionCompound
+	if err := tx.Preload("Components").Where("prescription_sale_id = ?", saleID).Find(&compounds).Error; err != nil {
+		return fmt.Errorf("failed to load compounds: %w", err)
+	}
+
+	for _, compound := range compounds {
+		for _, component := range compound.Components {
+			if err := tx.Model(&stock.Stock{}).Where("id = ?", component.StockID).
+				Update("quantity", gorm.Expr("quantity + ?", component.StockQuantity)).Error; err != nil {
+				return fmt.Errorf("failed to restore stock: %w", err)
+			}
+		}
+		if !remove {
+			continue
+		}
+		if err := tx.Where("compound_id = ?", compound.ID).Delete(&PrescriptionCompoundComponent{}).Error; err != nil {
+			return fmt.Errorf("failed to delete compound components: %w", err)
+		}
+		if err := tx.Delete(&compound).Error; err != nil {
+			return fmt.Errorf("failed to delete compound ID %d: %w", compound.ID, err)
+		}
+	}
+	return nil
+}
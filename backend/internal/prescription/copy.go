@@ -0,0 +1,147 @@
+package prescription
+
+import (
+	"fmt"
+	"go-gin-auth/internal/pharmacy"
+	"go-gin-auth/pkg/pdf"
+	"strings"
+	"time"
+)
+
+// Ukuran salinan resep A5 dalam point
+const (
+	copyWidth  = 148 * pdf.MMToPt
+	copyHeight = 210 * pdf.MMToPt
+)
+
+// CopyPrescription mencetak salinan resep (PDF A5) untuk resep yang masih
+// memiliki sisa atau iter yang belum diserahkan. Penjualan mana pun dalam
+// rantai resep yang sama menghasilkan salinan dari resep asal.
+func (s *PrescriptionSaleService) CopyPrescription(id uint) ([]byte, error) {
+	remainder, err := s.Remainder(id)
+	if err != nil {
+		return nil, err
+	}
+	if !remainder.HasRemainder {
+		return nil, ErrFullyDispensed
+	}
+
+	origin, err := s.GetByID(remainder.OriginSaleID)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := pharmacy.Get(s.db)
+	if err != nil {
+		return nil, err
+	}
+	return renderCopy(origin, remainder, profile, time.Now()), nil
+}
+
+func renderCopy(sale *PrescriptionSale, remainder *Remainder, profile pharmacy.Profile, printedAt time.Time) []byte {
+	doc := pdf.New(copyWidth, copyHeight, 28)
+
+	doc.SetFont(pdf.Bold, 12)
+	doc.Centered(profile.Name)
+	doc.SetFont(pdf.Regular, 8)
+	if profile.Address != "" {
+		doc.Centered(profile.Address)
+	}
+	if profile.Phone != "" {
+		doc.Centered("Telp. " + profile.Phone)
+	}
+	if profile.PharmacistName != "" {
+		doc.Centered("Apoteker: " + profile.PharmacistName)
+	}
+	if profile.SIANumber != "" {
+		doc.Centered("SIA: " + profile.SIANumber)
+	}
+	doc.Line()
+
+	doc.SetFont(pdf.Bold, 11)
+	doc.Centered("SALINAN RESEP")
+	doc.Gap(4)
+
+	doc.SetFont(pdf.Regular, 9)
+	doc.Text("No. Resep     : " + sale.PrescriptionNo)
+	doc.Text("Tanggal Resep : " + sale.PrescriptionDate.Format("02-01-2006"))
+	doc.Text("Dokter        : " + decrypted(sale.Doctor.FullName))
+	doc.Text("Pasien        : " + decrypted(sale.Patient.FullName))
+	doc.Text("No. Transaksi : " + sale.TransactionCode)
+	doc.Line()
+
+	doc.SetFont(pdf.Mono, 9)
+	for _, item := range remainder.Items {
+		doc.Text(fmt.Sprintf("R/ %s No. %d %s", item.ItemName, item.PrescribedQuantity, item.Unit))
+		if item.Signa != "" {
+			doc.Text("   S " + item.Signa)
+		}
+		if item.Iter > 0 {
+			doc.Text(fmt.Sprintf("   iter %dx", item.Iter))
+		}
+		doc.Text("   " + dispenseNote(item))
+		doc.Gap(4)
+	}
+	for _, compound := range sale.Compounds {
+		doc.Text(fmt.Sprintf("R/ %s (racikan)", compound.Name))
+		for _, component := range compound.Components {
+			doc.Text(fmt.Sprintf("     %s %s %s", component.ItemName, formatQuantity(component.Quantity), component.Unit))
+		}
+		doc.Text(fmt.Sprintf("   m.f. %s dtd No. %d", strings.ToLower(compound.DosageForm), compound.Quantity))
+		doc.Text("   S " + compound.Signa)
+		doc.Text("   det orig")
+		doc.Gap(4)
+	}
+	doc.Line()
+
+	doc.SetFont(pdf.Regular, 8)
+	doc.Text("Diserahkan:")
+	for _, d := range remainder.Dispensings {
+		doc.Text(fmt.Sprintf("- %s  %s", d.TransactionDate.Format("02-01-2006"), d.TransactionCode))
+	}
+	doc.Gap(10)
+
+	width := doc.ContentWidth()
+	right := func(s string) {
+		doc.Row(pdf.Column{X: width / 2, Width: width / 2, Text: s, Align: pdf.Center})
+	}
+	right("Tanggal " + printedAt.Format("02-01-2006"))
+	right("p.c.c.")
+	doc.Gap(28)
+	doc.SetFont(pdf.Bold, 9)
+	right(profile.PharmacistName)
+	doc.SetFont(pdf.Regular, 8)
+	if profile.SIPANumber != "" {
+		right("SIPA: " + profile.SIPANumber)
+	}
+	return doc.Bytes()
+}
+
+// dispenseNote menuliskan status penyerahan dengan singkatan salinan resep:
+// det orig (resep asli diserahkan), det iter Nx, det N (diserahkan
+// sebagian) atau nedet (belum diserahkan)
+func dispenseNote(item RemainderItem) string {
+	if item.Dispensed <= 0 || item.PrescribedQuantity <= 0 {
+		return "nedet"
+	}
+	fills := item.Dispensed / item.PrescribedQuantity
+	partial := item.Dispensed % item.PrescribedQuantity
+
+	var notes []string
+	if fills >= 1 {
+		notes = append(notes, "det orig")
+	}
+	if fills > 1 {
+		notes = append(notes, fmt.Sprintf("det iter %dx", fills-1))
+	}
+	if partial > 0 {
+		notes = append(notes, fmt.Sprintf("det %d", partial))
+	}
+	return strings.Join(notes, ", ")
+}
+
+func formatQuantity(q float64) string {
+	if q == float64(int(q)) {
+		return fmt.Sprintf("%d", int(q))
+	}
+	return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.3f", q), "0"), ".")
+}
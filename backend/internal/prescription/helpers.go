@@ -4,31 +4,16 @@ package prescription
 import (
 	"fmt"
 	"go-gin-auth/internal/stock"
-	"math/rand"
-	"time"
 )
 
-func generateTransactionCode() string {
-	return fmt.Sprintf("TRX-%d-%s", time.Now().Unix(), generateRandomString(4))
-}
-
-func generateRandomString(length int) string {
-	const charset = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	result := make([]byte, length)
-	for i := range result {
-		result[i] = charset[rand.Intn(len(charset))]
-	}
-	return string(result)
-}
-
 // ValidateUpdateRequest validates the update request
 func (s *PrescriptionSaleService) ValidateUpdateRequest(req *CreatePrescriptionSaleRequest) error {
 	if req.PrescriptionNo == "" {
 		return fmt.Errorf("prescription number is required")
 	}
 
-	if len(req.Items) == 0 {
-		return fmt.Errorf("at least one item is required")
+	if len(req.Items) == 0 && len(req.Compounds) == 0 {
+		return ErrEmptyPrescription
 	}
 
 	for i, item := range req.Items {
@@ -43,6 +28,18 @@ func (s *PrescriptionSaleService) ValidateUpdateRequest(req *CreatePrescriptionS
 		}
 	}
 
+	for i, compound := range req.Compounds {
+		if !validDosageForm(compound.DosageForm) {
+			return ErrInvalidDosageForm
+		}
+		if compound.Quantity <= 0 {
+			return fmt.Errorf("quantity must be positive for compound %d", i+1)
+		}
+		if len(compound.Components) == 0 {
+			return fmt.Errorf("at least one component is required for compound %d", i+1)
+		}
+	}
+
 	return nil
 }
 
@@ -0,0 +1,281 @@
+package prescription
+
+import (
+	"errors"
+	"fmt"
+	"go-gin-auth/internal/payment"
+	"go-gin-auth/utils"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrOriginVoided      = errors.New("resep asal sudah dibatalkan")
+	ErrOriginItemMissing = errors.New("setiap item pengambilan lanjutan wajib merujuk item pada resep asal")
+	ErrExceedsPrescribed = errors.New("jumlah yang diserahkan melebihi jumlah resep beserta iternya")
+	ErrHasFollowUps      = errors.New("resep sudah memiliki pengambilan lanjutan sehingga tidak dapat diubah")
+	ErrFullyDispensed    = errors.New("seluruh resep sudah diserahkan, salinan resep tidak diperlukan")
+	ErrCompoundFollowUp  = errors.New("racikan tidak dapat diambil lanjutan, buat penjualan resep baru untuk racikan")
+)
+
+// Remainder adalah status penyerahan resep asal beserta seluruh
+// pengambilan lanjutannya
+type Remainder struct {
+	OriginSaleID     uint            `json:"origin_sale_id"`
+	PrescriptionNo   string          `json:"prescription_no"`
+	PrescriptionDate time.Time       `json:"prescription_date"`
+	PatientID        uint            `json:"patient_id"`
+	Items            []RemainderItem `json:"items"`
+	Dispensings      []Dispensing    `json:"dispensings"`
+	// HasRemainder menandai masih ada item yang belum diserahkan sehingga
+	// salinan resep dapat dicetak
+	HasRemainder bool `json:"has_remainder"`
+}
+
+// RemainderItem adalah hak dan realisasi penyerahan satu item resep.
+// Entitled adalah PrescribedQuantity x (1 + Iter).
+type RemainderItem struct {
+	ItemID             uint   `json:"item_id"`
+	ProductID          uint   `json:"product_id"`
+	ItemCode           string `json:"item_code"`
+	ItemName           string `json:"item_name"`
+	Unit               string `json:"unit"`
+	Signa              string `json:"signa"`
+	PrescribedQuantity int    `json:"prescribed_quantity"`
+	Iter               int    `json:"iter"`
+	Entitled           int    `json:"entitled"`
+	Dispensed          int    `json:"dispensed"`
+	Remaining          int    `json:"remaining"`
+}
+
+// Dispensing adalah satu penjualan yang menyerahkan bagian resep
+type Dispensing struct {
+	SaleID          uint      `json:"sale_id"`
+	TransactionCode string    `json:"transaction_code"`
+	TransactionDate time.Time `json:"transaction_date"`
+}
+
+// rootSale mengambil resep asal dari penjualan mana pun dalam rantai
+// pengambilan resep yang sama
+func rootSale(db *gorm.DB, id uint) (*PrescriptionSale, error) {
+	var sale PrescriptionSale
+	if err := db.First(&sale, id).Error; err != nil {
+		return nil, err
+	}
+	if sale.OriginSaleID == nil {
+		return &sale, nil
+	}
+	var origin PrescriptionSale
+	if err := db.First(&origin, *sale.OriginSaleID).Error; err != nil {
+		return nil, err
+	}
+	return &origin, nil
+}
+
+// dispensed menjumlahkan penyerahan per item resep asal dari penjualan yang
+// tidak dibatalkan. excludeSaleID dilewati saat penjualan itu sedang diubah.
+func dispensed(db *gorm.DB, originSaleID, excludeSaleID uint) (map[uint]int, error) {
+	var rows []struct {
+		RootID   uint
+		Quantity int
+	}
+	err := db.Raw(`
+		SELECT COALESCE(i.origin_item_id, i.id) AS root_id, SUM(i.quantity) AS quantity
+		FROM prescription_items i
+		JOIN prescription_sales s ON s.id = i.prescription_sale_id
+		WHERE (s.id = ? OR s.origin_sale_id = ?) AND s.id <> ?
+		AND i.deleted_at IS NULL AND s.deleted_at IS NULL AND s.status <> ?
+		GROUP BY COALESCE(i.origin_item_id, i.id)
+	`, originSaleID, originSaleID, excludeSaleID, payment.SaleVoided).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[uint]int, len(rows))
+	for _, r := range rows {
+		result[r.RootID] = r.Quantity
+	}
+	return result, nil
+}
+
+// applyPrescribed memeriksa jumlah penyerahan terhadap resep. Untuk resep
+// baru, jumlah resep bawaannya sama dengan jumlah yang diserahkan. Untuk
+// pengambilan lanjutan, data resep dan setiap item disalin dari resep asal
+// dan jumlahnya tidak boleh melebihi sisa. Racikan tidak memiliki jumlah
+// resep dan iter sehingga tidak boleh ada pada pengambilan lanjutan.
+// saleID diisi saat mengubah penjualan agar penyerahannya sendiri tidak
+// ikut dihitung.
+func applyPrescribed(tx *gorm.DB, req *CreatePrescriptionSaleRequest, saleID uint) error {
+	if req.OriginSaleID == nil {
+		for i := range req.Items {
+			item := &req.Items[i]
+			if item.OriginItemID != nil {
+				return ErrOriginItemMissing
+			}
+			if item.PrescribedQuantity <= 0 {
+				item.PrescribedQuantity = item.Quantity
+			}
+			if item.Quantity > item.PrescribedQuantity*(1+item.Iter) {
+				return fmt.Errorf("%w: %s", ErrExceedsPrescribed, item.Name)
+			}
+		}
+		return nil
+	}
+	if len(req.Compounds) > 0 {
+		return ErrCompoundFollowUp
+	}
+
+	root, err := rootSale(tx, *req.OriginSaleID)
+	if err != nil {
+		return fmt.Errorf("origin prescription not found: %w", err)
+	}
+	// resep asal dikunci sampai transaksi selesai agar pengambilan lanjutan
+	// yang bersamaan tidak sama-sama lolos pemeriksaan sisa resep
+	var origin PrescriptionSale
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&origin, root.ID).Error; err != nil {
+		return fmt.Errorf("origin prescription not found: %w", err)
+	}
+	if origin.ID == saleID {
+		return ErrOriginItemMissing
+	}
+	if origin.Status == payment.SaleVoided {
+		return ErrOriginVoided
+	}
+	req.OriginSaleID = &origin.ID
+	req.PrescriptionNo = origin.PrescriptionNo
+	req.PrescriptionDate = origin.PrescriptionDate
+	req.DoctorID = origin.DoctorID
+	req.PatientID = origin.PatientID
+
+	var originItems []PrescriptionItem
+	if err := tx.Preload("Stock").Where("prescription_sale_id = ?", origin.ID).Find(&originItems).Error; err != nil {
+		return err
+	}
+	byID := make(map[uint]PrescriptionItem, len(originItems))
+	for _, item := range originItems {
+		byID[item.ID] = item
+	}
+
+	given, err := dispensed(tx, origin.ID, saleID)
+	if err != nil {
+		return err
+	}
+	for i := range req.Items {
+		item := &req.Items[i]
+		if item.OriginItemID == nil {
+			return ErrOriginItemMissing
+		}
+		root, ok := byID[*item.OriginItemID]
+		if !ok || root.Stock.ProductID != item.ProductID {
+			return fmt.Errorf("%w: %s", ErrOriginItemMissing, item.Name)
+		}
+		item.PrescribedQuantity = root.PrescribedQuantity
+		item.Iter = root.Iter
+		item.Signa = root.Signa
+		if item.Instruction == "" {
+			item.Instruction = root.Instruction
+		}
+		if item.LabelType == "" {
+			item.LabelType = root.LabelType
+		}
+
+		remaining := root.PrescribedQuantity*(1+root.Iter) - given[root.ID]
+		if item.Quantity > remaining {
+			return fmt.Errorf("%w: %s tersisa %d %s", ErrExceedsPrescribed, item.Name, remaining, root.Unit)
+		}
+		given[root.ID] += item.Quantity
+	}
+	return nil
+}
+
+// ensureNoFollowUps menolak perubahan resep asal yang sudah diambil
+// lanjutan, karena item lanjutan merujuk ID item resep asal
+func ensureNoFollowUps(tx *gorm.DB, saleID uint) error {
+	var count int64
+	if err := tx.Model(&PrescriptionSale{}).
+		Where("origin_sale_id = ? AND status <> ?", saleID, payment.SaleVoided).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrHasFollowUps
+	}
+	return nil
+}
+
+// Remainder menghitung sisa penyerahan resep dari penjualan mana pun dalam
+// rantai pengambilan resep yang sama
+func (s *PrescriptionSaleService) Remainder(id uint) (*Remainder, error) {
+	origin, err := rootSale(s.db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []PrescriptionItem
+	if err := s.db.Preload("Stock").Where("prescription_sale_id = ?", origin.ID).Order("id").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	given, err := dispensed(s.db, origin.ID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Remainder{
+		OriginSaleID:     origin.ID,
+		PrescriptionNo:   origin.PrescriptionNo,
+		PrescriptionDate: origin.PrescriptionDate,
+		PatientID:        origin.PatientID,
+		Items:            []RemainderItem{},
+	}
+	for _, item := range items {
+		prescribed := item.PrescribedQuantity
+		if prescribed <= 0 {
+			prescribed = item.Quantity
+		}
+		entitled := prescribed * (1 + item.Iter)
+		remaining := entitled - given[item.ID]
+		if remaining < 0 {
+			remaining = 0
+		}
+		if remaining > 0 {
+			result.HasRemainder = true
+		}
+		result.Items = append(result.Items, RemainderItem{
+			ItemID:             item.ID,
+			ProductID:          item.Stock.ProductID,
+			ItemCode:           item.ItemCode,
+			ItemName:           item.ItemName,
+			Unit:               item.Unit,
+			Signa:              item.Signa,
+			PrescribedQuantity: prescribed,
+			Iter:               item.Iter,
+			Entitled:           entitled,
+			Dispensed:          given[item.ID],
+			Remaining:          remaining,
+		})
+	}
+
+	err = s.db.Model(&PrescriptionSale{}).
+		Select("id AS sale_id, transaction_code, transaction_date").
+		Where("(id = ? OR origin_sale_id = ?) AND status <> ?", origin.ID, origin.ID, payment.SaleVoided).
+		Order("transaction_date, id").
+		Scan(&result.Dispensings).Error
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// decrypted membuka data terenkripsi pasien/dokter; nilai yang tidak
+// terenkripsi dikembalikan apa adanya
+func decrypted(value string) string {
+	if value == "" {
+		return ""
+	}
+	plain, err := utils.Decrypt(value)
+	if err != nil {
+		return value
+	}
+	return plain
+}
@@ -0,0 +1,102 @@
+package prescription
+
+import (
+	"fmt"
+	"go-gin-auth/internal/payment"
+	"go-gin-auth/internal/pharmacy"
+	"go-gin-auth/internal/receipt"
+	"time"
+)
+
+// itemLabelType mengisi jenis etiket item; bawaannya etiket putih
+func itemLabelType(labelType string) string {
+	if labelType == "" {
+		return receipt.LabelWhite
+	}
+	return labelType
+}
+
+// compoundLabelType mengisi jenis etiket racikan; salep dan krim memakai
+// etiket biru (obat luar)
+func compoundLabelType(req CreateCompoundRequest) string {
+	if req.LabelType != "" {
+		return req.LabelType
+	}
+	switch req.DosageForm {
+	case "Salep", "Krim":
+		return receipt.LabelBlue
+	}
+	return receipt.LabelWhite
+}
+
+// PrintLabels mencetak etiket seluruh item dan racikan pada resep dengan
+// format pdf atau escpos. Nilai kedua adalah content type.
+func (s *PrescriptionSaleService) PrintLabels(id uint, format string, paper int) ([]byte, string, error) {
+	sale, err := s.GetByID(id)
+	if err != nil {
+		return nil, "", err
+	}
+	if sale.Status == payment.SaleVoided {
+		return nil, "", payment.ErrSaleVoided
+	}
+	profile, err := pharmacy.Get(s.db)
+	if err != nil {
+		return nil, "", err
+	}
+
+	labels, err := s.labels(sale)
+	if err != nil {
+		return nil, "", err
+	}
+	return receipt.RenderLabels(labels, profile, format, paper)
+}
+
+func (s *PrescriptionSaleService) labels(sale *PrescriptionSale) ([]receipt.Label, error) {
+	patient := decrypted(sale.Patient.FullName)
+
+	labels := make([]receipt.Label, 0, len(sale.Items)+len(sale.Compounds))
+	for _, item := range sale.Items {
+		labels = append(labels, receipt.Label{
+			Type:        itemLabelType(item.LabelType),
+			Number:      len(labels) + 1,
+			Code:        sale.TransactionCode,
+			Date:        sale.TransactionDate,
+			Patient:     patient,
+			Signa:       item.Signa,
+			Instruction: item.Instruction,
+			Product:     item.ItemName,
+			Quantity:    fmt.Sprintf("%d %s", item.Quantity, item.Unit),
+			Expiry:      item.Stock.ExpiryDate,
+		})
+	}
+
+	for _, compound := range sale.Compounds {
+		// tanggal kedaluwarsa racikan mengikuti komponen yang paling cepat
+		// kedaluwarsa
+		stockIDs := make([]uint, len(compound.Components))
+		for i, component := range compound.Components {
+			stockIDs[i] = component.StockID
+		}
+		var expiry *time.Time
+		if len(stockIDs) > 0 {
+			if err := s.db.Table("stocks").Where("id IN ?", stockIDs).
+				Select("MIN(expiry_date)").Scan(&expiry).Error; err != nil {
+				return nil, err
+			}
+		}
+
+		labels = append(labels, receipt.Label{
+			Type:        compound.LabelType,
+			Number:      len(labels) + 1,
+			Code:        sale.TransactionCode,
+			Date:        sale.TransactionDate,
+			Patient:     patient,
+			Signa:       compound.Signa,
+			Instruction: compound.Instruction,
+			Product:     fmt.Sprintf("%s (%s)", compound.Name, compound.DosageForm),
+			Quantity:    fmt.Sprintf("No. %d", compound.Quantity),
+			Expiry:      expiry,
+		})
+	}
+	return labels, nil
+}
@@ -2,7 +2,9 @@ package prescription
 
 import (
 	"go-gin-auth/internal/doctor"
+	"go-gin-auth/internal/interaction"
 	"go-gin-auth/internal/patient"
+	"go-gin-auth/internal/payment"
 	"go-gin-auth/internal/shift"
 	"go-gin-auth/internal/stock"
 	"time"
@@ -21,25 +23,49 @@ type PrescriptionSale struct {
 	Clinic           string        `json:"clinic"`
 	Diagnosis        string        `json:"diagnosis"`
 
+	// OriginSaleID menunjuk penjualan resep asal bila penjualan ini
+	// mengambil sisa atau iter resep yang sama
+	OriginSaleID *uint `json:"origin_sale_id,omitempty" gorm:"index"`
+
 	// Patient Info
 	PatientID uint            `json:"patient_id"`
 	Patient   patient.Patient `json:"patient" gorm:"foreignKey:PatientID"`
 
 	// Transaction Info
-	TransactionDate time.Time   `json:"transaction_date"`
-	PaymentMethod   string      `json:"payment_method"` // Tunai/Transfer/QRIS/Kredit/BPJS/Jaminan
-	DiscountPercent float64     `json:"discount_percent"`
-	DiscountAmount  float64     `json:"discount_amount"`
-	TotalAmount     float64     `json:"total_amount"`
-	ShiftID         uint        `json:"shift_id"`
-	Shift           shift.Shift `json:"shift" gorm:"foreignKey:ShiftID"`
+	TransactionDate time.Time `json:"transaction_date"`
+	PaymentMethod   string    `json:"payment_method"` // Tunai/Transfer/QRIS/Kredit/BPJS/Jaminan/Campuran
+	DiscountPercent float64   `json:"discount_percent"`
+	DiscountAmount  float64   `json:"discount_amount"`
+	// PromotionDiscount adalah total potongan promosi sebelum diskon manual
+	PromotionDiscount float64 `json:"promotion_discount"`
+	// TaxBase dan TaxAmount adalah DPP dan PPN seluruh item
+	TaxBase     float64     `json:"tax_base" gorm:"type:decimal(15,2);not null;default:0"`
+	TaxAmount   float64     `json:"tax_amount" gorm:"type:decimal(15,2);not null;default:0"`
+	TotalAmount float64     `json:"total_amount"`
+	ShiftID     uint        `json:"shift_id"`
+	Shift       shift.Shift `json:"shift" gorm:"foreignKey:ShiftID"`
+	RegisterID  *uint       `json:"register_id,omitempty" gorm:"index"`
+	Status      string      `json:"status" gorm:"type:varchar(20);not null;default:'Selesai';index"`
+	VoidedAt    *time.Time  `json:"voided_at,omitempty"`
 
 	// Items with proper cascade delete
 	Items []PrescriptionItem `json:"items" gorm:"foreignKey:PrescriptionSaleID;constraint:OnDelete:CASCADE"`
 
+	// Compounds adalah item racikan beserta komponennya
+	Compounds []PrescriptionCompound `json:"compounds" gorm:"foreignKey:PrescriptionSaleID;constraint:OnDelete:CASCADE"`
+
+	Payments []payment.Payment `json:"payments" gorm:"polymorphic:Sale;polymorphicValue:prescription"`
+
+	// InteractionWarnings adalah peringatan interaksi obat saat resep disimpan
+	InteractionWarnings []interaction.Warning `json:"interaction_warnings,omitempty" gorm:"-"`
+
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+
+	// CashierID adalah kasir yang menyimpan penjualan; satu shift dapat
+	// dipakai beberapa kasir sehingga pembuka shift bukan kasirnya
+	CashierID *uint `json:"cashier_id,omitempty" gorm:"index"`
 }
 
 // PrescriptionItem represents individual items in a prescription sale
@@ -54,15 +80,94 @@ type PrescriptionItem struct {
 	Unit               string         `json:"unit"`
 	Price              float64        `json:"price" gorm:"not null;check:price >= 0"`
 	SubTotal           float64        `json:"sub_total" gorm:"not null;check:sub_total >= 0"`
+	PromotionID        *uint          `json:"promotion_id,omitempty"`
+	Discount           float64        `json:"discount" gorm:"not null;default:0"`
+	TaxRate            float64        `json:"tax_rate" gorm:"type:decimal(5,2);not null;default:0"`
+	TaxBase            float64        `json:"tax_base" gorm:"type:decimal(15,2);not null;default:0"`
+	TaxAmount          float64        `json:"tax_amount" gorm:"type:decimal(15,2);not null;default:0"`
 	CreatedAt          time.Time      `json:"created_at"`
 	UpdatedAt          time.Time      `json:"updated_at"`
 	DeletedAt          gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+
+	// PrescribedQuantity adalah jumlah yang ditulis dokter untuk sekali
+	// ambil dan Iter jumlah pengulangan yang diizinkan. Quantity adalah
+	// jumlah yang diserahkan pada penjualan ini.
+	PrescribedQuantity int    `json:"prescribed_quantity" gorm:"not null;default:0"`
+	Iter               int    `json:"iter" gorm:"not null;default:0"`
+	Signa              string `json:"signa" gorm:"type:varchar(255)"`
+	// OriginItemID menunjuk item pada resep asal untuk pengambilan lanjutan
+	OriginItemID *uint `json:"origin_item_id,omitempty" gorm:"index"`
+
+	// Instruction adalah aturan pakai tambahan pada etiket, misalnya
+	// "sesudah makan"; LabelType menentukan etiket putih (obat dalam) atau
+	// biru (obat luar)
+	Instruction string `json:"instruction" gorm:"type:varchar(255)"`
+	LabelType   string `json:"label_type" gorm:"type:varchar(10);not null;default:'putih'"`
 }
 
 func (PrescriptionItem) TableName() string {
 	return "prescription_items"
 }
 
+// PrescriptionCompound adalah item racikan: beberapa obat digerus atau
+// dicampur menjadi Quantity kapsul/bungkus dengan bentuk sediaan DosageForm.
+// SubTotal adalah total harga komponen ditambah biaya tuslah/embalase (Fee);
+// Price adalah harga per kapsul/bungkus.
+type PrescriptionCompound struct {
+	ID                 uint    `json:"id" gorm:"primaryKey"`
+	PrescriptionSaleID uint    `json:"prescription_sale_id" gorm:"not null;index"`
+	Name               string  `json:"name" gorm:"type:varchar(255);not null"`
+	DosageForm         string  `json:"dosage_form" gorm:"type:varchar(20);not null"`
+	Quantity           int     `json:"quantity" gorm:"not null;check:quantity > 0"`
+	Signa              string  `json:"signa" gorm:"type:varchar(255);not null"`
+	ComponentTotal     float64 `json:"component_total" gorm:"type:decimal(15,2);not null;default:0"`
+	Fee                float64 `json:"fee" gorm:"type:decimal(15,2);not null;default:0"`
+	Price              float64 `json:"price" gorm:"type:decimal(15,2);not null;default:0"`
+	SubTotal           float64 `json:"sub_total" gorm:"type:decimal(15,2);not null;default:0"`
+	// FeeTaxBase adalah bagian DPP dari biaya racikan; biaya racikan tidak
+	// dikenai PPN. TaxBase dan TaxAmount adalah jumlah komponen dan biaya.
+	FeeTaxBase float64                         `json:"fee_tax_base" gorm:"type:decimal(15,2);not null;default:0"`
+	TaxBase    float64                         `json:"tax_base" gorm:"type:decimal(15,2);not null;default:0"`
+	TaxAmount  float64                         `json:"tax_amount" gorm:"type:decimal(15,2);not null;default:0"`
+	Components []PrescriptionCompoundComponent `json:"components" gorm:"foreignKey:CompoundID;constraint:OnDelete:CASCADE"`
+	CreatedAt  time.Time                       `json:"created_at"`
+	UpdatedAt  time.Time                       `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt                  `json:"deleted_at" gorm:"index"`
+
+	// Instruction dan LabelType dipakai saat mencetak etiket racikan
+	Instruction string `json:"instruction" gorm:"type:varchar(255)"`
+	LabelType   string `json:"label_type" gorm:"type:varchar(10);not null;default:'putih'"`
+}
+
+func (PrescriptionCompound) TableName() string {
+	return "prescription_compounds"
+}
+
+// PrescriptionCompoundComponent adalah obat penyusun racikan. Quantity boleh
+// pecahan (misalnya ½ tablet per kapsul); stok dan harga dihitung dari
+// StockQuantity, yaitu Quantity yang dibulatkan ke atas.
+type PrescriptionCompoundComponent struct {
+	ID            uint           `json:"id" gorm:"primaryKey"`
+	CompoundID    uint           `json:"compound_id" gorm:"not null;index"`
+	ProductID     uint           `json:"product_id" gorm:"not null;index"`
+	StockID       uint           `json:"stock_id" gorm:"not null"`
+	ItemCode      string         `json:"item_code"`
+	ItemName      string         `json:"item_name"`
+	Unit          string         `json:"unit"`
+	Quantity      float64        `json:"quantity" gorm:"type:decimal(10,3);not null"`
+	StockQuantity int            `json:"stock_quantity" gorm:"not null;check:stock_quantity > 0"`
+	Price         float64        `json:"price" gorm:"type:decimal(15,2);not null;default:0"`
+	SubTotal      float64        `json:"sub_total" gorm:"type:decimal(15,2);not null;default:0"`
+	TaxRate       float64        `json:"tax_rate" gorm:"type:decimal(5,2);not null;default:0"`
+	TaxBase       float64        `json:"tax_base" gorm:"type:decimal(15,2);not null;default:0"`
+	TaxAmount     float64        `json:"tax_amount" gorm:"type:decimal(15,2);not null;default:0"`
+	DeletedAt     gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+func (PrescriptionCompoundComponent) TableName() string {
+	return "prescription_compound_components"
+}
+
 // CreatePrescriptionSaleRequest represents the request payload for creating/updating prescription sales
 type CreatePrescriptionSaleRequest struct {
 	PrescriptionNo   string                          `json:"prescription_no" binding:"required"`
@@ -72,11 +177,29 @@ type CreatePrescriptionSaleRequest struct {
 	Diagnosis        string                          `json:"diagnosis"`
 	PatientID        uint                            `json:"patient_id" binding:"required"`
 	TransactionDate  time.Time                       `json:"transaction_date" binding:"required"`
-	PaymentMethod    string                          `json:"payment_method" binding:"required"`
+	PaymentMethod    string                          `json:"payment_method"`
 	DiscountPercent  float64                         `json:"discount_percent"`
 	DiscountAmount   float64                         `json:"discount_amount"`
-	ShiftID          uint                            `json:"shift_id" binding:"required"`
-	Items            []CreatePrescriptionItemRequest `json:"items" binding:"required,min=1"`
+	Items            []CreatePrescriptionItemRequest `json:"items"`
+	Payments         []payment.PaymentRequest        `json:"payments" binding:"omitempty,dive"`
+	// Compounds adalah item racikan; resep wajib berisi minimal satu item
+	// atau racikan
+	Compounds []CreateCompoundRequest `json:"compounds" binding:"omitempty,dive"`
+	// OriginSaleID diisi saat menyerahkan sisa atau iter dari resep yang
+	// sudah pernah dilayani; data resep, dokter dan pasien mengikuti resep
+	// asal dan setiap item wajib mengisi origin_item_id
+	OriginSaleID *uint `json:"origin_sale_id"`
+	// InteractionOverrideReason adalah alasan apoteker tetap melayani resep
+	// yang memiliki interaksi atau kontraindikasi berat
+	InteractionOverrideReason string `json:"interaction_override_reason"`
+	// PharmacistEmail dan PharmacistPassword adalah login ulang apoteker
+	// yang menyetujui alasan di atas
+	PharmacistEmail    string `json:"pharmacist_email"`
+	PharmacistPassword string `json:"pharmacist_password"`
+
+	// CashierID diisi dari token; penjualan otomatis terikat ke shift
+	// aktif kasir tersebut
+	CashierID uint `json:"-"`
 }
 
 // CreatePrescriptionItemRequest represents individual item in the request
@@ -87,4 +210,48 @@ type CreatePrescriptionItemRequest struct {
 	Quantity  int     `json:"quantity" binding:"required,min=1"`
 	Unit      string  `json:"unit" binding:"required"`
 	Price     float64 `json:"price" binding:"required,min=0"`
+
+	// PrescribedQuantity bawaannya sama dengan Quantity
+	PrescribedQuantity int    `json:"prescribed_quantity" binding:"min=0"`
+	Iter               int    `json:"iter" binding:"min=0"`
+	Signa              string `json:"signa"`
+	OriginItemID       *uint  `json:"origin_item_id"`
+	// LabelType bawaannya putih (obat dalam)
+	Instruction string `json:"instruction"`
+	LabelType   string `json:"label_type" binding:"omitempty,oneof=putih biru"`
+}
+
+// CreateCompoundRequest adalah satu racikan; harganya dihitung server dari
+// harga jual komponen. Fee mengganti biaya tuslah/embalase bawaan profil
+// apotek bila diisi.
+type CreateCompoundRequest struct {
+	Name       string                   `json:"name" binding:"required"`
+	DosageForm string                   `json:"dosage_form" binding:"required"`
+	Quantity   int                      `json:"quantity" binding:"required,min=1"`
+	Signa      string                   `json:"signa" binding:"required"`
+	Fee        *float64                 `json:"fee" binding:"omitempty,min=0"`
+	Components []CompoundComponentInput `json:"components" binding:"required,min=1,dive"`
+	// LabelType bawaannya biru untuk salep dan krim, selain itu putih
+	Instruction string `json:"instruction"`
+	LabelType   string `json:"label_type" binding:"omitempty,oneof=putih biru"`
+}
+
+// CompoundComponentInput adalah jumlah total satu obat untuk seluruh racikan
+type CompoundComponentInput struct {
+	ProductID uint    `json:"product_id" binding:"required"`
+	Quantity  float64 `json:"quantity" binding:"required,gt=0"`
+}
+
+// productIDs mencakup produk item biasa dan komponen racikan
+func (r *CreatePrescriptionSaleRequest) productIDs() []uint {
+	ids := make([]uint, 0, len(r.Items))
+	for _, item := range r.Items {
+		ids = append(ids, item.ProductID)
+	}
+	for _, compound := range r.Compounds {
+		for _, component := range compound.Components {
+			ids = append(ids, component.ProductID)
+		}
+	}
+	return ids
 }
@@ -0,0 +1,92 @@
+package prescription
+
+import (
+	"fmt"
+	"go-gin-auth/internal/doctor"
+	"go-gin-auth/internal/narcotic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ensurePrescriber memastikan resep yang memuat narkotika/psikotropika
+// bernomor dan ditulis oleh dokter yang terdaftar
+func ensurePrescriber(tx *gorm.DB, req *CreatePrescriptionSaleRequest) error {
+	controlled, err := narcotic.Controlled(tx, req.productIDs())
+	if err != nil {
+		return err
+	}
+	if len(controlled) == 0 {
+		return nil
+	}
+	if req.PrescriptionNo == "" {
+		return fmt.Errorf("%w: nomor resep wajib diisi", narcotic.ErrPrescriptionRequired)
+	}
+	var count int64
+	if err := tx.Model(&doctor.Doctor{}).Where("id = ?", req.DoctorID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("%w: dokter penulis resep tidak ditemukan", narcotic.ErrPrescriptionRequired)
+	}
+	return nil
+}
+
+// dispensedLines menjumlahkan kuantitas stok yang keluar per produk dari
+// item dan komponen racikan penjualan resep saleID
+func dispensedLines(tx *gorm.DB, saleID uint) ([]narcotic.Line, error) {
+	var lines []narcotic.Line
+	err := tx.Raw(`
+		SELECT product_id, SUM(quantity) AS qty_out
+		FROM (
+			SELECT s.product_id, i.quantity
+			FROM prescription_items i
+			JOIN stocks s ON s.id = i.stock_id
+			WHERE i.prescription_sale_id = ? AND i.deleted_at IS NULL
+			UNION ALL
+			SELECT k.product_id, k.stock_quantity
+			FROM prescription_compound_components k
+			JOIN prescription_compounds c ON c.id = k.compound_id
+			WHERE c.prescription_sale_id = ? AND k.deleted_at IS NULL AND c.deleted_at IS NULL
+		) t
+		GROUP BY product_id
+	`, saleID, saleID).Scan(&lines).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect dispensed products: %w", err)
+	}
+	return lines, nil
+}
+
+// recordNarcotics mencatat penyerahan (atau pembatalannya) narkotika dan
+// psikotropika pada penjualan resep ke register beserta pasien dan dokternya
+func recordNarcotics(tx *gorm.DB, saleID uint, movementType string, lines []narcotic.Line, userID uint) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	var sale PrescriptionSale
+	if err := tx.Preload("Patient").Preload("Doctor").First(&sale, saleID).Error; err != nil {
+		return fmt.Errorf("prescription sale not found: %w", err)
+	}
+
+	movement := narcotic.Movement{
+		Type:    movementType,
+		RefType: "prescription_sale",
+		RefID:   sale.ID,
+		RefCode: sale.TransactionCode,
+		Date:    sale.TransactionDate,
+		Party:   decrypted(sale.Patient.FullName),
+		Doctor:  decrypted(sale.Doctor.FullName),
+		Note:    "Resep " + sale.PrescriptionNo,
+		UserID:  userID,
+		Lines:   lines,
+	}
+	if movementType == narcotic.TypeCancellation {
+		// penyerahan yang dibatalkan mengembalikan stok
+		movement.Date = time.Now()
+		movement.Lines = make([]narcotic.Line, len(lines))
+		for i, l := range lines {
+			movement.Lines[i] = narcotic.Line{ProductID: l.ProductID, QtyIn: l.QtyOut}
+		}
+	}
+	return narcotic.Record(tx, movement)
+}
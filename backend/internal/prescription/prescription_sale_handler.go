@@ -2,6 +2,13 @@
 package prescription
 
 import (
+	"errors"
+	"fmt"
+	"go-gin-auth/internal/interaction"
+	"go-gin-auth/internal/payment"
+	"go-gin-auth/internal/receipt"
+	"go-gin-auth/internal/shift"
+	"go-gin-auth/utils"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -54,8 +61,16 @@ func (h *PrescriptionSaleHandler) Create(c *gin.Context) {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
+	req.CashierID = utils.GetCurrentUserID(c)
 
 	sale, err := h.service.Create(&req)
+	if errors.Is(err, shift.ErrNoOpenShift) || errors.Is(err, shift.ErrShiftNotOpen) {
+		c.JSON(409, gin.H{"error": err.Error()})
+		return
+	}
+	if respondBlocked(c, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
@@ -73,7 +88,12 @@ func (h *PrescriptionSaleHandler) Update(c *gin.Context) {
 		return
 	}
 
+	req.CashierID = utils.GetCurrentUserID(c)
+
 	sale, err := h.service.Update(uint(id), &req)
+	if respondBlocked(c, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
@@ -82,14 +102,102 @@ func (h *PrescriptionSaleHandler) Update(c *gin.Context) {
 	c.JSON(200, gin.H{"data": sale})
 }
 
-func (h *PrescriptionSaleHandler) Delete(c *gin.Context) {
+func (h *PrescriptionSaleHandler) Receipt(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	opts, err := receipt.ParseOptions(c.Query("format"), c.Query("paper"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, contentType, err := h.service.PrintReceipt(uint(id), opts, utils.GetCurrentUserID(c))
+	if err != nil {
+		c.JSON(404, gin.H{"error": "Prescription sale not found"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=faktur-resep-%d.%s", id, receipt.Extension(opts.Format)))
+	c.Data(200, contentType, data)
+}
+
+// Remainder menampilkan hak, realisasi dan sisa penyerahan resep beserta iternya
+func (h *PrescriptionSaleHandler) Remainder(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	remainder, err := h.service.Remainder(uint(id))
+	if err != nil {
+		c.JSON(404, gin.H{"error": "Prescription sale not found"})
+		return
+	}
+
+	c.JSON(200, gin.H{"data": remainder})
+}
+
+// Copy mencetak salinan resep selama masih ada bagian yang belum diserahkan
+func (h *PrescriptionSaleHandler) Copy(c *gin.Context) {
 	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
 
-	err := h.service.Delete(uint(id))
+	data, err := h.service.CopyPrescription(uint(id))
+	if errors.Is(err, ErrFullyDispensed) {
+		c.JSON(409, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(404, gin.H{"error": "Prescription sale not found"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=salinan-resep-%d.pdf", id))
+	c.Data(200, "application/pdf", data)
+}
+
+// Labels mencetak etiket (putih/biru) seluruh item dan racikan resep.
+// Query: format (pdf/escpos, bawaannya pdf) dan paper (58/80) untuk escpos
+func (h *PrescriptionSaleHandler) Labels(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+
+	format, err := receipt.ParseLabelFormat(c.Query("format"))
 	if err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
+	opts, err := receipt.ParseOptions("", c.Query("paper"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, contentType, err := h.service.PrintLabels(uint(id), format, opts.Paper)
+	if errors.Is(err, receipt.ErrLabelOverflow) {
+		c.JSON(422, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, payment.ErrSaleVoided) {
+		c.JSON(409, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(404, gin.H{"error": "Prescription sale not found"})
+		return
+	}
 
-	c.JSON(200, gin.H{"message": "Prescription sale deleted successfully"})
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=etiket-%d.%s", id, receipt.Extension(format)))
+	c.Data(200, contentType, data)
+}
+
+// respondBlocked menjawab 409 beserta peringatan bila resep tertahan oleh
+// interaksi berat yang belum diberi alasan dan login apoteker, atau 403 bila
+// login apoteker ditolak
+func respondBlocked(c *gin.Context, err error) bool {
+	if errors.Is(err, interaction.ErrPharmacistRejected) {
+		c.JSON(403, gin.H{"error": err.Error()})
+		return true
+	}
+	var blocked *interaction.BlockedError
+	if !errors.As(err, &blocked) {
+		return false
+	}
+	c.JSON(409, gin.H{"error": err.Error(), "interaction_warnings": blocked.Warnings})
+	return true
 }
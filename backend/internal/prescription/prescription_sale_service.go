@@ -2,10 +2,21 @@ package prescription
 
 import (
 	"fmt"
+	"go-gin-auth/internal/interaction"
+	"go-gin-auth/internal/narcotic"
+	"go-gin-auth/internal/numbering"
+	"go-gin-auth/internal/opname"
+	"go-gin-auth/internal/payment"
+	"go-gin-auth/internal/promotion"
+	"go-gin-auth/internal/receivable"
+	"go-gin-auth/internal/sale_void"
+	"go-gin-auth/internal/shift"
 	"go-gin-auth/internal/stock"
 	"log"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Service
@@ -29,7 +40,7 @@ func (s *PrescriptionSaleService) GetAll(page, limit int) ([]PrescriptionSale, i
 	}
 
 	err = s.db.Preload("Doctor").Preload("Patient").Preload("Shift").
-		Preload("Items").Preload("Items.Stock").
+		Preload("Items").Preload("Items.Stock").Preload("Compounds.Components").Preload("Payments").
 		Offset(offset).Limit(limit).
 		Order("created_at DESC").Find(&sales).Error
 
@@ -62,10 +73,18 @@ func (s *PrescriptionSaleService) GetByID(id uint) (*PrescriptionSale, error) {
 
 	sale.Items = items
 
+	s.db.Preload("Components").Where("prescription_sale_id = ?", id).Find(&sale.Compounds)
+
+	s.db.Where("sale_type = ? AND sale_id = ?", payment.SalePrescription, id).Find(&sale.Payments)
+
 	return &sale, nil
 }
 
 func (s *PrescriptionSaleService) Create(req *CreatePrescriptionSaleRequest) (*PrescriptionSale, error) {
+	if len(req.Items) == 0 && len(req.Compounds) == 0 {
+		return nil, ErrEmptyPrescription
+	}
+
 	tx := s.db.Begin()
 	defer func() {
 		if r := recover(); r != nil {
@@ -73,42 +92,90 @@ func (s *PrescriptionSaleService) Create(req *CreatePrescriptionSaleRequest) (*P
 		}
 	}()
 
+	if err := opname.EnsureNotFrozen(tx, req.productIDs()); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := ensurePrescriber(tx, req); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := applyPrescribed(tx, req, 0); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	warnings, pharmacistID, err := interaction.Guard(tx, req.interactionCheck(0), req.approval())
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	current, err := shift.Current(tx, req.CashierID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := shift.EnsureOpen(tx, current.ID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
 	// Validate stock availability first
 	if err := s.validateStockAvailability(tx, req.Items); err != nil {
 		tx.Rollback()
 		return nil, err
 	}
 
-	// Generate transaction code
-	transactionCode := generateTransactionCode()
+	// Terbitkan nomor transaksi di dalam transaksi agar tidak berlubang
+	transactionCode, err := numbering.Next(tx, numbering.Prescription, req.TransactionDate)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
 
-	// Calculate total
-	var totalAmount float64
-	for _, item := range req.Items {
-		totalAmount += item.Price * float64(item.Quantity)
+	compounds, err := buildCompounds(tx, req.Compounds)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Calculate total with promotions and discount
+	priced, err := calculateTotal(tx, req, compounds, 0)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
 	}
 
-	// Apply discount
-	if req.DiscountPercent > 0 {
-		totalAmount = totalAmount * (1 - req.DiscountPercent/100)
+	payments, paymentMethod, err := payment.Build(req.Payments, req.PaymentMethod, priced.amount)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
 	}
-	totalAmount -= req.DiscountAmount
 
 	// Create prescription sale
 	sale := PrescriptionSale{
-		TransactionCode:  transactionCode,
-		PrescriptionNo:   req.PrescriptionNo,
-		PrescriptionDate: req.PrescriptionDate,
-		DoctorID:         req.DoctorID,
-		Clinic:           req.Clinic,
-		Diagnosis:        req.Diagnosis,
-		PatientID:        req.PatientID,
-		TransactionDate:  req.TransactionDate,
-		PaymentMethod:    req.PaymentMethod,
-		DiscountPercent:  req.DiscountPercent,
-		DiscountAmount:   req.DiscountAmount,
-		TotalAmount:      totalAmount,
-		ShiftID:          req.ShiftID,
+		TransactionCode:   transactionCode,
+		PrescriptionNo:    req.PrescriptionNo,
+		OriginSaleID:      req.OriginSaleID,
+		PrescriptionDate:  req.PrescriptionDate,
+		DoctorID:          req.DoctorID,
+		Clinic:            req.Clinic,
+		Diagnosis:         req.Diagnosis,
+		PatientID:         req.PatientID,
+		TransactionDate:   req.TransactionDate,
+		PaymentMethod:     paymentMethod,
+		DiscountPercent:   req.DiscountPercent,
+		DiscountAmount:    req.DiscountAmount,
+		PromotionDiscount: priced.promo.TotalDiscount,
+		TaxBase:           priced.totals.Base,
+		TaxAmount:         priced.totals.Tax,
+		TotalAmount:       priced.amount,
+		ShiftID:           current.ID,
+		RegisterID:        current.RegisterID,
+		Status:            payment.SaleCompleted,
+		CashierID:         &req.CashierID,
 	}
 
 	if err := tx.Create(&sale).Error; err != nil {
@@ -116,8 +183,17 @@ func (s *PrescriptionSaleService) Create(req *CreatePrescriptionSaleRequest) (*P
 		return nil, fmt.Errorf("failed to create prescription sale: %w", err)
 	}
 
+	if err := payment.Replace(tx, payment.SalePrescription, sale.ID, payments); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to save payments: %w", err)
+	}
+	if err := receivable.Sync(tx, receivableSource(sale.ID, sale.TransactionCode, req), payments); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
 	// Create items and update stock
-	for _, itemReq := range req.Items {
+	for i, itemReq := range req.Items {
 		// Get stock berdasarkan ProductID
 		var stockItem stock.Stock
 		err := tx.Where("product_id = ?", itemReq.ProductID).First(&stockItem).Error
@@ -136,6 +212,17 @@ func (s *PrescriptionSaleService) Create(req *CreatePrescriptionSaleRequest) (*P
 			Unit:               itemReq.Unit,
 			Price:              itemReq.Price,
 			SubTotal:           itemReq.Price * float64(itemReq.Quantity),
+			PromotionID:        priced.promo.Lines[i].PromotionID,
+			Discount:           priced.promo.Lines[i].Discount,
+			TaxRate:            priced.taxes[i].Percent,
+			TaxBase:            priced.taxes[i].Base,
+			TaxAmount:          priced.taxes[i].Tax,
+			PrescribedQuantity: itemReq.PrescribedQuantity,
+			Iter:               itemReq.Iter,
+			Signa:              itemReq.Signa,
+			OriginItemID:       itemReq.OriginItemID,
+			Instruction:        itemReq.Instruction,
+			LabelType:          itemLabelType(itemReq.LabelType),
 		}
 
 		if err := tx.Create(&item).Error; err != nil {
@@ -159,16 +246,47 @@ func (s *PrescriptionSaleService) Create(req *CreatePrescriptionSaleRequest) (*P
 		}
 	}
 
+	if err := saveCompounds(tx, sale.ID, compounds); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	lines, err := dispensedLines(tx, sale.ID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := recordNarcotics(tx, sale.ID, narcotic.TypeDispensing, lines, req.CashierID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := promotion.Record(tx, payment.SalePrescription, sale.ID, req.customerKey(), priced.promo); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to record promotions: %w", err)
+	}
+	if err := interaction.RecordOverride(tx, payment.SalePrescription, sale.ID, req.CashierID, pharmacistID, req.InteractionOverrideReason, warnings); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to record interaction override: %w", err)
+	}
+
 	if err := tx.Commit().Error; err != nil {
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
 	// Return with preloaded data
-	return s.GetByID(sale.ID)
+	created, err := s.GetByID(sale.ID)
+	if err != nil {
+		return nil, err
+	}
+	created.InteractionWarnings = warnings
+	return created, nil
 }
 
 func (s *PrescriptionSaleService) Update(id uint, req *CreatePrescriptionSaleRequest) (*PrescriptionSale, error) {
 	log.Printf("🔄 [Start] Updating prescription sale ID %d", id)
+	if len(req.Items) == 0 && len(req.Compounds) == 0 {
+		return nil, ErrEmptyPrescription
+	}
 
 	// Step 1: Get existing sale with items
 	existingSale, err := s.GetByID(id)
@@ -176,6 +294,9 @@ func (s *PrescriptionSaleService) Update(id uint, req *CreatePrescriptionSaleReq
 		log.Printf("❌ Failed to get existing sale ID %d: %v", id, err)
 		return nil, fmt.Errorf("failed to get existing sale: %w", err)
 	}
+	if existingSale.Status == payment.SaleVoided {
+		return nil, payment.ErrSaleVoided
+	}
 
 	// Step 2: Start transaction
 	tx := s.db.Begin()
@@ -202,7 +323,39 @@ func (s *PrescriptionSaleService) Update(id uint, req *CreatePrescriptionSaleReq
 		productStockChanges[existingStock.ProductID] -= existingItem.Quantity
 	}
 
-	// Step 4: Validate stock availability
+	// Step 4: Validate prescription quantities and stock availability
+	if err := ensureNoFollowUps(tx, id); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := applyPrescribed(tx, req, id); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	warnings, pharmacistID, err := interaction.Guard(tx, req.interactionCheck(id), req.approval())
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	// stok item dan komponen racikan lama ikut dikembalikan sehingga
+	// produknya juga diperiksa
+	previous, err := dispensedLines(tx, id)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	productIDs := req.productIDs()
+	for _, line := range previous {
+		productIDs = append(productIDs, line.ProductID)
+	}
+	if err := opname.EnsureNotFrozen(tx, productIDs); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := ensurePrescriber(tx, req); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
 	for productID, netChange := range productStockChanges {
 		if netChange > 0 {
 			var currentStock stock.Stock
@@ -235,38 +388,75 @@ func (s *PrescriptionSaleService) Update(id uint, req *CreatePrescriptionSaleReq
 		}
 	}
 
-	// Step 7: Calculate total amount
-	var totalAmount float64
-	for _, item := range req.Items {
-		totalAmount += item.Price * float64(item.Quantity)
+	// Step 6b: Restore and remove existing compounds, then rebuild them
+	if err := restoreCompounds(tx, id, true); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := recordNarcotics(tx, id, narcotic.TypeCancellation, previous, req.CashierID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	compounds, err := buildCompounds(tx, req.Compounds)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Step 7: Calculate total amount with promotions and discount
+	priced, err := calculateTotal(tx, req, compounds, id)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
 	}
-	if req.DiscountPercent > 0 {
-		totalAmount *= (1 - req.DiscountPercent/100)
+
+	payments, paymentMethod, err := payment.Build(req.Payments, req.PaymentMethod, priced.amount)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
 	}
-	totalAmount -= req.DiscountAmount
 
 	// Step 8: Update sale main record
 	updates := map[string]interface{}{
-		"prescription_no":   req.PrescriptionNo,
-		"prescription_date": req.PrescriptionDate,
-		"doctor_id":         req.DoctorID,
-		"clinic":            req.Clinic,
-		"diagnosis":         req.Diagnosis,
-		"patient_id":        req.PatientID,
-		"transaction_date":  req.TransactionDate,
-		"payment_method":    req.PaymentMethod,
-		"discount_percent":  req.DiscountPercent,
-		"discount_amount":   req.DiscountAmount,
-		"total_amount":      totalAmount,
-		"shift_id":          req.ShiftID,
+		"prescription_no":    req.PrescriptionNo,
+		"origin_sale_id":     req.OriginSaleID,
+		"prescription_date":  req.PrescriptionDate,
+		"doctor_id":          req.DoctorID,
+		"clinic":             req.Clinic,
+		"diagnosis":          req.Diagnosis,
+		"patient_id":         req.PatientID,
+		"transaction_date":   req.TransactionDate,
+		"payment_method":     paymentMethod,
+		"discount_percent":   req.DiscountPercent,
+		"discount_amount":    req.DiscountAmount,
+		"promotion_discount": priced.promo.TotalDiscount,
+		"tax_base":           priced.totals.Base,
+		"tax_amount":         priced.totals.Tax,
+		"total_amount":       priced.amount,
 	}
 	if err := tx.Model(&existingSale).Updates(updates).Error; err != nil {
 		tx.Rollback()
 		return nil, fmt.Errorf("failed to update prescription sale: %w", err)
 	}
+	if err := payment.Replace(tx, payment.SalePrescription, id, payments); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to save payments: %w", err)
+	}
+	if err := receivable.Sync(tx, receivableSource(id, existingSale.TransactionCode, req), payments); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := promotion.Remove(tx, payment.SalePrescription, id); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to remove promotions: %w", err)
+	}
+	if err := promotion.Record(tx, payment.SalePrescription, id, req.customerKey(), priced.promo); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to record promotions: %w", err)
+	}
 
 	// Step 9: Create new items and update stock
-	for _, itemReq := range req.Items {
+	for i, itemReq := range req.Items {
 		var stockItem stock.Stock
 		if err := tx.Where("product_id = ?", itemReq.ProductID).First(&stockItem).Error; err != nil {
 			tx.Rollback()
@@ -282,6 +472,17 @@ func (s *PrescriptionSaleService) Update(id uint, req *CreatePrescriptionSaleReq
 			Unit:               itemReq.Unit,
 			Price:              itemReq.Price,
 			SubTotal:           itemReq.Price * float64(itemReq.Quantity),
+			PromotionID:        priced.promo.Lines[i].PromotionID,
+			Discount:           priced.promo.Lines[i].Discount,
+			TaxRate:            priced.taxes[i].Percent,
+			TaxBase:            priced.taxes[i].Base,
+			TaxAmount:          priced.taxes[i].Tax,
+			PrescribedQuantity: itemReq.PrescribedQuantity,
+			Iter:               itemReq.Iter,
+			Signa:              itemReq.Signa,
+			OriginItemID:       itemReq.OriginItemID,
+			Instruction:        itemReq.Instruction,
+			LabelType:          itemLabelType(itemReq.LabelType),
 		}
 
 		if err := tx.Create(&item).Error; err != nil {
@@ -315,6 +516,23 @@ func (s *PrescriptionSaleService) Update(id uint, req *CreatePrescriptionSaleReq
 		tx.Rollback()
 		return nil, fmt.Errorf("data integrity error: expected %d items but found %d", len(req.Items), finalItemCount)
 	}
+	if err := saveCompounds(tx, id, compounds); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	current, err := dispensedLines(tx, id)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := recordNarcotics(tx, id, narcotic.TypeDispensing, current, req.CashierID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := interaction.RecordOverride(tx, payment.SalePrescription, id, req.CashierID, pharmacistID, req.InteractionOverrideReason, warnings); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to record interaction override: %w", err)
+	}
 
 	// Step 11: Commit
 	if err := tx.Commit().Error; err != nil {
@@ -322,17 +540,61 @@ func (s *PrescriptionSaleService) Update(id uint, req *CreatePrescriptionSaleReq
 	}
 
 	// Step 12: Return updated sale
-	return s.GetByID(id)
+	updated, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	updated.InteractionWarnings = warnings
+	return updated, nil
 }
 
-func (s *PrescriptionSaleService) Delete(id uint) error {
-	// Get existing sale with items
-	sale, err := s.GetByID(id)
-	if err != nil {
+// VoidInfo mengambil ringkasan penjualan resep untuk pengajuan pembatalan
+func (s *PrescriptionSaleService) VoidInfo(id uint) (*sale_void.Sale, error) {
+	var sale PrescriptionSale
+	if err := s.db.First(&sale, id).Error; err != nil {
+		return nil, err
+	}
+
+	var cashier string
+	if sale.CashierID != nil {
+		s.db.Table("users").Where("id = ?", *sale.CashierID).Pluck("full_name", &cashier)
+	}
+
+	return &sale_void.Sale{
+		Code:        sale.TransactionCode,
+		Amount:      sale.TotalAmount,
+		CashierName: cashier,
+		Voided:      sale.Status == payment.SaleVoided,
+	}, nil
+}
+
+// Void membatalkan penjualan resep yang sudah disetujui supervisor: stok
+// item dan komponen racikan dikembalikan ke batch asalnya, catatan promosi dilepas, piutangnya
+// dibatalkan, dan status diubah menjadi Batal. Item dan pembayaran tetap disimpan sebagai jejak audit.
+// Baris penjualan dikunci agar dua pembatalan tidak mengembalikan stok dua kali.
+func (s *PrescriptionSaleService) Void(tx *gorm.DB, id uint) error {
+	var sale PrescriptionSale
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&sale, id).Error; err != nil {
+		return fmt.Errorf("prescription sale not found: %w", err)
+	}
+	if sale.Status == payment.SaleVoided {
+		return payment.ErrSaleVoided
+	}
+	if err := tx.Where("prescription_sale_id = ?", id).Find(&sale.Items).Error; err != nil {
 		return err
 	}
 
-	tx := s.db.Begin()
+	lines, err := dispensedLines(tx, id)
+	if err != nil {
+		return err
+	}
+	var productIDs []uint
+	for _, l := range lines {
+		productIDs = append(productIDs, l.ProductID)
+	}
+	if err := opname.EnsureNotFrozen(tx, productIDs); err != nil {
+		return err
+	}
 
 	// Restore stock - MENAMBAH stock karena barang dikembalikan
 	for _, item := range sale.Items {
@@ -342,21 +604,59 @@ func (s *PrescriptionSaleService) Delete(id uint) error {
 		}
 	}
 
-	// Delete prescription items first (foreign key constraint)
-	if err := tx.Where("prescription_sale_id = ?", id).Delete(&PrescriptionItem{}).Error; err != nil {
-		return fmt.Errorf("failed to delete prescription items: %w", err)
+	if err := restoreCompounds(tx, id, false); err != nil {
+		return err
+	}
+	if err := recordNarcotics(tx, id, narcotic.TypeCancellation, lines, 0); err != nil {
+		return err
 	}
 
-	// Delete prescription sale
-	if err := tx.Delete(&PrescriptionSale{}, id).Error; err != nil {
-		return fmt.Errorf("failed to delete prescription sale: %w", err)
+	if err := promotion.Remove(tx, payment.SalePrescription, id); err != nil {
+		return fmt.Errorf("failed to release promotions: %w", err)
+	}
+	if err := receivable.Cancel(tx, payment.SalePrescription, id); err != nil {
+		return err
 	}
 
-	if err := tx.Commit().Error; err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	now := time.Now()
+	return tx.Model(&PrescriptionSale{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":    payment.SaleVoided,
+		"voided_at": now,
+	}).Error
+}
+
+// interactionCheck menyiapkan pemeriksaan interaksi untuk seluruh item dan
+// komponen racikan terhadap riwayat pasien dan diagnosis resep
+func (r *CreatePrescriptionSaleRequest) interactionCheck(saleID uint) interaction.CheckRequest {
+	patientID := r.PatientID
+	return interaction.CheckRequest{
+		PatientID:     &patientID,
+		ProductIDs:    r.productIDs(),
+		Diagnosis:     r.Diagnosis,
+		ExcludeSaleID: saleID,
+	}
+}
+
+// approval mengambil alasan dan login apoteker untuk interaksi berat
+func (r *CreatePrescriptionSaleRequest) approval() interaction.Approval {
+	return interaction.Approval{
+		Reason:   r.InteractionOverrideReason,
+		Email:    r.PharmacistEmail,
+		Password: r.PharmacistPassword,
 	}
+}
 
-	return nil
+// receivableSource menyiapkan data piutang penjualan resep; debiturnya
+// pelanggan yang terhubung ke pasien atau penjamin pasien
+func receivableSource(id uint, code string, req *CreatePrescriptionSaleRequest) receivable.Source {
+	patientID := req.PatientID
+	return receivable.Source{
+		SaleType:  payment.SalePrescription,
+		SaleID:    id,
+		SaleCode:  code,
+		Date:      req.TransactionDate,
+		PatientID: &patientID,
+	}
 }
 
 // Helper function to validate stock availability
@@ -0,0 +1,122 @@
+package prescription
+
+import (
+	"fmt"
+	"go-gin-auth/internal/payment"
+	"go-gin-auth/internal/promotion"
+	"go-gin-auth/internal/tax"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// pricing adalah hasil perhitungan total penjualan resep
+type pricing struct {
+	promo  *promotion.Result
+	taxes  []tax.LineTax
+	totals tax.Totals
+	amount float64
+}
+
+// calculateTotal menerapkan promosi aktif per item lalu diskon manual
+// (persen kemudian nominal), menghitung PPN per item dan mengembalikan
+// total yang harus dibayar. Racikan tidak ikut promosi, tetapi ikut diskon
+// manual; PPN racikan dihitung per komponen dan ditulis ke compounds,
+// sedangkan biaya racikan tidak dikenai PPN.
+func calculateTotal(tx *gorm.DB, req *CreatePrescriptionSaleRequest, compounds []PrescriptionCompound, saleID uint) (*pricing, error) {
+	cart := promotion.Cart{
+		CustomerKey: req.customerKey(),
+		SaleType:    payment.SalePrescription,
+		SaleID:      saleID,
+	}
+	var totalAmount float64
+	for _, item := range req.Items {
+		totalAmount += item.Price * float64(item.Quantity)
+		cart.Lines = append(cart.Lines, promotion.Line{
+			ProductID: item.ProductID,
+			Qty:       item.Quantity,
+			UnitPrice: item.Price,
+		})
+	}
+
+	// baris pasien dikunci agar batas promosi per pasien tidak terlewati
+	// oleh dua penjualan resep bersamaan
+	if err := lockPatient(tx, req.PatientID); err != nil {
+		return nil, err
+	}
+	promo, err := promotion.Evaluate(tx, cart, req.TransactionDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate promotions: %w", err)
+	}
+	totalAmount -= promo.TotalDiscount
+	for _, compound := range compounds {
+		totalAmount += compound.SubTotal
+	}
+	afterPromo := totalAmount
+
+	if req.DiscountPercent > 0 {
+		totalAmount = totalAmount * (1 - req.DiscountPercent/100)
+	}
+	totalAmount -= req.DiscountAmount
+	if totalAmount < 0 {
+		return nil, fmt.Errorf("total discount exceeds sale amount")
+	}
+
+	inclusive, err := tax.PricesIncludeTax(tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tax setting: %w", err)
+	}
+	rates, err := tax.ProductRates(tx, req.productIDs())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tax rates: %w", err)
+	}
+
+	lines := make([]tax.Line, len(req.Items))
+	for i, item := range req.Items {
+		lines[i] = tax.Line{
+			Amount:  item.Price*float64(item.Quantity) - promo.Lines[i].Discount,
+			Percent: rates[item.ProductID],
+		}
+	}
+	for _, compound := range compounds {
+		for _, component := range compound.Components {
+			lines = append(lines, tax.Line{Amount: component.SubTotal, Percent: rates[component.ProductID]})
+		}
+		lines = append(lines, tax.Line{Amount: compound.Fee})
+	}
+	taxes, totals := tax.Allocate(lines, afterPromo-totalAmount, inclusive)
+	if !inclusive {
+		totalAmount += totals.Tax
+	}
+
+	// pisahkan pajak racikan dari pajak item biasa
+	next := len(req.Items)
+	for i := range compounds {
+		compound := &compounds[i]
+		compound.TaxBase, compound.TaxAmount = 0, 0
+		for j := range compound.Components {
+			line := taxes[next]
+			next++
+			compound.Components[j].TaxRate = line.Percent
+			compound.Components[j].TaxBase = line.Base
+			compound.Components[j].TaxAmount = line.Tax
+			compound.TaxBase += line.Base
+			compound.TaxAmount += line.Tax
+		}
+		compound.FeeTaxBase = taxes[next].Base
+		compound.TaxBase += taxes[next].Base
+		next++
+	}
+
+	return &pricing{promo: promo, taxes: taxes[:len(req.Items)], totals: totals, amount: totalAmount}, nil
+}
+
+func lockPatient(tx *gorm.DB, patientID uint) error {
+	var id uint
+	return tx.Table("patients").Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", patientID).Select("id").Scan(&id).Error
+}
+
+func (r *CreatePrescriptionSaleRequest) customerKey() string {
+	return fmt.Sprintf("patient:%d", r.PatientID)
+}
@@ -0,0 +1,61 @@
+package prescription
+
+import (
+	"go-gin-auth/internal/payment"
+	"go-gin-auth/internal/receipt"
+)
+
+// PrintReceipt merender faktur penjualan resep dan mencatat riwayat cetaknya
+func (s *PrescriptionSaleService) PrintReceipt(id uint, opts receipt.Options, userID uint) ([]byte, string, error) {
+	sale, err := s.GetByID(id)
+	if err != nil {
+		return nil, "", err
+	}
+	return receipt.Print(s.db, sale.toReceipt(), opts, userID)
+}
+
+func (sale *PrescriptionSale) toReceipt() *receipt.Receipt {
+	r := &receipt.Receipt{
+		SaleType: payment.SalePrescription,
+		SaleID:   sale.ID,
+		Title:    "FAKTUR PENJUALAN RESEP",
+		Number:   sale.TransactionCode,
+		Date:     sale.TransactionDate,
+		Customer: sale.Patient.FullName,
+		Info: []string{
+			"No. Resep: " + sale.PrescriptionNo,
+			"Dokter   : " + sale.Doctor.FullName,
+		},
+		Total:    sale.TotalAmount,
+		Payments: sale.Payments,
+		VoidedAt: sale.VoidedAt,
+
+		TaxBase:   sale.TaxBase,
+		TaxAmount: sale.TaxAmount,
+	}
+	for _, item := range sale.Items {
+		r.Items = append(r.Items, receipt.Item{
+			Name:     item.ItemName,
+			Qty:      item.Quantity,
+			Unit:     item.Unit,
+			Price:    item.Price,
+			SubTotal: item.SubTotal,
+		})
+		r.SubTotal += item.SubTotal
+	}
+	for _, compound := range sale.Compounds {
+		r.Items = append(r.Items, receipt.Item{
+			Name:     "R/ " + compound.Name,
+			Qty:      compound.Quantity,
+			Unit:     compound.DosageForm,
+			Price:    compound.Price,
+			SubTotal: compound.SubTotal,
+		})
+		r.SubTotal += compound.SubTotal
+	}
+	r.Discount = r.SubTotal - r.Total
+	if len(r.Payments) == 0 {
+		r.Payments = []payment.Payment{{Method: sale.PaymentMethod, Amount: r.Total}}
+	}
+	return r
+}
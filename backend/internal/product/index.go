@@ -0,0 +1,175 @@
+package product
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// Index menyimpan data produk di memori agar pencarian kasir (scan barcode)
+// tidak perlu query ke database. Indeks diperbarui setiap kali produk
+// dibuat, diubah, atau dihapus lewat ProductRepository.
+type Index struct {
+	mu        sync.RWMutex
+	loadMu    sync.Mutex
+	loaded    bool
+	byID      map[uint]Product
+	byBarcode map[string]uint
+	byCode    map[string]uint
+	names     []indexedName
+}
+
+type indexedName struct {
+	name string
+	id   uint
+}
+
+var defaultIndex = &Index{}
+
+// ProductIndex mengembalikan indeks produk bersama milik aplikasi
+func ProductIndex() *Index {
+	return defaultIndex
+}
+
+// Load membaca ulang seluruh produk aktif dari database
+func (i *Index) Load(db *gorm.DB) error {
+	var products []Product
+	if err := db.Find(&products).Error; err != nil {
+		return err
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.byID = make(map[uint]Product, len(products))
+	i.byBarcode = make(map[string]uint, len(products))
+	i.byCode = make(map[string]uint, len(products))
+	i.names = i.names[:0]
+	for _, p := range products {
+		i.putLocked(p)
+	}
+	i.sortNamesLocked()
+	i.loaded = true
+	return nil
+}
+
+// Put menambahkan atau memperbarui satu produk pada indeks
+func (i *Index) Put(p Product) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.loaded {
+		return
+	}
+	i.removeLocked(p.ID)
+	i.putLocked(p)
+	i.sortNamesLocked()
+}
+
+// Remove menghapus produk dari indeks
+func (i *Index) Remove(id uint) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.loaded {
+		return
+	}
+	i.removeLocked(id)
+}
+
+// Lookup mencari produk berdasarkan barcode atau kode yang persis sama,
+// lalu berdasarkan awalan nama bila tidak ada yang cocok
+func (i *Index) Lookup(db *gorm.DB, query string, limit int) ([]Product, error) {
+	if err := i.ensureLoaded(db); err != nil {
+		return nil, err
+	}
+
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, nil
+	}
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	if id, ok := i.byBarcode[query]; ok {
+		return []Product{i.byID[id]}, nil
+	}
+	if id, ok := i.byCode[strings.ToLower(query)]; ok {
+		return []Product{i.byID[id]}, nil
+	}
+
+	prefix := strings.ToLower(query)
+	start := sort.Search(len(i.names), func(n int) bool {
+		return i.names[n].name >= prefix
+	})
+
+	var result []Product
+	for n := start; n < len(i.names) && len(result) < limit; n++ {
+		if !strings.HasPrefix(i.names[n].name, prefix) {
+			break
+		}
+		result = append(result, i.byID[i.names[n].id])
+	}
+	return result, nil
+}
+
+// ensureLoaded memuat indeks pada permintaan pertama. loadMu memastikan
+// permintaan pertama yang bersamaan hanya memuat satu kali.
+func (i *Index) ensureLoaded(db *gorm.DB) error {
+	if i.isLoaded() {
+		return nil
+	}
+
+	i.loadMu.Lock()
+	defer i.loadMu.Unlock()
+	if i.isLoaded() {
+		return nil
+	}
+	return i.Load(db)
+}
+
+func (i *Index) isLoaded() bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.loaded
+}
+
+func (i *Index) putLocked(p Product) {
+	i.byID[p.ID] = p
+	if p.Barcode != "" {
+		i.byBarcode[p.Barcode] = p.ID
+	}
+	if p.Code != "" {
+		i.byCode[strings.ToLower(p.Code)] = p.ID
+	}
+	i.names = append(i.names, indexedName{name: strings.ToLower(p.Name), id: p.ID})
+}
+
+func (i *Index) removeLocked(id uint) {
+	old, ok := i.byID[id]
+	if !ok {
+		return
+	}
+	delete(i.byID, id)
+	if i.byBarcode[old.Barcode] == id {
+		delete(i.byBarcode, old.Barcode)
+	}
+	if i.byCode[strings.ToLower(old.Code)] == id {
+		delete(i.byCode, strings.ToLower(old.Code))
+	}
+	for n := range i.names {
+		if i.names[n].id == id {
+			i.names = append(i.names[:n], i.names[n+1:]...)
+			break
+		}
+	}
+}
+
+func (i *Index) sortNamesLocked() {
+	sort.Slice(i.names, func(a, b int) bool {
+		return i.names[a].name < i.names[b].name
+	})
+}
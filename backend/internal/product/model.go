@@ -36,4 +36,5 @@ type Product struct {
 	DrugCategoryID         uint                            `gorm:"not null;comment:ID Kategori Obat;default:1" json:"drug_category_id" form:"drug_category_id"`
 	DrugCategory           drug_category.DrugCategory      `gorm:"-" json:"drug_category"`
 	MinStock               int                             `gorm:"not null;default:0;comment:Stok Minimum" json:"min_stock" form:"min_stock"`
+	TaxRateID              *uint                           `gorm:"comment:ID Tarif Pajak, kosong berarti mengikuti kategori" json:"tax_rate_id" form:"tax_rate_id"`
 }
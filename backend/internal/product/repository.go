@@ -107,6 +107,7 @@ func (r *ProductRepository) CreateProduct(product Product) (Product, error) {
 	if err != nil {
 		return product, err
 	}
+	ProductIndex().Put(product)
 
 	var category category.Category
 	if err := r.db.First(&category, product.CategoryID).Error; err == nil {
@@ -132,6 +133,7 @@ func (r *ProductRepository) UpdateProduct(id uint, product Product) (Product, er
 	if err != nil {
 		return product, errors.New("failed to update product")
 	}
+	ProductIndex().Put(existingProduct)
 
 	var category category.Category
 	if err := r.db.First(&category, existingProduct.CategoryID).Error; err == nil {
@@ -150,5 +152,6 @@ func (r *ProductRepository) DeleteProduct(id uint) error {
 	if err != nil {
 		return errors.New("failed to delete product")
 	}
+	ProductIndex().Remove(id)
 	return nil
 }
@@ -0,0 +1,170 @@
+package promotion
+
+import (
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Evaluate menerapkan promosi aktif pada keranjang. Setiap baris hanya
+// mendapat satu promosi, yaitu yang potongannya paling besar.
+func Evaluate(db *gorm.DB, cart Cart, at time.Time) (*Result, error) {
+	result := &Result{Lines: make([]LineResult, len(cart.Lines))}
+	for i, l := range cart.Lines {
+		result.Lines[i].ProductID = l.ProductID
+	}
+	if len(cart.Lines) == 0 {
+		return result, nil
+	}
+
+	var promotions []Promotion
+	if err := db.Where("active = ? AND start_date <= ? AND end_date >= ?", true, at, at).
+		Find(&promotions).Error; err != nil {
+		return nil, err
+	}
+	if len(promotions) == 0 {
+		return result, nil
+	}
+
+	attrs, err := productAttributes(db, cart.Lines)
+	if err != nil {
+		return nil, err
+	}
+
+	var subTotal float64
+	for _, l := range cart.Lines {
+		subTotal += float64(l.Qty) * l.UnitPrice
+	}
+
+	eligible := make([]Promotion, 0, len(promotions))
+	for _, p := range promotions {
+		if !p.withinHours(at) || subTotal < p.MinPurchase {
+			continue
+		}
+		if p.PerCustomerLimit > 0 {
+			// Batas per pelanggan tidak bisa diperiksa untuk pembeli anonim
+			if cart.CustomerKey == "" {
+				continue
+			}
+			used, err := usageCount(db, p.ID, cart)
+			if err != nil {
+				return nil, err
+			}
+			if used >= int64(p.PerCustomerLimit) {
+				continue
+			}
+		}
+		eligible = append(eligible, p)
+	}
+
+	for i, l := range cart.Lines {
+		attr := attrs[l.ProductID]
+		for _, p := range eligible {
+			if !p.matches(l.ProductID, attr) {
+				continue
+			}
+			discount := p.discount(l.Qty, l.UnitPrice)
+			if discount > result.Lines[i].Discount {
+				id := p.ID
+				result.Lines[i].PromotionID = &id
+				result.Lines[i].PromotionCode = p.Code
+				result.Lines[i].Discount = discount
+			}
+		}
+		result.TotalDiscount += result.Lines[i].Discount
+	}
+
+	return result, nil
+}
+
+type productAttribute struct {
+	ID         uint
+	CategoryID uint
+	BrandID    uint
+}
+
+func productAttributes(db *gorm.DB, lines []Line) (map[uint]productAttribute, error) {
+	ids := make([]uint, 0, len(lines))
+	for _, l := range lines {
+		ids = append(ids, l.ProductID)
+	}
+
+	var rows []productAttribute
+	if err := db.Table("products").Select("id, category_id, brand_id").
+		Where("id IN ?", ids).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	attrs := make(map[uint]productAttribute, len(rows))
+	for _, r := range rows {
+		attrs[r.ID] = r
+	}
+	return attrs, nil
+}
+
+func usageCount(db *gorm.DB, promotionID uint, cart Cart) (int64, error) {
+	var count int64
+	err := db.Raw(`
+		SELECT COUNT(DISTINCT sale_type || '-' || sale_id)
+		FROM promotion_applications
+		WHERE promotion_id = ? AND customer_key = ? AND deleted_at IS NULL
+		AND NOT (sale_type = ? AND sale_id = ?)
+	`, promotionID, cart.CustomerKey, cart.SaleType, cart.SaleID).Scan(&count).Error
+	return count, err
+}
+
+func (p *Promotion) matches(productID uint, attr productAttribute) bool {
+	if p.Scope == ScopeAll {
+		return true
+	}
+	if p.TargetID == nil {
+		return false
+	}
+	switch p.Scope {
+	case ScopeProduct:
+		return productID == *p.TargetID
+	case ScopeCategory:
+		return attr.CategoryID == *p.TargetID
+	case ScopeBrand:
+		return attr.BrandID == *p.TargetID
+	}
+	return false
+}
+
+// discount menghitung potongan untuk qty unit dengan harga satuan price
+func (p *Promotion) discount(qty int, price float64) float64 {
+	gross := float64(qty) * price
+	var d float64
+	switch p.Type {
+	case TypePercentage:
+		d = gross * p.Value / 100
+	case TypeFixed:
+		d = math.Min(p.Value, price) * float64(qty)
+	case TypeBuyXGetY:
+		if group := p.BuyQty + p.GetQty; p.BuyQty > 0 && p.GetQty > 0 {
+			d = float64(qty/group*p.GetQty) * price
+		}
+	case TypeBundle:
+		if p.BundleQty > 0 {
+			bundles := qty / p.BundleQty
+			d = float64(bundles) * (float64(p.BundleQty)*price - p.Value)
+		}
+	}
+	if d < 0 {
+		return 0
+	}
+	return math.Round(math.Min(d, gross)*100) / 100
+}
+
+// withinHours memeriksa jam berlaku harian; rentang boleh melewati tengah malam
+func (p *Promotion) withinHours(at time.Time) bool {
+	if p.StartTime == "" || p.EndTime == "" {
+		return true
+	}
+	now := at.Format("15:04")
+	if p.StartTime <= p.EndTime {
+		return now >= p.StartTime && now <= p.EndTime
+	}
+	return now >= p.StartTime || now <= p.EndTime
+}
@@ -0,0 +1,69 @@
+package promotion
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPromotionDiscount(t *testing.T) {
+	tests := []struct {
+		name  string
+		promo Promotion
+		qty   int
+		price float64
+		want  float64
+	}{
+		{"persen", Promotion{Type: TypePercentage, Value: 10}, 3, 15000, 4500},
+		{"persen dibulatkan ke sen", Promotion{Type: TypePercentage, Value: 12.5}, 1, 999.99, 125},
+		{"persen melebihi 100 dibatasi harga", Promotion{Type: TypePercentage, Value: 150}, 2, 1000, 2000},
+		{"nominal per unit", Promotion{Type: TypeFixed, Value: 2000}, 3, 10000, 6000},
+		{"nominal melebihi harga", Promotion{Type: TypeFixed, Value: 15000}, 2, 10000, 20000},
+		{"beli 2 gratis 1", Promotion{Type: TypeBuyXGetY, BuyQty: 2, GetQty: 1}, 7, 5000, 10000},
+		{"beli 2 gratis 1 belum cukup", Promotion{Type: TypeBuyXGetY, BuyQty: 2, GetQty: 1}, 2, 5000, 0},
+		{"beli X gratis Y tanpa konfigurasi", Promotion{Type: TypeBuyXGetY}, 5, 5000, 0},
+		{"paket 3 seharga 25000", Promotion{Type: TypeBundle, BundleQty: 3, Value: 25000}, 7, 10000, 10000},
+		{"paket lebih mahal dari harga satuan", Promotion{Type: TypeBundle, BundleQty: 2, Value: 25000}, 2, 10000, 0},
+		{"paket tanpa jumlah", Promotion{Type: TypeBundle, Value: 25000}, 4, 10000, 0},
+		{"tipe tidak dikenal", Promotion{Type: "cashback", Value: 5000}, 1, 10000, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.promo.discount(tt.qty, tt.price); got != tt.want {
+				t.Errorf("discount() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPromotionWithinHours(t *testing.T) {
+	at := func(hour, min int) time.Time {
+		return time.Date(2026, time.March, 7, hour, min, 0, 0, time.Local)
+	}
+	tests := []struct {
+		name       string
+		start, end string
+		at         time.Time
+		want       bool
+	}{
+		{"tanpa batas jam", "", "", at(3, 0), true},
+		{"hanya jam mulai", "08:00", "", at(3, 0), true},
+		{"di dalam rentang", "08:00", "12:00", at(10, 15), true},
+		{"tepat jam mulai", "08:00", "12:00", at(8, 0), true},
+		{"tepat jam selesai", "08:00", "12:00", at(12, 0), true},
+		{"sebelum rentang", "08:00", "12:00", at(7, 59), false},
+		{"setelah rentang", "08:00", "12:00", at(12, 1), false},
+		{"melewati tengah malam, malam hari", "22:00", "02:00", at(23, 30), true},
+		{"melewati tengah malam, dini hari", "22:00", "02:00", at(1, 45), true},
+		{"melewati tengah malam, siang hari", "22:00", "02:00", at(12, 0), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Promotion{StartTime: tt.start, EndTime: tt.end}
+			if got := p.withinHours(tt.at); got != tt.want {
+				t.Errorf("withinHours() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
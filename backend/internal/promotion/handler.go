@@ -0,0 +1,107 @@
+package promotion
+
+import (
+	"errors"
+	"go-gin-auth/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) GetAll(c *gin.Context) {
+	promotions, err := h.service.GetAll(c.Query("active") == "true")
+	if err != nil {
+		utils.Respond(c, http.StatusInternalServerError, "Gagal mengambil daftar promosi", err.Error(), nil)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Daftar promosi berhasil diambil", nil, promotions)
+}
+
+func (h *Handler) GetByID(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	p, err := h.service.GetByID(uint(id))
+	if err != nil {
+		h.respondError(c, "Gagal mengambil data promosi", err)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Detail promosi berhasil diambil", nil, p)
+}
+
+func (h *Handler) Create(c *gin.Context) {
+	var input Promotion
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Respond(c, http.StatusBadRequest, "Input tidak valid", err.Error(), nil)
+		return
+	}
+	input.CreatedBy = utils.GetCurrentUserID(c)
+	input.UpdatedBy = input.CreatedBy
+
+	p, err := h.service.Create(&input)
+	if err != nil {
+		h.respondError(c, "Gagal membuat promosi", err)
+		return
+	}
+	utils.Respond(c, http.StatusCreated, "Promosi berhasil dibuat", nil, p)
+}
+
+func (h *Handler) Update(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var input Promotion
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Respond(c, http.StatusBadRequest, "Input tidak valid", err.Error(), nil)
+		return
+	}
+	input.UpdatedBy = utils.GetCurrentUserID(c)
+
+	p, err := h.service.Update(uint(id), &input)
+	if err != nil {
+		h.respondError(c, "Gagal memperbarui promosi", err)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Promosi berhasil diperbarui", nil, p)
+}
+
+func (h *Handler) Delete(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err := h.service.Delete(uint(id)); err != nil {
+		h.respondError(c, "Gagal menghapus promosi", err)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Promosi berhasil dihapus", nil, nil)
+}
+
+// Evaluate menghitung potongan promosi untuk keranjang tanpa menyimpan apa pun
+func (h *Handler) Evaluate(c *gin.Context) {
+	var cart Cart
+	if err := c.ShouldBindJSON(&cart); err != nil {
+		utils.Respond(c, http.StatusBadRequest, "Input tidak valid", err.Error(), nil)
+		return
+	}
+
+	result, err := h.service.Evaluate(cart)
+	if err != nil {
+		utils.Respond(c, http.StatusInternalServerError, "Gagal menghitung promosi", err.Error(), nil)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Promosi berhasil dihitung", nil, result)
+}
+
+func (h *Handler) respondError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		utils.Respond(c, http.StatusNotFound, err.Error(), err.Error(), nil)
+	case errors.Is(err, ErrInvalidInput):
+		utils.Respond(c, http.StatusBadRequest, err.Error(), err.Error(), nil)
+	default:
+		utils.Respond(c, http.StatusInternalServerError, message, err.Error(), nil)
+	}
+}
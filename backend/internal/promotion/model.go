@@ -0,0 +1,112 @@
+package promotion
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Jenis promosi
+const (
+	TypePercentage = "percentage"  // potongan persen dari harga
+	TypeFixed      = "fixed"       // potongan nominal per unit
+	TypeBuyXGetY   = "buy_x_get_y" // beli X gratis Y unit barang yang sama
+	TypeBundle     = "bundle"      // harga paket untuk sejumlah unit
+)
+
+// Cakupan barang yang mendapat promosi
+const (
+	ScopeAll      = "all"
+	ScopeProduct  = "product"
+	ScopeCategory = "category"
+	ScopeBrand    = "brand"
+)
+
+type Promotion struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	Code        string `gorm:"type:varchar(50);uniqueIndex;not null" json:"code" form:"code"`
+	Name        string `gorm:"type:varchar(255);not null" json:"name" form:"name"`
+	Description string `gorm:"type:text" json:"description,omitempty" form:"description"`
+	Type        string `gorm:"type:varchar(20);not null" json:"type" form:"type"`
+	Scope       string `gorm:"type:varchar(20);not null;default:'all'" json:"scope" form:"scope"`
+	// TargetID berisi ID produk, kategori atau brand sesuai Scope
+	TargetID *uint `json:"target_id,omitempty" form:"target_id"`
+	// Value adalah persen (percentage), potongan per unit (fixed) atau
+	// harga paket (bundle)
+	Value     float64 `gorm:"type:decimal(15,2);not null;default:0" json:"value" form:"value"`
+	BuyQty    int     `gorm:"not null;default:0" json:"buy_qty" form:"buy_qty"`
+	GetQty    int     `gorm:"not null;default:0" json:"get_qty" form:"get_qty"`
+	BundleQty int     `gorm:"not null;default:0" json:"bundle_qty" form:"bundle_qty"`
+	// MinPurchase adalah subtotal belanja minimum agar promosi berlaku
+	MinPurchase float64   `gorm:"type:decimal(15,2);not null;default:0" json:"min_purchase" form:"min_purchase"`
+	StartDate   time.Time `gorm:"not null" json:"start_date" form:"start_date"`
+	EndDate     time.Time `gorm:"not null" json:"end_date" form:"end_date"`
+	// StartTime dan EndTime (format 15:04) membatasi jam berlaku setiap hari
+	StartTime string `gorm:"type:varchar(5)" json:"start_time,omitempty" form:"start_time"`
+	EndTime   string `gorm:"type:varchar(5)" json:"end_time,omitempty" form:"end_time"`
+	// PerCustomerLimit adalah batas pemakaian per pelanggan, 0 berarti tanpa batas
+	PerCustomerLimit int            `gorm:"not null;default:0" json:"per_customer_limit" form:"per_customer_limit"`
+	Active           bool           `gorm:"not null;default:true" json:"active" form:"active"`
+	CreatedBy        uint           `json:"created_by"`
+	UpdatedBy        uint           `json:"updated_by"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// Application mencatat promosi yang diterapkan pada satu baris penjualan
+type Application struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	PromotionID uint           `gorm:"not null;index" json:"promotion_id"`
+	SaleType    string         `gorm:"type:varchar(20);not null;index:idx_promotion_sale" json:"sale_type"`
+	SaleID      uint           `gorm:"not null;index:idx_promotion_sale" json:"sale_id"`
+	ProductID   uint           `gorm:"not null" json:"product_id"`
+	CustomerKey string         `gorm:"type:varchar(255);index" json:"customer_key,omitempty"`
+	Discount    float64        `gorm:"type:decimal(15,2);not null" json:"discount"`
+	CreatedAt   time.Time      `json:"created_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+func (Application) TableName() string {
+	return "promotion_applications"
+}
+
+// Line adalah satu baris keranjang yang dievaluasi
+type Line struct {
+	ProductID uint    `json:"product_id" binding:"required"`
+	Qty       int     `json:"qty" binding:"required,min=1"`
+	UnitPrice float64 `json:"unit_price" binding:"min=0"`
+}
+
+// Cart adalah keranjang belanja yang dievaluasi terhadap promosi aktif.
+// CustomerKey mengidentifikasi pelanggan untuk batas pemakaian per pelanggan.
+// SaleType dan SaleID diisi saat mengubah penjualan agar pemakaian promosi
+// oleh penjualan itu sendiri tidak ikut dihitung.
+type Cart struct {
+	CustomerKey string `json:"customer_key"`
+	Lines       []Line `json:"lines" binding:"required,min=1,dive"`
+	SaleType    string `json:"-"`
+	SaleID      uint   `json:"-"`
+}
+
+// LineResult adalah hasil evaluasi satu baris, urutannya sama dengan Cart.Lines
+type LineResult struct {
+	ProductID     uint    `json:"product_id"`
+	PromotionID   *uint   `json:"promotion_id,omitempty"`
+	PromotionCode string  `json:"promotion_code,omitempty"`
+	Discount      float64 `json:"discount"`
+}
+
+type Result struct {
+	Lines         []LineResult `json:"lines"`
+	TotalDiscount float64      `json:"total_discount"`
+}
+
+// Cost adalah rekap biaya promosi untuk analitik
+type Cost struct {
+	PromotionID  uint    `json:"promotion_id"`
+	Code         string  `json:"code"`
+	Name         string  `json:"name"`
+	Transactions int     `json:"transactions"`
+	Discount     float64 `json:"discount"`
+}
@@ -0,0 +1,24 @@
+package promotion
+
+import (
+	"go-gin-auth/config"
+	"go-gin-auth/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func PromotionRouter(api *gin.RouterGroup) {
+	service := NewService(config.DB)
+	handler := NewHandler(service)
+
+	promotions := api.Group("/promotions")
+	{
+		promotions.POST("/evaluate", middleware.AuthMiddleware(), handler.Evaluate)
+
+		promotions.GET("", middleware.AuthAdminMiddleware(), handler.GetAll)
+		promotions.GET("/:id", middleware.AuthAdminMiddleware(), handler.GetByID)
+		promotions.POST("", middleware.AuthAdminMiddleware(), handler.Create)
+		promotions.PUT("/:id", middleware.AuthAdminMiddleware(), handler.Update)
+		promotions.DELETE("/:id", middleware.AuthAdminMiddleware(), handler.Delete)
+	}
+}
@@ -0,0 +1,189 @@
+package promotion
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotFound     = errors.New("promosi tidak ditemukan")
+	ErrInvalidInput = errors.New("data promosi tidak valid")
+)
+
+type Service struct {
+	db *gorm.DB
+}
+
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db}
+}
+
+func (s *Service) GetAll(activeOnly bool) ([]Promotion, error) {
+	query := s.db.Order("start_date DESC")
+	if activeOnly {
+		now := time.Now()
+		query = query.Where("active = ? AND start_date <= ? AND end_date >= ?", true, now, now)
+	}
+
+	var promotions []Promotion
+	if err := query.Find(&promotions).Error; err != nil {
+		return nil, err
+	}
+	return promotions, nil
+}
+
+func (s *Service) GetByID(id uint) (*Promotion, error) {
+	var p Promotion
+	if err := s.db.First(&p, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (s *Service) Create(p *Promotion) (*Promotion, error) {
+	if err := validate(p); err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(p).Error; err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (s *Service) Update(id uint, input *Promotion) (*Promotion, error) {
+	existing, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := validate(input); err != nil {
+		return nil, err
+	}
+
+	input.ID = existing.ID
+	input.CreatedBy = existing.CreatedBy
+	input.CreatedAt = existing.CreatedAt
+	if err := s.db.Save(input).Error; err != nil {
+		return nil, err
+	}
+	return input, nil
+}
+
+func (s *Service) Delete(id uint) error {
+	result := s.db.Delete(&Promotion{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Evaluate menghitung potongan promosi untuk pratinjau di layar kasir
+func (s *Service) Evaluate(cart Cart) (*Result, error) {
+	return Evaluate(s.db, cart, time.Now())
+}
+
+// Record menyimpan promosi yang diterapkan pada sebuah penjualan
+func Record(tx *gorm.DB, saleType string, saleID uint, customerKey string, result *Result) error {
+	var apps []Application
+	for _, l := range result.Lines {
+		if l.PromotionID == nil || l.Discount <= 0 {
+			continue
+		}
+		apps = append(apps, Application{
+			PromotionID: *l.PromotionID,
+			SaleType:    saleType,
+			SaleID:      saleID,
+			ProductID:   l.ProductID,
+			CustomerKey: customerKey,
+			Discount:    l.Discount,
+		})
+	}
+	if len(apps) == 0 {
+		return nil
+	}
+	return tx.Create(&apps).Error
+}
+
+// Remove menghapus catatan promosi milik penjualan, misalnya saat diubah atau dihapus
+func Remove(tx *gorm.DB, saleType string, saleID uint) error {
+	return tx.Where("sale_type = ? AND sale_id = ?", saleType, saleID).Delete(&Application{}).Error
+}
+
+// CostByPeriod merekap biaya promosi per promosi pada rentang tanggal transaksi
+func CostByPeriod(db *gorm.DB, start, end time.Time) ([]Cost, error) {
+	var costs []Cost
+	err := db.Raw(`
+		SELECT p.id AS promotion_id, p.code, p.name,
+			COUNT(DISTINCT a.sale_type || '-' || a.sale_id) AS transactions,
+			SUM(a.discount) AS discount
+		FROM promotion_applications a
+		JOIN promotions p ON p.id = a.promotion_id
+		LEFT JOIN sales_regulars sr ON a.sale_type = 'regular' AND sr.id = a.sale_id
+		LEFT JOIN prescription_sales ps ON a.sale_type = 'prescription' AND ps.id = a.sale_id
+		WHERE a.deleted_at IS NULL
+		AND COALESCE(sr.transaction_date, ps.transaction_date) >= ?
+		AND COALESCE(sr.transaction_date, ps.transaction_date) <= ?
+		GROUP BY p.id, p.code, p.name
+		ORDER BY discount DESC
+	`, start, end).Scan(&costs).Error
+	return costs, err
+}
+
+func validate(p *Promotion) error {
+	if p.Code == "" || p.Name == "" {
+		return fmt.Errorf("%w: kode dan nama wajib diisi", ErrInvalidInput)
+	}
+	if p.EndDate.Before(p.StartDate) {
+		return fmt.Errorf("%w: tanggal berakhir sebelum tanggal mulai", ErrInvalidInput)
+	}
+	if (p.StartTime == "") != (p.EndTime == "") {
+		return fmt.Errorf("%w: jam mulai dan jam berakhir harus diisi bersamaan", ErrInvalidInput)
+	}
+	for _, t := range []string{p.StartTime, p.EndTime} {
+		if _, err := time.Parse("15:04", t); t != "" && err != nil {
+			return fmt.Errorf("%w: format jam harus HH:MM", ErrInvalidInput)
+		}
+	}
+
+	switch p.Scope {
+	case "":
+		p.Scope = ScopeAll
+	case ScopeAll:
+	case ScopeProduct, ScopeCategory, ScopeBrand:
+		if p.TargetID == nil {
+			return fmt.Errorf("%w: target_id wajib diisi untuk cakupan %s", ErrInvalidInput, p.Scope)
+		}
+	default:
+		return fmt.Errorf("%w: cakupan %q tidak dikenal", ErrInvalidInput, p.Scope)
+	}
+
+	switch p.Type {
+	case TypePercentage:
+		if p.Value <= 0 || p.Value > 100 {
+			return fmt.Errorf("%w: persentase harus antara 0 dan 100", ErrInvalidInput)
+		}
+	case TypeFixed:
+		if p.Value <= 0 {
+			return fmt.Errorf("%w: nilai potongan harus lebih dari 0", ErrInvalidInput)
+		}
+	case TypeBuyXGetY:
+		if p.BuyQty <= 0 || p.GetQty <= 0 {
+			return fmt.Errorf("%w: buy_qty dan get_qty harus lebih dari 0", ErrInvalidInput)
+		}
+	case TypeBundle:
+		if p.BundleQty <= 1 || p.Value <= 0 {
+			return fmt.Errorf("%w: bundle_qty minimal 2 dan harga paket harus diisi", ErrInvalidInput)
+		}
+	default:
+		return fmt.Errorf("%w: jenis promosi %q tidak dikenal", ErrInvalidInput, p.Type)
+	}
+	return nil
+}
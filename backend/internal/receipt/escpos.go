@@ -0,0 +1,67 @@
+package receipt
+
+import (
+	"bytes"
+	"go-gin-auth/internal/pharmacy"
+)
+
+// Perintah ESC/POS yang dipakai
+var (
+	escInit        = []byte{0x1b, 0x40}
+	escAlignLeft   = []byte{0x1b, 0x61, 0x00}
+	escAlignCenter = []byte{0x1b, 0x61, 0x01}
+	escBoldOn      = []byte{0x1b, 0x45, 0x01}
+	escBoldOff     = []byte{0x1b, 0x45, 0x00}
+	gsSizeDouble   = []byte{0x1d, 0x21, 0x11}
+	gsSizeNormal   = []byte{0x1d, 0x21, 0x00}
+	gsCutPartial   = []byte{0x1d, 0x56, 0x42, 0x03}
+)
+
+// RenderESCPOS menghasilkan byte mentah ESC/POS untuk printer thermal 58mm/80mm
+func RenderESCPOS(r *Receipt, profile pharmacy.Profile, paper int) []byte {
+	width := columns(paper)
+
+	var b bytes.Buffer
+	b.Write(escInit)
+	for _, l := range thermalLines(r, profile, width) {
+		if l.align == alignCenter {
+			b.Write(escAlignCenter)
+		} else {
+			b.Write(escAlignLeft)
+		}
+		if l.bold {
+			b.Write(escBoldOn)
+		}
+		if l.large {
+			b.Write(gsSizeDouble)
+		}
+
+		b.WriteString(asciiOnly(l.text))
+		b.WriteByte('\n')
+
+		if l.large {
+			b.Write(gsSizeNormal)
+		}
+		if l.bold {
+			b.Write(escBoldOff)
+		}
+	}
+	b.Write(escAlignLeft)
+	b.WriteString("\n\n\n")
+	b.Write(gsCutPartial)
+	return b.Bytes()
+}
+
+// asciiOnly mengganti karakter di luar ASCII karena code page printer
+// thermal berbeda-beda
+func asciiOnly(s string) string {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r < 32 || r > 126 {
+			out = append(out, '?')
+			continue
+		}
+		out = append(out, byte(r))
+	}
+	return string(out)
+}
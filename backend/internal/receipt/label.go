@@ -0,0 +1,202 @@
+package receipt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go-gin-auth/internal/pharmacy"
+	"go-gin-auth/pkg/pdf"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Jenis etiket obat
+const (
+	LabelWhite = "putih" // obat dalam
+	LabelBlue  = "biru"  // obat luar
+)
+
+// Ukuran etiket PDF dalam milimeter, sesuai kertas label 70 x 45 mm
+const (
+	LabelWidthMM  = 70
+	LabelHeightMM = 45
+)
+
+var (
+	ErrUnknownLabelFormat = errors.New("format etiket harus pdf atau escpos")
+	// ErrLabelOverflow dikembalikan bila isi etiket terlalu panjang untuk
+	// satu kertas label
+	ErrLabelOverflow = errors.New("isi etiket tidak muat pada satu label, persingkat signa atau aturan pakai")
+)
+
+// Label adalah satu etiket untuk item atau racikan pada resep
+type Label struct {
+	Type        string
+	Number      int
+	Code        string
+	Date        time.Time
+	Patient     string
+	Signa       string
+	Instruction string
+	Product     string
+	Quantity    string
+	Expiry      *time.Time
+}
+
+// ParseLabelFormat membaca query format etiket; bawaannya PDF
+func ParseLabelFormat(format string) (string, error) {
+	switch format {
+	case "":
+		return FormatPDF, nil
+	case FormatPDF, FormatESCPOS:
+		return format, nil
+	}
+	return "", ErrUnknownLabelFormat
+}
+
+// RenderLabels merender etiket sesuai format. Nilai kedua adalah content type.
+func RenderLabels(labels []Label, profile pharmacy.Profile, format string, paper int) ([]byte, string, error) {
+	if format == FormatESCPOS {
+		return RenderLabelsESCPOS(labels, profile, paper), "application/octet-stream", nil
+	}
+	data, err := RenderLabelsPDF(labels, profile)
+	return data, "application/pdf", err
+}
+
+// RenderLabelsPDF mencetak satu etiket per halaman seukuran kertas label.
+// Warna etiket mengikuti kertas yang dipasang, sehingga etiket biru
+// ditandai dengan tulisan OBAT LUAR. Signa, aturan pakai dan nama obat
+// dipecah ke beberapa baris; ErrLabelOverflow dikembalikan bila hasilnya
+// tidak muat pada satu label.
+func RenderLabelsPDF(labels []Label, profile pharmacy.Profile) ([]byte, error) {
+	doc := pdf.New(LabelWidthMM*pdf.MMToPt, LabelHeightMM*pdf.MMToPt, 8)
+	width := doc.ContentWidth()
+	for i, l := range labels {
+		if i > 0 {
+			doc.AddPage()
+		}
+
+		doc.SetFont(pdf.Bold, 8)
+		doc.Centered(truncate(profile.Name, 40))
+		doc.SetFont(pdf.Regular, 6)
+		if header := labelHeader(profile); header != "" {
+			doc.Centered(truncate(header, 60))
+		}
+		doc.Line()
+
+		doc.SetFont(pdf.Regular, 7)
+		doc.Row(
+			pdf.Column{Width: width / 2, Text: labelNumber(l)},
+			pdf.Column{X: width / 2, Width: width / 2, Text: "Tgl " + l.Date.Format("02-01-2006"), Align: pdf.Right},
+		)
+		doc.SetFont(pdf.Bold, 8)
+		doc.Text(truncate(l.Patient, 38))
+
+		doc.SetFont(pdf.Bold, 11)
+		for _, part := range wrap(l.Signa, 28) {
+			doc.Centered(part)
+		}
+		doc.SetFont(pdf.Regular, 7)
+		if l.Instruction != "" {
+			for _, part := range wrap(l.Instruction, 48) {
+				doc.Centered(part)
+			}
+		}
+		for _, part := range wrap(strings.TrimSpace(l.Product+" "+l.Quantity), 48) {
+			doc.Text(part)
+		}
+		if l.Expiry != nil {
+			doc.Text("ED: " + l.Expiry.Format("02-01-2006"))
+		}
+		if l.Type == LabelBlue {
+			doc.SetFont(pdf.Bold, 7)
+			doc.Centered("OBAT LUAR - TIDAK BOLEH DIMINUM")
+		}
+		if doc.PageCount() > i+1 {
+			return nil, fmt.Errorf("%w: etiket ke-%d (%s)", ErrLabelOverflow, l.Number, l.Product)
+		}
+	}
+	return doc.Bytes(), nil
+}
+
+// RenderLabelsESCPOS mencetak etiket berurutan pada printer thermal dengan
+// potongan kertas di antara etiket
+func RenderLabelsESCPOS(labels []Label, profile pharmacy.Profile, paper int) []byte {
+	width := columns(paper)
+
+	var b bytes.Buffer
+	b.Write(escInit)
+	text := func(s string) {
+		for _, part := range wrap(s, width) {
+			b.WriteString(asciiOnly(part))
+			b.WriteByte('\n')
+		}
+	}
+	for _, l := range labels {
+		b.Write(escAlignCenter)
+		b.Write(escBoldOn)
+		text(profile.Name)
+		b.Write(escBoldOff)
+		if header := labelHeader(profile); header != "" {
+			text(header)
+		}
+		text(strings.Repeat("-", width))
+
+		b.Write(escAlignLeft)
+		text(spread(labelNumber(l), "Tgl "+l.Date.Format("02-01-2006"), width))
+		b.Write(escBoldOn)
+		text(l.Patient)
+		b.Write(escBoldOff)
+
+		b.Write(escAlignCenter)
+		b.Write(gsSizeDouble)
+		for _, part := range wrap(l.Signa, width/2) {
+			b.WriteString(asciiOnly(part))
+			b.WriteByte('\n')
+		}
+		b.Write(gsSizeNormal)
+		if l.Instruction != "" {
+			text(l.Instruction)
+		}
+
+		b.Write(escAlignLeft)
+		text(strings.TrimSpace(l.Product + " " + l.Quantity))
+		if l.Expiry != nil {
+			text("ED: " + l.Expiry.Format("02-01-2006"))
+		}
+		if l.Type == LabelBlue {
+			b.Write(escAlignCenter)
+			b.Write(escBoldOn)
+			text("** OBAT LUAR **")
+			text("TIDAK BOLEH DIMINUM")
+			b.Write(escBoldOff)
+		}
+		b.Write(escAlignLeft)
+		b.WriteString("\n\n\n")
+		b.Write(gsCutPartial)
+	}
+	return b.Bytes()
+}
+
+// labelHeader menggabungkan alamat, telepon dan apoteker untuk kepala etiket
+func labelHeader(profile pharmacy.Profile) string {
+	var parts []string
+	if profile.Address != "" {
+		parts = append(parts, profile.Address)
+	}
+	if profile.Phone != "" {
+		parts = append(parts, "Telp. "+profile.Phone)
+	}
+	if profile.PharmacistName != "" {
+		parts = append(parts, "Apt. "+profile.PharmacistName)
+	}
+	return strings.Join(parts, " | ")
+}
+
+func labelNumber(l Label) string {
+	if l.Code == "" {
+		return "No. " + strconv.Itoa(l.Number)
+	}
+	return "No. " + l.Code + "/" + strconv.Itoa(l.Number)
+}
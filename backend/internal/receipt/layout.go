@@ -0,0 +1,173 @@
+package receipt
+
+import (
+	"fmt"
+	"go-gin-auth/internal/pharmacy"
+	"go-gin-auth/utils"
+	"strings"
+)
+
+type align int
+
+const (
+	alignLeft align = iota
+	alignCenter
+)
+
+// line adalah satu baris struk thermal yang sudah dipotong sesuai lebar kertas
+type line struct {
+	text  string
+	align align
+	bold  bool
+	large bool
+}
+
+// columns mengembalikan jumlah karakter per baris font A printer thermal
+func columns(paper int) int {
+	if paper == Paper58 {
+		return 32
+	}
+	return 48
+}
+
+// thermalLines menyusun isi struk sebagai baris-baris teks monospace,
+// dipakai bersama oleh keluaran teks biasa dan ESC/POS
+func thermalLines(r *Receipt, profile pharmacy.Profile, width int) []line {
+	var lines []line
+	center := func(s string, bold bool) {
+		for _, w := range wrap(s, width) {
+			lines = append(lines, line{text: w, align: alignCenter, bold: bold})
+		}
+	}
+	left := func(s string) {
+		for _, w := range wrap(s, width) {
+			lines = append(lines, line{text: w})
+		}
+	}
+	pair := func(label, value string, bold bool) {
+		lines = append(lines, line{text: spread(label, value, width), bold: bold})
+	}
+	rule := func() {
+		lines = append(lines, line{text: strings.Repeat("-", width)})
+	}
+
+	lines = append(lines, line{text: profile.Name, align: alignCenter, bold: true, large: true})
+	if profile.Address != "" {
+		center(profile.Address, false)
+	}
+	if profile.Phone != "" {
+		center("Telp. "+profile.Phone, false)
+	}
+	if profile.SIANumber != "" {
+		center("SIA: "+profile.SIANumber, false)
+	}
+	if r.Copy {
+		lines = append(lines, line{})
+		center(fmt.Sprintf("*** SALINAN (CETAK KE-%d) ***", r.PrintNo), true)
+	}
+	if r.VoidedAt != nil {
+		lines = append(lines, line{})
+		lines = append(lines, line{text: "*** BATAL ***", align: alignCenter, bold: true, large: true})
+		center("Dibatalkan "+r.VoidedAt.Format("02-01-2006 15:04"), true)
+		center("BUKAN BUKTI PEMBAYARAN", true)
+	}
+	rule()
+
+	left("No     : " + r.Number)
+	left("Tanggal: " + r.Date.Format("02-01-2006 15:04"))
+	if r.Cashier != "" {
+		left("Kasir  : " + r.Cashier)
+	}
+	if r.Customer != "" {
+		left("Pembeli: " + r.Customer)
+	}
+	for _, info := range r.Info {
+		left(info)
+	}
+	rule()
+
+	for _, item := range r.Items {
+		left(item.Name)
+		qty := fmt.Sprintf("  %d %s x %s", item.Qty, item.Unit, utils.FormatRupiah(item.Price))
+		pair(qty, utils.FormatRupiah(item.SubTotal), false)
+	}
+	rule()
+
+	pair("Subtotal", utils.FormatRupiah(r.SubTotal), false)
+	if r.Discount != 0 {
+		pair("Diskon", "-"+utils.FormatRupiah(r.Discount), false)
+	}
+	pair("TOTAL", utils.FormatRupiah(r.Total), true)
+	if r.TaxAmount != 0 {
+		pair("DPP", utils.FormatRupiah(r.TaxBase), false)
+		pair("PPN", utils.FormatRupiah(r.TaxAmount), false)
+	}
+
+	var change float64
+	for _, p := range r.Payments {
+		label := p.Method
+		if p.ReferenceNo != "" {
+			label += " (" + p.ReferenceNo + ")"
+		}
+		pair(label, utils.FormatRupiah(p.Amount), false)
+		change += p.ChangeGiven
+	}
+	if change > 0 {
+		pair("Kembali", utils.FormatRupiah(change), false)
+	}
+	rule()
+
+	if profile.ReceiptFooter != "" {
+		center(profile.ReceiptFooter, false)
+	}
+	return lines
+}
+
+// spread menaruh label di kiri dan nilai di kanan dalam satu baris
+func spread(label, value string, width int) string {
+	lr, vr := []rune(label), []rune(value)
+	if len(lr)+len(vr)+1 > width {
+		max := width - len(vr) - 1
+		if max < 0 {
+			max = 0
+		}
+		if len(lr) > max {
+			lr = lr[:max]
+		}
+	}
+	return string(lr) + strings.Repeat(" ", width-len(lr)-len(vr)) + string(vr)
+}
+
+// wrap memecah teks per kata agar tidak melebihi lebar baris
+func wrap(s string, width int) []string {
+	if len([]rune(s)) <= width {
+		return []string{s}
+	}
+
+	var out []string
+	var current string
+	for _, word := range strings.Fields(s) {
+		for len([]rune(word)) > width {
+			if current != "" {
+				out = append(out, current)
+				current = ""
+			}
+			r := []rune(word)
+			out = append(out, string(r[:width]))
+			word = string(r[width:])
+		}
+		switch {
+		case current == "":
+			current = word
+		case len([]rune(current))+1+len([]rune(word)) <= width:
+			current += " " + word
+		default:
+			out = append(out, current)
+			current = word
+		}
+	}
+	if current != "" || len(out) == 0 {
+		out = append(out, current)
+	}
+	return out
+}
@@ -0,0 +1,78 @@
+package receipt
+
+import (
+	"go-gin-auth/internal/payment"
+	"time"
+)
+
+// Format keluaran struk
+const (
+	FormatText   = "text"
+	FormatESCPOS = "escpos"
+	FormatPDF    = "pdf"
+)
+
+// Lebar kertas printer thermal dalam milimeter
+const (
+	Paper58 = 58
+	Paper80 = 80
+)
+
+// Item adalah satu baris barang pada struk
+type Item struct {
+	Name     string
+	Qty      int
+	Unit     string
+	Price    float64
+	SubTotal float64
+}
+
+// Receipt adalah bentuk netral dari penjualan reguler maupun resep yang
+// siap dirender ke teks, ESC/POS atau PDF
+type Receipt struct {
+	SaleType string
+	SaleID   uint
+	Title    string
+	Number   string
+	Date     time.Time
+	Cashier  string
+	Customer string
+	// Info berisi baris tambahan seperti nomor resep, dokter dan pasien
+	Info     []string
+	Items    []Item
+	SubTotal float64
+	Discount float64
+	Total    float64
+	Payments []payment.Payment
+	// TaxBase dan TaxAmount adalah DPP dan PPN yang sudah termasuk dalam
+	// Total; keduanya dicetak bila PPN tidak nol
+	TaxBase   float64
+	TaxAmount float64
+
+	// Copy bernilai true untuk cetak ulang; PrintNo adalah urutan cetak
+	Copy    bool
+	PrintNo int
+	// VoidedAt diisi bila penjualan sudah dibatalkan; struknya dicetak
+	// dengan tanda BATAL agar tidak dipakai sebagai bukti bayar
+	VoidedAt *time.Time
+}
+
+// Options menentukan format dan lebar kertas saat mencetak struk
+type Options struct {
+	Format string
+	Paper  int
+}
+
+// PrintLog adalah riwayat pencetakan struk, dipakai untuk menandai cetak ulang
+type PrintLog struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	SaleType  string    `gorm:"type:varchar(20);not null;index:idx_receipt_print_sale" json:"sale_type"`
+	SaleID    uint      `gorm:"not null;index:idx_receipt_print_sale" json:"sale_id"`
+	Format    string    `gorm:"type:varchar(10);not null" json:"format"`
+	PrintedBy uint      `json:"printed_by"`
+	PrintedAt time.Time `gorm:"not null" json:"printed_at"`
+}
+
+func (PrintLog) TableName() string {
+	return "receipt_prints"
+}
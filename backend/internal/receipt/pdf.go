@@ -0,0 +1,125 @@
+package receipt
+
+import (
+	"fmt"
+	"go-gin-auth/internal/pharmacy"
+	"go-gin-auth/pkg/pdf"
+	"go-gin-auth/utils"
+)
+
+// RenderPDF mencetak faktur penjualan dalam format PDF A4
+func RenderPDF(r *Receipt, profile pharmacy.Profile) []byte {
+	doc := pdf.New(pdf.A4Width, pdf.A4Height, 40)
+	width := doc.ContentWidth()
+
+	doc.SetFont(pdf.Bold, 14)
+	doc.Text(profile.Name)
+	doc.SetFont(pdf.Regular, 9)
+	if profile.Address != "" {
+		doc.Text(profile.Address)
+	}
+	if profile.Phone != "" {
+		doc.Text("Telp. " + profile.Phone)
+	}
+	if profile.SIANumber != "" {
+		doc.Text("SIA: " + profile.SIANumber)
+	}
+	doc.Line()
+
+	doc.SetFont(pdf.Bold, 12)
+	doc.Centered(r.Title)
+	if r.Copy {
+		doc.SetFont(pdf.Bold, 9)
+		doc.Centered(fmt.Sprintf("SALINAN - CETAK KE-%d", r.PrintNo))
+	}
+	if r.VoidedAt != nil {
+		doc.SetFont(pdf.Bold, 14)
+		doc.Centered("*** BATAL ***")
+		doc.SetFont(pdf.Bold, 9)
+		doc.Centered("Dibatalkan " + r.VoidedAt.Format("02-01-2006 15:04") + " - bukan bukti pembayaran")
+	}
+	doc.Gap(6)
+
+	doc.SetFont(pdf.Regular, 9)
+	doc.Text("No. Transaksi : " + r.Number)
+	doc.Text("Tanggal       : " + r.Date.Format("02-01-2006 15:04"))
+	if r.Cashier != "" {
+		doc.Text("Kasir         : " + r.Cashier)
+	}
+	if r.Customer != "" {
+		doc.Text("Pembeli       : " + r.Customer)
+	}
+	for _, info := range r.Info {
+		doc.Text(info)
+	}
+	doc.Gap(6)
+
+	doc.SetFont(pdf.MonoB, 8)
+	doc.Row(invoiceColumns(width, "No", "Nama Barang", "Qty", "Satuan", "Harga", "Subtotal")...)
+	doc.Line()
+	doc.SetFont(pdf.Mono, 8)
+	for i, item := range r.Items {
+		doc.Row(invoiceColumns(width,
+			fmt.Sprintf("%d", i+1), item.Name, fmt.Sprintf("%d", item.Qty), item.Unit,
+			utils.FormatRupiah(item.Price), utils.FormatRupiah(item.SubTotal))...)
+	}
+	doc.Line()
+
+	total := func(label, value string) {
+		doc.Row(
+			pdf.Column{X: width - 220, Width: 110, Text: label},
+			pdf.Column{X: width - 110, Width: 110, Text: value, Align: pdf.Right},
+		)
+	}
+	total("Subtotal", utils.FormatRupiah(r.SubTotal))
+	if r.Discount != 0 {
+		total("Diskon", "-"+utils.FormatRupiah(r.Discount))
+	}
+	doc.SetFont(pdf.MonoB, 9)
+	total("TOTAL", utils.FormatRupiah(r.Total))
+	doc.SetFont(pdf.Mono, 8)
+	if r.TaxAmount != 0 {
+		total("DPP", utils.FormatRupiah(r.TaxBase))
+		total("PPN", utils.FormatRupiah(r.TaxAmount))
+	}
+
+	var change float64
+	for _, p := range r.Payments {
+		label := p.Method
+		if p.ReferenceNo != "" {
+			label += " " + p.ReferenceNo
+		}
+		total(truncate(label, 20), utils.FormatRupiah(p.Amount))
+		change += p.ChangeGiven
+	}
+	if change > 0 {
+		total("Kembali", utils.FormatRupiah(change))
+	}
+
+	if profile.ReceiptFooter != "" {
+		doc.Gap(16)
+		doc.SetFont(pdf.Regular, 9)
+		doc.Centered(profile.ReceiptFooter)
+	}
+
+	return doc.Bytes()
+}
+
+func invoiceColumns(width float64, no, name, qty, unit, price, subTotal string) []pdf.Column {
+	return []pdf.Column{
+		{Width: 20, Text: no, Align: pdf.Right},
+		{X: 28, Width: 220, Text: truncate(name, 45)},
+		{X: 255, Width: 35, Text: qty, Align: pdf.Right},
+		{X: 298, Width: 60, Text: truncate(unit, 12)},
+		{X: width - 170, Width: 80, Text: price, Align: pdf.Right},
+		{X: width - 85, Width: 85, Text: subTotal, Align: pdf.Right},
+	}
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
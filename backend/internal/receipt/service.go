@@ -0,0 +1,102 @@
+package receipt
+
+import (
+	"errors"
+	"fmt"
+	"go-gin-auth/internal/payment"
+	"go-gin-auth/internal/pharmacy"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrUnknownFormat = errors.New("format struk harus text, escpos atau pdf")
+
+// saleTables memetakan jenis penjualan ke tabelnya untuk penguncian baris
+var saleTables = map[string]string{
+	payment.SaleRegular:      "sales_regulars",
+	payment.SalePrescription: "prescription_sales",
+}
+
+// ParseOptions membaca query format dan paper; bawaannya teks 80mm
+func ParseOptions(format, paper string) (Options, error) {
+	opts := Options{Format: FormatText, Paper: Paper80}
+	switch format {
+	case "":
+	case FormatText, FormatESCPOS, FormatPDF:
+		opts.Format = format
+	default:
+		return opts, ErrUnknownFormat
+	}
+	switch paper {
+	case "", "80":
+	case "58":
+		opts.Paper = Paper58
+	default:
+		return opts, fmt.Errorf("lebar kertas %q tidak didukung, gunakan 58 atau 80", paper)
+	}
+	return opts, nil
+}
+
+// Extension mengembalikan ekstensi file untuk header Content-Disposition
+func Extension(format string) string {
+	switch format {
+	case FormatPDF:
+		return "pdf"
+	case FormatESCPOS:
+		return "bin"
+	default:
+		return "txt"
+	}
+}
+
+// Print mencatat riwayat cetak, menandai struk sebagai salinan bila sudah
+// pernah dicetak, lalu merender sesuai format. Nilai kedua adalah content type.
+func Print(db *gorm.DB, r *Receipt, opts Options, userID uint) ([]byte, string, error) {
+	profile, err := pharmacy.Get(db)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// baris penjualan dikunci selama menghitung dan mencatat riwayat agar
+	// dua cetak bersamaan tidak sama-sama ditandai sebagai cetakan asli
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if table, ok := saleTables[r.SaleType]; ok {
+			var locked uint
+			if err := tx.Table(table).Clauses(clause.Locking{Strength: "UPDATE"}).
+				Select("id").Where("id = ?", r.SaleID).Scan(&locked).Error; err != nil {
+				return err
+			}
+		}
+
+		var printed int64
+		if err := tx.Model(&PrintLog{}).
+			Where("sale_type = ? AND sale_id = ?", r.SaleType, r.SaleID).
+			Count(&printed).Error; err != nil {
+			return err
+		}
+		r.PrintNo = int(printed) + 1
+		r.Copy = printed > 0
+
+		return tx.Create(&PrintLog{
+			SaleType:  r.SaleType,
+			SaleID:    r.SaleID,
+			Format:    opts.Format,
+			PrintedBy: userID,
+			PrintedAt: time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	switch opts.Format {
+	case FormatPDF:
+		return RenderPDF(r, profile), "application/pdf", nil
+	case FormatESCPOS:
+		return RenderESCPOS(r, profile, opts.Paper), "application/octet-stream", nil
+	default:
+		return RenderText(r, profile, opts.Paper), "text/plain; charset=utf-8", nil
+	}
+}
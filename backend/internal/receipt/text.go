@@ -0,0 +1,24 @@
+package receipt
+
+import (
+	"go-gin-auth/internal/pharmacy"
+	"strings"
+)
+
+// RenderText mencetak struk sebagai teks biasa selebar kertas thermal
+func RenderText(r *Receipt, profile pharmacy.Profile, paper int) []byte {
+	width := columns(paper)
+
+	var b strings.Builder
+	for _, l := range thermalLines(r, profile, width) {
+		text := l.text
+		if l.align == alignCenter {
+			if pad := (width - len([]rune(text))) / 2; pad > 0 {
+				text = strings.Repeat(" ", pad) + text
+			}
+		}
+		b.WriteString(text)
+		b.WriteByte('\n')
+	}
+	return []byte(b.String())
+}
@@ -0,0 +1,164 @@
+package receivable
+
+import (
+	"errors"
+	"go-gin-auth/internal/customer"
+	"go-gin-auth/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// GetAll menampilkan daftar piutang.
+// Query: status, customer_id, debtor, overdue=true
+func (h *Handler) GetAll(c *gin.Context) {
+	filter := Filter{
+		Status:  c.Query("status"),
+		Debtor:  c.Query("debtor"),
+		Overdue: c.Query("overdue") == "true",
+	}
+	if v := c.Query("customer_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			utils.Respond(c, http.StatusBadRequest, "customer_id tidak valid", err.Error(), nil)
+			return
+		}
+		customerID := uint(id)
+		filter.CustomerID = &customerID
+	}
+
+	receivables, err := h.service.List(filter)
+	if err != nil {
+		utils.Respond(c, http.StatusInternalServerError, "Gagal mengambil daftar piutang", err.Error(), nil)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Daftar piutang berhasil diambil", nil, receivables)
+}
+
+func (h *Handler) GetByID(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	r, err := h.service.GetByID(uint(id))
+	if err != nil {
+		h.respondError(c, "Gagal mengambil piutang", err)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Detail piutang berhasil diambil", nil, r)
+}
+
+func (h *Handler) AddPayment(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var input PaymentRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Respond(c, http.StatusBadRequest, "Input tidak valid", err.Error(), nil)
+		return
+	}
+	r, err := h.service.AddPayment(uint(id), &input, utils.GetCurrentUserID(c))
+	if err != nil {
+		h.respondError(c, "Gagal mencatat pembayaran piutang", err)
+		return
+	}
+	utils.Respond(c, http.StatusCreated, "Pembayaran piutang berhasil dicatat", nil, r)
+}
+
+func (h *Handler) DeletePayment(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	paymentID, _ := strconv.ParseUint(c.Param("paymentId"), 10, 32)
+	r, err := h.service.DeletePayment(uint(id), uint(paymentID))
+	if err != nil {
+		h.respondError(c, "Gagal menghapus pembayaran piutang", err)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Pembayaran piutang berhasil dihapus", nil, r)
+}
+
+// Statement menampilkan kartu piutang satu debitur.
+// Query: customer_id atau debtor, start_date, end_date (YYYY-MM-DD)
+func (h *Handler) Statement(c *gin.Context) {
+	var customerID *uint
+	if v := c.Query("customer_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			utils.Respond(c, http.StatusBadRequest, "customer_id tidak valid", err.Error(), nil)
+			return
+		}
+		cid := uint(id)
+		customerID = &cid
+	}
+
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	end := now
+	if v := c.Query("start_date"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			utils.Respond(c, http.StatusBadRequest, "Format start_date harus YYYY-MM-DD", err.Error(), nil)
+			return
+		}
+		start = t
+	}
+	if v := c.Query("end_date"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			utils.Respond(c, http.StatusBadRequest, "Format end_date harus YYYY-MM-DD", err.Error(), nil)
+			return
+		}
+		end = t.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	st, err := h.service.Statement(customerID, c.Query("debtor"), start, end)
+	if err != nil {
+		h.respondError(c, "Gagal menyusun kartu piutang", err)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Kartu piutang berhasil disusun", nil, st)
+}
+
+// Aging menampilkan umur piutang per debitur. Query: as_of (YYYY-MM-DD)
+func (h *Handler) Aging(c *gin.Context) {
+	asOf := time.Now()
+	if v := c.Query("as_of"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			utils.Respond(c, http.StatusBadRequest, "Format as_of harus YYYY-MM-DD", err.Error(), nil)
+			return
+		}
+		asOf = t.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	report, err := h.service.Aging(asOf)
+	if err != nil {
+		utils.Respond(c, http.StatusInternalServerError, "Gagal menyusun umur piutang", err.Error(), nil)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Umur piutang berhasil disusun", nil, report)
+}
+
+func (h *Handler) respondError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound), errors.Is(err, ErrPaymentNotFound), errors.Is(err, customer.ErrNotFound):
+		utils.Respond(c, http.StatusNotFound, err.Error(), err.Error(), nil)
+	case errors.Is(err, ErrNotOpen), errors.Is(err, ErrOverpayment), errors.Is(err, ErrDebtorMissing):
+		utils.Respond(c, http.StatusBadRequest, err.Error(), err.Error(), nil)
+	default:
+		utils.Respond(c, http.StatusInternalServerError, message, err.Error(), nil)
+	}
+}
+
+// IsSaleError menandai kesalahan piutang saat menyimpan penjualan yang
+// perlu diperbaiki kasir, bukan kegagalan server
+func IsSaleError(err error) bool {
+	return errors.Is(err, ErrDebtorRequired) ||
+		errors.Is(err, ErrCreditLimit) ||
+		errors.Is(err, ErrHasPayments) ||
+		errors.Is(err, ErrAmountBelowPaid)
+}
@@ -0,0 +1,125 @@
+package receivable
+
+import "time"
+
+// Status piutang
+const (
+	StatusOpen      = "Belum Lunas"
+	StatusPaid      = "Lunas"
+	StatusCancelled = "Batal"
+)
+
+// DefaultTermDays dipakai bila pelanggan tidak memiliki tempo pembayaran
+// sendiri atau piutang ditagihkan ke penjamin pasien
+const DefaultTermDays = 30
+
+// Receivable adalah piutang dari bagian penjualan yang dibayar dengan metode
+// Kredit atau Jaminan. Debitur adalah pelanggan, atau penjamin pasien pada
+// penjualan resep bila pasien tidak terhubung ke data pelanggan.
+type Receivable struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	SaleType   string     `gorm:"type:varchar(20);not null;uniqueIndex:idx_receivables_sale" json:"sale_type"`
+	SaleID     uint       `gorm:"not null;uniqueIndex:idx_receivables_sale" json:"sale_id"`
+	SaleCode   string     `gorm:"type:varchar(50);not null" json:"sale_code"`
+	CustomerID *uint      `gorm:"index" json:"customer_id,omitempty"`
+	PatientID  *uint      `gorm:"index" json:"patient_id,omitempty"`
+	DebtorName string     `gorm:"type:varchar(255);not null;index" json:"debtor_name"`
+	Method     string     `gorm:"type:varchar(30);not null" json:"method"`
+	Amount     float64    `gorm:"type:decimal(15,2);not null" json:"amount"`
+	Paid       float64    `gorm:"type:decimal(15,2);not null;default:0" json:"paid"`
+	Balance    float64    `gorm:"type:decimal(15,2);not null" json:"balance"`
+	IssuedAt   time.Time  `gorm:"not null" json:"issued_at"`
+	DueDate    time.Time  `gorm:"not null;index" json:"due_date"`
+	Status     string     `gorm:"type:varchar(20);not null;index" json:"status"`
+	PaidOffAt  *time.Time `json:"paid_off_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	Payments []Payment `gorm:"foreignKey:ReceivableID" json:"payments,omitempty"`
+}
+
+func (Receivable) TableName() string {
+	return "receivables"
+}
+
+// Payment adalah pelunasan (sebagian atau penuh) atas satu piutang
+type Payment struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	ReceivableID uint      `gorm:"not null;index" json:"receivable_id"`
+	PaidAt       time.Time `gorm:"not null" json:"paid_at"`
+	Amount       float64   `gorm:"type:decimal(15,2);not null" json:"amount"`
+	Method       string    `gorm:"type:varchar(30);not null" json:"method"`
+	ReferenceNo  string    `gorm:"type:varchar(100)" json:"reference_no,omitempty"`
+	Note         string    `gorm:"type:text" json:"note,omitempty"`
+	CreatedBy    uint      `json:"created_by"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (Payment) TableName() string {
+	return "receivable_payments"
+}
+
+type PaymentRequest struct {
+	Amount      float64    `json:"amount" binding:"required,gt=0"`
+	Method      string     `json:"method" binding:"required,oneof=Tunai Transfer QRIS Debit"`
+	PaidAt      *time.Time `json:"paid_at"`
+	ReferenceNo string     `json:"reference_no"`
+	Note        string     `json:"note"`
+}
+
+// Source adalah data penjualan yang dipakai untuk membuat atau menyesuaikan
+// piutang di dalam transaksi penjualan
+type Source struct {
+	SaleType   string
+	SaleID     uint
+	SaleCode   string
+	Date       time.Time
+	CustomerID *uint
+	PatientID  *uint
+}
+
+type Filter struct {
+	Status     string
+	CustomerID *uint
+	Debtor     string
+	Overdue    bool
+}
+
+// StatementLine adalah satu mutasi pada kartu piutang: tagihan menambah
+// saldo, pembayaran menguranginya
+type StatementLine struct {
+	Date        time.Time `json:"date"`
+	Reference   string    `json:"reference"`
+	Description string    `json:"description"`
+	Debit       float64   `json:"debit"`
+	Credit      float64   `json:"credit"`
+	Balance     float64   `json:"balance"`
+}
+
+type Statement struct {
+	DebtorName     string          `json:"debtor_name"`
+	CustomerID     *uint           `json:"customer_id,omitempty"`
+	Start          time.Time       `json:"start"`
+	End            time.Time       `json:"end"`
+	OpeningBalance float64         `json:"opening_balance"`
+	Lines          []StatementLine `json:"lines"`
+	ClosingBalance float64         `json:"closing_balance"`
+}
+
+// AgingRow adalah sisa piutang satu debitur per umur jatuh tempo
+type AgingRow struct {
+	DebtorName string  `json:"debtor_name"`
+	CustomerID *uint   `json:"customer_id,omitempty"`
+	Current    float64 `json:"current"`
+	Days1To30  float64 `json:"days_1_30"`
+	Days31To60 float64 `json:"days_31_60"`
+	Days61To90 float64 `json:"days_61_90"`
+	Over90     float64 `json:"over_90"`
+	Total      float64 `json:"total"`
+}
+
+type AgingReport struct {
+	AsOf   time.Time  `json:"as_of"`
+	Rows   []AgingRow `json:"rows"`
+	Totals AgingRow   `json:"totals"`
+}
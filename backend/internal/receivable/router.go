@@ -0,0 +1,22 @@
+package receivable
+
+import (
+	"go-gin-auth/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ReceivableRouter(api *gin.RouterGroup) {
+	service := NewService(config.DB)
+	handler := NewHandler(service)
+
+	receivables := api.Group("/receivables")
+	{
+		receivables.GET("", handler.GetAll)
+		receivables.GET("/aging", handler.Aging)
+		receivables.GET("/statement", handler.Statement)
+		receivables.GET("/:id", handler.GetByID)
+		receivables.POST("/:id/payments", handler.AddPayment)
+		receivables.DELETE("/:id/payments/:paymentId", handler.DeletePayment)
+	}
+}
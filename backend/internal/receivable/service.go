@@ -0,0 +1,269 @@
+package receivable
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrNotFound        = errors.New("piutang tidak ditemukan")
+	ErrPaymentNotFound = errors.New("pembayaran piutang tidak ditemukan")
+	ErrNotOpen         = errors.New("piutang sudah lunas atau dibatalkan")
+	ErrOverpayment     = errors.New("pembayaran melebihi sisa piutang")
+	ErrDebtorMissing   = errors.New("pilih customer_id atau nama debitur")
+)
+
+type Service struct {
+	db *gorm.DB
+}
+
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db}
+}
+
+func (s *Service) List(filter Filter) ([]Receivable, error) {
+	receivables := []Receivable{}
+	query := s.db.Model(&Receivable{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.CustomerID != nil {
+		query = query.Where("customer_id = ?", *filter.CustomerID)
+	}
+	if debtor := strings.TrimSpace(filter.Debtor); debtor != "" {
+		query = query.Where("LOWER(debtor_name) LIKE ?", "%"+strings.ToLower(debtor)+"%")
+	}
+	if filter.Overdue {
+		query = query.Where("status = ? AND due_date < ?", StatusOpen, time.Now())
+	}
+	if err := query.Order("due_date ASC, id ASC").Find(&receivables).Error; err != nil {
+		return nil, err
+	}
+	return receivables, nil
+}
+
+func (s *Service) GetByID(id uint) (*Receivable, error) {
+	var r Receivable
+	err := s.db.Preload("Payments", func(db *gorm.DB) *gorm.DB {
+		return db.Order("paid_at ASC, id ASC")
+	}).First(&r, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &r, nil
+}
+
+// AddPayment mencatat pelunasan dan memperbarui sisa piutang
+func (s *Service) AddPayment(id uint, req *PaymentRequest, userID uint) (*Receivable, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		r, err := lockReceivable(tx, id)
+		if err != nil {
+			return err
+		}
+		if r.Status != StatusOpen {
+			return ErrNotOpen
+		}
+		amount := round(req.Amount)
+		if amount > r.Balance {
+			return ErrOverpayment
+		}
+
+		paidAt := time.Now()
+		if req.PaidAt != nil {
+			paidAt = *req.PaidAt
+		}
+		p := Payment{
+			ReceivableID: r.ID,
+			PaidAt:       paidAt,
+			Amount:       amount,
+			Method:       req.Method,
+			ReferenceNo:  req.ReferenceNo,
+			Note:         req.Note,
+			CreatedBy:    userID,
+		}
+		if err := tx.Create(&p).Error; err != nil {
+			return err
+		}
+
+		r.Paid = round(r.Paid + amount)
+		settle(r)
+		return tx.Omit(clause.Associations).Save(r).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetByID(id)
+}
+
+// DeletePayment menghapus pembayaran yang salah input; sisa piutang dibuka lagi
+func (s *Service) DeletePayment(id, paymentID uint) (*Receivable, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		r, err := lockReceivable(tx, id)
+		if err != nil {
+			return err
+		}
+		if r.Status == StatusCancelled {
+			return ErrNotOpen
+		}
+
+		var p Payment
+		if err := tx.Where("id = ? AND receivable_id = ?", paymentID, id).First(&p).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrPaymentNotFound
+			}
+			return err
+		}
+		if err := tx.Delete(&p).Error; err != nil {
+			return err
+		}
+
+		r.Paid = round(r.Paid - p.Amount)
+		settle(r)
+		return tx.Omit(clause.Associations).Save(r).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetByID(id)
+}
+
+// Statement menyusun kartu piutang satu debitur pada rentang tanggal: saldo
+// awal, tagihan dan pembayaran berurutan, lalu saldo akhir
+func (s *Service) Statement(customerID *uint, debtorName string, start, end time.Time) (*Statement, error) {
+	query := s.db.Model(&Receivable{}).Where("status <> ?", StatusCancelled)
+	switch {
+	case customerID != nil:
+		query = query.Where("customer_id = ?", *customerID)
+	case strings.TrimSpace(debtorName) != "":
+		query = query.Where("customer_id IS NULL AND LOWER(debtor_name) = ?", strings.ToLower(strings.TrimSpace(debtorName)))
+	default:
+		return nil, ErrDebtorMissing
+	}
+
+	var receivables []Receivable
+	if err := query.Preload("Payments").Where("issued_at <= ?", end).Order("issued_at").Find(&receivables).Error; err != nil {
+		return nil, err
+	}
+
+	st := &Statement{CustomerID: customerID, DebtorName: strings.TrimSpace(debtorName), Start: start, End: end, Lines: []StatementLine{}}
+	for _, r := range receivables {
+		st.DebtorName = r.DebtorName
+		if r.IssuedAt.Before(start) {
+			st.OpeningBalance += r.Amount
+		} else {
+			st.Lines = append(st.Lines, StatementLine{
+				Date:        r.IssuedAt,
+				Reference:   r.SaleCode,
+				Description: "Penjualan " + r.Method,
+				Debit:       r.Amount,
+			})
+		}
+		for _, p := range r.Payments {
+			switch {
+			case p.PaidAt.Before(start):
+				st.OpeningBalance -= p.Amount
+			case !p.PaidAt.After(end):
+				st.Lines = append(st.Lines, StatementLine{
+					Date:        p.PaidAt,
+					Reference:   r.SaleCode,
+					Description: "Pembayaran " + p.Method,
+					Credit:      p.Amount,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(st.Lines, func(i, j int) bool { return st.Lines[i].Date.Before(st.Lines[j].Date) })
+	st.OpeningBalance = round(st.OpeningBalance)
+	balance := st.OpeningBalance
+	for i := range st.Lines {
+		balance = round(balance + st.Lines[i].Debit - st.Lines[i].Credit)
+		st.Lines[i].Balance = balance
+	}
+	st.ClosingBalance = balance
+	return st, nil
+}
+
+// Aging mengelompokkan sisa piutang per debitur menurut lama lewat jatuh
+// tempo pada tanggal asOf. Pembayaran setelah asOf belum diperhitungkan.
+func (s *Service) Aging(asOf time.Time) (*AgingReport, error) {
+	var receivables []Receivable
+	err := s.db.Preload("Payments", "paid_at <= ?", asOf).
+		Where("status <> ? AND issued_at <= ?", StatusCancelled, asOf).
+		Order("debtor_name").
+		Find(&receivables).Error
+	if err != nil {
+		return nil, err
+	}
+
+	report := &AgingReport{AsOf: asOf, Rows: []AgingRow{}}
+	index := map[string]int{}
+	for _, r := range receivables {
+		balance := r.Amount
+		for _, p := range r.Payments {
+			balance -= p.Amount
+		}
+		balance = round(balance)
+		if balance <= 0 {
+			continue
+		}
+
+		key := "debtor:" + strings.ToLower(r.DebtorName)
+		if r.CustomerID != nil {
+			key = fmt.Sprintf("customer:%d", *r.CustomerID)
+		}
+		i, ok := index[key]
+		if !ok {
+			report.Rows = append(report.Rows, AgingRow{DebtorName: r.DebtorName, CustomerID: r.CustomerID})
+			i = len(report.Rows) - 1
+			index[key] = i
+		}
+		addAging(&report.Rows[i], balance, daysOverdue(r.DueDate, asOf))
+		addAging(&report.Totals, balance, daysOverdue(r.DueDate, asOf))
+	}
+	report.Totals.DebtorName = "Total"
+	return report, nil
+}
+
+func daysOverdue(due, asOf time.Time) int {
+	if !asOf.After(due) {
+		return 0
+	}
+	return int(asOf.Sub(due).Hours() / 24)
+}
+
+func addAging(row *AgingRow, amount float64, days int) {
+	switch {
+	case days <= 0:
+		row.Current = round(row.Current + amount)
+	case days <= 30:
+		row.Days1To30 = round(row.Days1To30 + amount)
+	case days <= 60:
+		row.Days31To60 = round(row.Days31To60 + amount)
+	case days <= 90:
+		row.Days61To90 = round(row.Days61To90 + amount)
+	default:
+		row.Over90 = round(row.Over90 + amount)
+	}
+	row.Total = round(row.Total + amount)
+}
+
+func lockReceivable(tx *gorm.DB, id uint) (*Receivable, error) {
+	var r Receivable
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&r, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &r, nil
+}
@@ -0,0 +1,208 @@
+package receivable
+
+import (
+	"errors"
+	"fmt"
+	"go-gin-auth/internal/customer"
+	"go-gin-auth/internal/patient"
+	"go-gin-auth/internal/payment"
+	"go-gin-auth/utils"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrDebtorRequired  = errors.New("pembayaran Kredit atau Jaminan memerlukan pelanggan terdaftar")
+	ErrCreditLimit     = errors.New("piutang melebihi limit kredit pelanggan")
+	ErrHasPayments     = errors.New("piutang sudah menerima pembayaran, hapus pembayarannya lebih dulu")
+	ErrAmountBelowPaid = errors.New("nilai piutang baru lebih kecil dari pembayaran yang sudah diterima")
+)
+
+// Sync membuat, menyesuaikan atau membatalkan piutang sebuah penjualan
+// sesuai baris pembayaran Kredit dan Jaminan. Dipanggil di dalam transaksi
+// penjualan setelah pembayaran disimpan.
+func Sync(tx *gorm.DB, src Source, payments []payment.Payment) error {
+	amount, method := 0.0, ""
+	for _, p := range payments {
+		if payment.OnAccount(p.Method) {
+			amount += p.Amount - p.ChangeGiven
+			if method == "" {
+				method = p.Method
+			}
+		}
+	}
+	amount = round(amount)
+
+	// piutang yang pernah dibatalkan dipakai lagi karena satu penjualan
+	// hanya memiliki satu baris piutang
+	var existing Receivable
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("sale_type = ? AND sale_id = ?", src.SaleType, src.SaleID).
+		First(&existing).Error
+	found := err == nil
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	if amount == 0 {
+		if !found || existing.Status == StatusCancelled {
+			return nil
+		}
+		return cancel(tx, &existing)
+	}
+
+	debtor, err := resolveDebtor(tx, src)
+	if err != nil {
+		return err
+	}
+	if err := checkLimit(tx, debtor, amount, src); err != nil {
+		return err
+	}
+
+	r := existing
+	if found && amount < r.Paid {
+		return ErrAmountBelowPaid
+	}
+	r.SaleType = src.SaleType
+	r.SaleID = src.SaleID
+	r.SaleCode = src.SaleCode
+	r.CustomerID = debtor.customerID
+	r.PatientID = src.PatientID
+	r.DebtorName = debtor.name
+	r.Method = method
+	r.Amount = amount
+	r.IssuedAt = src.Date
+	r.DueDate = src.Date.AddDate(0, 0, debtor.termDays)
+	settle(&r)
+
+	if found {
+		return tx.Omit(clause.Associations).Save(&r).Error
+	}
+	return tx.Create(&r).Error
+}
+
+// Cancel membatalkan piutang penjualan yang di-void. Piutang yang sudah
+// menerima pembayaran tidak dapat dibatalkan.
+func Cancel(tx *gorm.DB, saleType string, saleID uint) error {
+	var existing Receivable
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("sale_type = ? AND sale_id = ? AND status <> ?", saleType, saleID, StatusCancelled).
+		First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return cancel(tx, &existing)
+}
+
+func cancel(tx *gorm.DB, r *Receivable) error {
+	if r.Paid > 0 {
+		return ErrHasPayments
+	}
+	return tx.Model(r).Updates(map[string]interface{}{
+		"status":  StatusCancelled,
+		"balance": 0,
+	}).Error
+}
+
+// settle menghitung ulang sisa dan status setelah nilai atau pembayaran berubah
+func settle(r *Receivable) {
+	r.Balance = round(r.Amount - r.Paid)
+	if r.Balance <= 0 {
+		r.Balance = 0
+		r.Status = StatusPaid
+		if r.PaidOffAt == nil {
+			now := time.Now()
+			r.PaidOffAt = &now
+		}
+		return
+	}
+	r.Status = StatusOpen
+	r.PaidOffAt = nil
+}
+
+type debtor struct {
+	customerID  *uint
+	name        string
+	termDays    int
+	creditLimit float64
+}
+
+// resolveDebtor menentukan pihak yang berutang: pelanggan yang dipilih,
+// pelanggan yang terhubung ke pasien, lalu penjamin atau pasien itu sendiri
+func resolveDebtor(tx *gorm.DB, src Source) (*debtor, error) {
+	var c customer.Customer
+	switch {
+	case src.CustomerID != nil:
+		found, err := customer.Find(tx, *src.CustomerID)
+		if err != nil {
+			return nil, err
+		}
+		c = *found
+	case src.PatientID != nil:
+		err := tx.Where("patient_id = ? AND active = ?", *src.PatientID, true).First(&c).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	if c.ID != 0 {
+		term := c.PaymentTermDays
+		if term == 0 {
+			term = DefaultTermDays
+		}
+		return &debtor{customerID: &c.ID, name: c.Name, termDays: term, creditLimit: c.CreditLimit}, nil
+	}
+	if src.PatientID == nil {
+		return nil, ErrDebtorRequired
+	}
+
+	var p patient.Patient
+	if err := tx.First(&p, *src.PatientID).Error; err != nil {
+		return nil, err
+	}
+	encrypted := p.GuarantorName
+	if encrypted == "" {
+		encrypted = p.FullName
+	}
+	name, err := utils.Decrypt(encrypted)
+	if err != nil {
+		return nil, err
+	}
+	return &debtor{name: name, termDays: DefaultTermDays}, nil
+}
+
+// checkLimit memastikan piutang terbuka pelanggan, di luar penjualan ini,
+// ditambah piutang baru tidak melebihi limit kreditnya. Baris pelanggan
+// dikunci lebih dulu agar dua penjualan kredit bersamaan tidak sama-sama
+// lolos dari sisa limit yang sama.
+func checkLimit(tx *gorm.DB, d *debtor, amount float64, src Source) error {
+	if d.customerID == nil || d.creditLimit <= 0 {
+		return nil
+	}
+	if err := customer.Lock(tx, *d.customerID); err != nil {
+		return err
+	}
+	var outstanding float64
+	err := tx.Model(&Receivable{}).
+		Where("customer_id = ? AND status = ? AND NOT (sale_type = ? AND sale_id = ?)",
+			*d.customerID, StatusOpen, src.SaleType, src.SaleID).
+		Select("COALESCE(SUM(balance), 0)").Scan(&outstanding).Error
+	if err != nil {
+		return err
+	}
+	if outstanding+amount > d.creditLimit {
+		available := math.Max(d.creditLimit-outstanding, 0)
+		return fmt.Errorf("%w: sisa limit Rp %s", ErrCreditLimit, utils.FormatRupiah(available))
+	}
+	return nil
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,49 @@
+package receivable
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSettle(t *testing.T) {
+	paidOff := time.Date(2026, time.February, 1, 9, 0, 0, 0, time.Local)
+	tests := []struct {
+		name       string
+		amount     float64
+		paid       float64
+		paidOffAt  *time.Time
+		balance    float64
+		status     string
+		keepPaidAt bool
+	}{
+		{name: "belum dibayar", amount: 150000, balance: 150000, status: StatusOpen},
+		{name: "dibayar sebagian", amount: 150000, paid: 50000, balance: 100000, status: StatusOpen},
+		{name: "sisa dibulatkan ke sen", amount: 100000.005, paid: 0.001, balance: 100000, status: StatusOpen},
+		{name: "lunas", amount: 150000, paid: 150000, balance: 0, status: StatusPaid},
+		{name: "lebih bayar tetap nol", amount: 150000, paid: 160000, balance: 0, status: StatusPaid},
+		{name: "selisih di bawah sen dianggap lunas", amount: 150000, paid: 149999.996, balance: 0, status: StatusPaid},
+		{name: "tanggal lunas tidak ditimpa", amount: 150000, paid: 150000, paidOffAt: &paidOff, balance: 0, status: StatusPaid, keepPaidAt: true},
+		{name: "nilai naik membuka kembali piutang", amount: 175000, paid: 150000, paidOffAt: &paidOff, balance: 25000, status: StatusOpen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Receivable{Amount: tt.amount, Paid: tt.paid, PaidOffAt: tt.paidOffAt}
+			settle(&r)
+			if r.Balance != tt.balance {
+				t.Errorf("Balance = %v, want %v", r.Balance, tt.balance)
+			}
+			if r.Status != tt.status {
+				t.Errorf("Status = %q, want %q", r.Status, tt.status)
+			}
+			switch {
+			case tt.status == StatusOpen && r.PaidOffAt != nil:
+				t.Errorf("PaidOffAt = %v, want nil", *r.PaidOffAt)
+			case tt.status == StatusPaid && r.PaidOffAt == nil:
+				t.Error("PaidOffAt is nil, want set")
+			case tt.keepPaidAt && !r.PaidOffAt.Equal(paidOff):
+				t.Errorf("PaidOffAt = %v, want %v", *r.PaidOffAt, paidOff)
+			}
+		})
+	}
+}
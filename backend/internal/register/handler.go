@@ -0,0 +1,86 @@
+package register
+
+import (
+	"errors"
+	"go-gin-auth/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) GetAll(c *gin.Context) {
+	registers, err := h.service.GetAll()
+	if err != nil {
+		utils.Respond(c, http.StatusInternalServerError, "Gagal mengambil daftar register", err.Error(), nil)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Daftar register berhasil diambil", nil, registers)
+}
+
+func (h *Handler) GetByID(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	reg, err := h.service.GetByID(uint(id))
+	if err != nil {
+		h.respondError(c, "Gagal mengambil register", err)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Detail register berhasil diambil", nil, reg)
+}
+
+func (h *Handler) Create(c *gin.Context) {
+	var input Register
+	if err := c.ShouldBind(&input); err != nil {
+		utils.Respond(c, http.StatusBadRequest, "Input tidak valid", err.Error(), nil)
+		return
+	}
+	reg, err := h.service.Create(&input)
+	if err != nil {
+		h.respondError(c, "Gagal membuat register", err)
+		return
+	}
+	utils.Respond(c, http.StatusCreated, "Register berhasil dibuat", nil, reg)
+}
+
+func (h *Handler) Update(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var input Register
+	if err := c.ShouldBind(&input); err != nil {
+		utils.Respond(c, http.StatusBadRequest, "Input tidak valid", err.Error(), nil)
+		return
+	}
+	reg, err := h.service.Update(uint(id), &input)
+	if err != nil {
+		h.respondError(c, "Gagal memperbarui register", err)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Register berhasil diperbarui", nil, reg)
+}
+
+func (h *Handler) Delete(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err := h.service.Delete(uint(id)); err != nil {
+		h.respondError(c, "Gagal menghapus register", err)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Register berhasil dihapus", nil, nil)
+}
+
+func (h *Handler) respondError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		utils.Respond(c, http.StatusNotFound, err.Error(), err.Error(), nil)
+	case errors.Is(err, ErrInvalidInput), errors.Is(err, ErrHasOpenShift):
+		utils.Respond(c, http.StatusBadRequest, err.Error(), err.Error(), nil)
+	default:
+		utils.Respond(c, http.StatusInternalServerError, message, err.Error(), nil)
+	}
+}
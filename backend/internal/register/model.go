@@ -0,0 +1,24 @@
+package register
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Register adalah mesin kasir/terminal. Setiap register hanya boleh
+// memiliki satu shift berstatus Buka pada satu waktu.
+type Register struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	Code      string         `gorm:"type:varchar(20);uniqueIndex;not null" json:"code" form:"code"`
+	Name      string         `gorm:"type:varchar(100);not null" json:"name" form:"name"`
+	Location  string         `gorm:"type:varchar(100)" json:"location" form:"location"`
+	Active    bool           `gorm:"not null;default:true" json:"active" form:"active"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+func (Register) TableName() string {
+	return "registers"
+}
@@ -0,0 +1,21 @@
+package register
+
+import (
+	"go-gin-auth/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRouter(api *gin.RouterGroup) {
+	service := NewService(config.DB)
+	handler := NewHandler(service)
+
+	registers := api.Group("/registers")
+	{
+		registers.GET("", handler.GetAll)
+		registers.GET("/:id", handler.GetByID)
+		registers.POST("", handler.Create)
+		registers.PUT("/:id", handler.Update)
+		registers.DELETE("/:id", handler.Delete)
+	}
+}
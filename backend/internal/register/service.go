@@ -0,0 +1,102 @@
+package register
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotFound     = errors.New("register tidak ditemukan")
+	ErrInactive     = errors.New("register tidak aktif")
+	ErrInvalidInput = errors.New("data register tidak valid")
+	ErrHasOpenShift = errors.New("register masih memiliki shift yang buka")
+)
+
+type Service struct {
+	db *gorm.DB
+}
+
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db}
+}
+
+func (s *Service) GetAll() ([]Register, error) {
+	var registers []Register
+	if err := s.db.Order("code").Find(&registers).Error; err != nil {
+		return nil, err
+	}
+	return registers, nil
+}
+
+func (s *Service) GetByID(id uint) (*Register, error) {
+	return Find(s.db, id)
+}
+
+func (s *Service) Create(input *Register) (*Register, error) {
+	if input.Code == "" || input.Name == "" {
+		return nil, fmt.Errorf("%w: kode dan nama wajib diisi", ErrInvalidInput)
+	}
+	input.ID = 0
+	if err := s.db.Create(input).Error; err != nil {
+		return nil, err
+	}
+	// kolom ber-default diabaikan GORM saat insert bila bernilai false
+	if !input.Active {
+		if err := s.db.Model(input).Update("active", false).Error; err != nil {
+			return nil, err
+		}
+	}
+	return input, nil
+}
+
+func (s *Service) Update(id uint, input *Register) (*Register, error) {
+	existing, err := Find(s.db, id)
+	if err != nil {
+		return nil, err
+	}
+	if input.Code == "" || input.Name == "" {
+		return nil, fmt.Errorf("%w: kode dan nama wajib diisi", ErrInvalidInput)
+	}
+
+	existing.Code = input.Code
+	existing.Name = input.Name
+	existing.Location = input.Location
+	existing.Active = input.Active
+	if err := s.db.Save(existing).Error; err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+func (s *Service) Delete(id uint) error {
+	var open int64
+	if err := s.db.Table("shifts").Where("register_id = ? AND status = ?", id, "Buka").Count(&open).Error; err != nil {
+		return err
+	}
+	if open > 0 {
+		return ErrHasOpenShift
+	}
+
+	result := s.db.Delete(&Register{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Find mengambil register berdasarkan ID
+func Find(db *gorm.DB, id uint) (*Register, error) {
+	var reg Register
+	if err := db.First(&reg, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &reg, nil
+}
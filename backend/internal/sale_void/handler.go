@@ -0,0 +1,140 @@
+package sale_void
+
+import (
+	"errors"
+	"go-gin-auth/internal/payment"
+	"go-gin-auth/internal/receivable"
+	"go-gin-auth/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) Request(c *gin.Context) {
+	var input Request
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Respond(c, http.StatusBadRequest, "Input tidak valid", err.Error(), nil)
+		return
+	}
+	void, err := h.service.Request(&input, utils.GetCurrentUserID(c))
+	if err != nil {
+		h.respondError(c, "Gagal mengajukan pembatalan", err)
+		return
+	}
+	utils.Respond(c, http.StatusCreated, "Pembatalan berhasil diajukan dan menunggu persetujuan supervisor", nil, void)
+}
+
+func (h *Handler) Approve(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var input Decision
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Respond(c, http.StatusBadRequest, "Input tidak valid", err.Error(), nil)
+		return
+	}
+	void, err := h.service.Approve(uint(id), &input)
+	if err != nil {
+		h.respondError(c, "Gagal menyetujui pembatalan", err)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Penjualan berhasil dibatalkan", nil, void)
+}
+
+func (h *Handler) Reject(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var input Decision
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Respond(c, http.StatusBadRequest, "Input tidak valid", err.Error(), nil)
+		return
+	}
+	void, err := h.service.Reject(uint(id), &input)
+	if err != nil {
+		h.respondError(c, "Gagal menolak pembatalan", err)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Pengajuan pembatalan ditolak", nil, void)
+}
+
+func (h *Handler) GetAll(c *gin.Context) {
+	filter, ok := parseFilter(c)
+	if !ok {
+		return
+	}
+	voids, err := h.service.List(filter)
+	if err != nil {
+		utils.Respond(c, http.StatusInternalServerError, "Gagal mengambil daftar pembatalan", err.Error(), nil)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Daftar pembatalan berhasil diambil", nil, voids)
+}
+
+func (h *Handler) GetByID(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	void, err := h.service.GetByID(uint(id))
+	if err != nil {
+		h.respondError(c, "Gagal mengambil pembatalan", err)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Detail pembatalan berhasil diambil", nil, void)
+}
+
+func (h *Handler) Report(c *gin.Context) {
+	filter, ok := parseFilter(c)
+	if !ok {
+		return
+	}
+	report, err := h.service.CashierReport(filter)
+	if err != nil {
+		utils.Respond(c, http.StatusInternalServerError, "Gagal mengambil laporan pembatalan", err.Error(), nil)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Laporan pembatalan per kasir berhasil diambil", nil, report)
+}
+
+func parseFilter(c *gin.Context) (Filter, bool) {
+	filter := Filter{
+		Status:   c.Query("status"),
+		SaleType: c.Query("type"),
+	}
+	if v := c.Query("start_date"); v != "" {
+		start, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			utils.Respond(c, http.StatusBadRequest, "Format start_date harus YYYY-MM-DD", err.Error(), nil)
+			return filter, false
+		}
+		filter.Start = &start
+	}
+	if v := c.Query("end_date"); v != "" {
+		end, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			utils.Respond(c, http.StatusBadRequest, "Format end_date harus YYYY-MM-DD", err.Error(), nil)
+			return filter, false
+		}
+		end = end.Add(24*time.Hour - time.Nanosecond)
+		filter.End = &end
+	}
+	return filter, true
+}
+
+func (h *Handler) respondError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound), errors.Is(err, ErrSaleNotFound):
+		utils.Respond(c, http.StatusNotFound, err.Error(), err.Error(), nil)
+	case errors.Is(err, ErrInvalidSupervisor), errors.Is(err, ErrSupervisorLocked), errors.Is(err, ErrSelfApproval):
+		utils.Respond(c, http.StatusForbidden, err.Error(), err.Error(), nil)
+	case errors.Is(err, ErrAlreadyRequested), errors.Is(err, ErrNotPending), errors.Is(err, payment.ErrSaleVoided),
+		errors.Is(err, receivable.ErrHasPayments):
+		utils.Respond(c, http.StatusConflict, err.Error(), err.Error(), nil)
+	default:
+		utils.Respond(c, http.StatusInternalServerError, message, err.Error(), nil)
+	}
+}
@@ -0,0 +1,83 @@
+package sale_void
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Status pengajuan pembatalan
+const (
+	StatusPending  = "Menunggu"
+	StatusApproved = "Disetujui"
+	StatusRejected = "Ditolak"
+)
+
+// Void adalah pengajuan pembatalan penjualan oleh kasir. Penjualan baru
+// dibatalkan setelah pengajuan disetujui supervisor.
+type Void struct {
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	SaleType        string     `gorm:"type:varchar(20);not null;index:idx_sale_voids_sale" json:"sale_type"`
+	SaleID          uint       `gorm:"not null;index:idx_sale_voids_sale" json:"sale_id"`
+	SaleCode        string     `gorm:"type:varchar(50);not null" json:"sale_code"`
+	Amount          float64    `gorm:"type:decimal(15,2);not null" json:"amount"`
+	CashierName     string     `gorm:"type:varchar(100)" json:"cashier_name"`
+	Reason          string     `gorm:"type:text;not null" json:"reason"`
+	Status          string     `gorm:"type:varchar(20);not null;index" json:"status"`
+	RequestedBy     uint       `gorm:"not null" json:"requested_by"`
+	RequestedByName string     `gorm:"type:varchar(100)" json:"requested_by_name"`
+	ApprovedBy      *uint      `json:"approved_by,omitempty"`
+	ApproverName    string     `gorm:"type:varchar(100)" json:"approver_name,omitempty"`
+	DecisionNote    string     `gorm:"type:text" json:"decision_note,omitempty"`
+	DecidedAt       *time.Time `json:"decided_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+func (Void) TableName() string {
+	return "sale_voids"
+}
+
+// Sale adalah ringkasan penjualan yang diajukan untuk dibatalkan
+type Sale struct {
+	Code        string
+	Amount      float64
+	CashierName string
+	Voided      bool
+}
+
+// Target adalah jenis penjualan yang dapat dibatalkan. Void dijalankan di
+// dalam transaksi yang sama dengan perubahan status pengajuan.
+type Target interface {
+	VoidInfo(id uint) (*Sale, error)
+	Void(tx *gorm.DB, id uint) error
+}
+
+type Request struct {
+	SaleType string `json:"sale_type" binding:"required,oneof=regular prescription"`
+	SaleID   uint   `json:"sale_id" binding:"required"`
+	Reason   string `json:"reason" binding:"required"`
+}
+
+// Decision memuat login kedua supervisor yang menyetujui atau menolak
+type Decision struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	Note     string `json:"note"`
+}
+
+type Filter struct {
+	Status   string
+	SaleType string
+	Start    *time.Time
+	End      *time.Time
+}
+
+// CashierReport merekap pembatalan per kasir
+type CashierReport struct {
+	CashierName string  `json:"cashier_name"`
+	Approved    int64   `json:"approved"`
+	Amount      float64 `json:"amount"`
+	Pending     int64   `json:"pending"`
+	Rejected    int64   `json:"rejected"`
+}
@@ -0,0 +1,22 @@
+package sale_void
+
+import (
+	"go-gin-auth/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func VoidRouter(api *gin.RouterGroup, targets map[string]Target) {
+	service := NewService(config.DB, targets)
+	handler := NewHandler(service)
+
+	voids := api.Group("/sales/voids")
+	{
+		voids.GET("", handler.GetAll)
+		voids.GET("/report", handler.Report)
+		voids.GET("/:id", handler.GetByID)
+		voids.POST("", handler.Request)
+		voids.POST("/:id/approve", handler.Approve)
+		voids.POST("/:id/reject", handler.Reject)
+	}
+}
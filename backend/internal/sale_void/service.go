@@ -0,0 +1,213 @@
+package sale_void
+
+import (
+	"errors"
+	"fmt"
+	"go-gin-auth/internal/payment"
+	"go-gin-auth/model"
+	"go-gin-auth/service"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotFound          = errors.New("pengajuan pembatalan tidak ditemukan")
+	ErrSaleNotFound      = errors.New("penjualan tidak ditemukan")
+	ErrAlreadyRequested  = errors.New("penjualan ini sudah memiliki pengajuan pembatalan yang menunggu")
+	ErrNotPending        = errors.New("pengajuan pembatalan sudah diputuskan")
+	ErrInvalidSupervisor = errors.New("email atau password supervisor salah, atau akun bukan admin aktif")
+	ErrSupervisorLocked  = errors.New("akun supervisor sedang terkunci")
+	ErrSelfApproval      = errors.New("pembatalan harus disetujui oleh supervisor lain")
+)
+
+type Service struct {
+	db      *gorm.DB
+	targets map[string]Target
+}
+
+func NewService(db *gorm.DB, targets map[string]Target) *Service {
+	return &Service{db: db, targets: targets}
+}
+
+// Request mencatat pengajuan pembatalan tanpa mengubah penjualan
+func (s *Service) Request(req *Request, userID uint) (*Void, error) {
+	target, ok := s.targets[req.SaleType]
+	if !ok {
+		return nil, ErrSaleNotFound
+	}
+	sale, err := target.VoidInfo(req.SaleID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSaleNotFound
+		}
+		return nil, err
+	}
+	if sale.Voided {
+		return nil, payment.ErrSaleVoided
+	}
+
+	var pending int64
+	err = s.db.Model(&Void{}).
+		Where("sale_type = ? AND sale_id = ? AND status = ?", req.SaleType, req.SaleID, StatusPending).
+		Count(&pending).Error
+	if err != nil {
+		return nil, err
+	}
+	if pending > 0 {
+		return nil, ErrAlreadyRequested
+	}
+
+	var requester model.User
+	if err := s.db.Select("id", "full_name").First(&requester, userID).Error; err != nil {
+		return nil, err
+	}
+
+	void := &Void{
+		SaleType:        req.SaleType,
+		SaleID:          req.SaleID,
+		SaleCode:        sale.Code,
+		Amount:          sale.Amount,
+		CashierName:     sale.CashierName,
+		Reason:          req.Reason,
+		Status:          StatusPending,
+		RequestedBy:     userID,
+		RequestedByName: requester.FullName,
+	}
+	if err := s.db.Create(void).Error; err != nil {
+		return nil, err
+	}
+	return void, nil
+}
+
+// Approve memverifikasi login supervisor lalu membatalkan penjualan:
+// stok dikembalikan dan status penjualan menjadi Batal
+func (s *Service) Approve(id uint, decision *Decision) (*Void, error) {
+	return s.decide(id, decision, StatusApproved)
+}
+
+// Reject menolak pengajuan; penjualan tidak berubah
+func (s *Service) Reject(id uint, decision *Decision) (*Void, error) {
+	return s.decide(id, decision, StatusRejected)
+}
+
+func (s *Service) decide(id uint, decision *Decision, status string) (*Void, error) {
+	void, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if void.Status != StatusPending {
+		return nil, ErrNotPending
+	}
+
+	supervisor, err := s.verifySupervisor(decision.Email, decision.Password)
+	if err != nil {
+		return nil, err
+	}
+	if supervisor.ID == void.RequestedBy {
+		return nil, ErrSelfApproval
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if status == StatusApproved {
+			if err := s.targets[void.SaleType].Void(tx, void.SaleID); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		void.Status = status
+		void.ApprovedBy = &supervisor.ID
+		void.ApproverName = supervisor.FullName
+		void.DecisionNote = decision.Note
+		void.DecidedAt = &now
+		result := tx.Model(&Void{}).Where("id = ? AND status = ?", void.ID, StatusPending).
+			Updates(map[string]interface{}{
+				"status":        void.Status,
+				"approved_by":   void.ApprovedBy,
+				"approver_name": void.ApproverName,
+				"decision_note": void.DecisionNote,
+				"decided_at":    void.DecidedAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotPending
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return void, nil
+}
+
+// verifySupervisor memeriksa login kedua supervisor dengan aturan penguncian
+// akun yang sama seperti login biasa
+func (s *Service) verifySupervisor(email, password string) (*model.User, error) {
+	user, err := service.VerifyApprover(s.db, email, password, "admin")
+	switch {
+	case errors.Is(err, service.ErrInvalidApprover):
+		return nil, ErrInvalidSupervisor
+	case errors.Is(err, service.ErrApproverLocked):
+		return nil, ErrSupervisorLocked
+	}
+	return user, err
+}
+
+func (s *Service) GetByID(id uint) (*Void, error) {
+	var void Void
+	if err := s.db.First(&void, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &void, nil
+}
+
+func (s *Service) List(filter Filter) ([]Void, error) {
+	voids := []Void{}
+	if err := s.filtered(filter).Order("created_at DESC").Find(&voids).Error; err != nil {
+		return nil, err
+	}
+	return voids, nil
+}
+
+// CashierReport merekap jumlah dan nilai pembatalan per kasir
+func (s *Service) CashierReport(filter Filter) ([]CashierReport, error) {
+	filter.Status = ""
+	report := []CashierReport{}
+	err := s.filtered(filter).
+		Select(fmt.Sprintf(`cashier_name,
+			COUNT(*) FILTER (WHERE status = '%[1]s') AS approved,
+			COALESCE(SUM(amount) FILTER (WHERE status = '%[1]s'), 0) AS amount,
+			COUNT(*) FILTER (WHERE status = '%[2]s') AS pending,
+			COUNT(*) FILTER (WHERE status = '%[3]s') AS rejected`,
+			StatusApproved, StatusPending, StatusRejected)).
+		Group("cashier_name").
+		Order("amount DESC").
+		Scan(&report).Error
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (s *Service) filtered(filter Filter) *gorm.DB {
+	query := s.db.Model(&Void{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.SaleType != "" {
+		query = query.Where("sale_type = ?", filter.SaleType)
+	}
+	if filter.Start != nil {
+		query = query.Where("created_at >= ?", *filter.Start)
+	}
+	if filter.End != nil {
+		query = query.Where("created_at <= ?", *filter.End)
+	}
+	return query
+}
@@ -1,28 +1,46 @@
 package sales
 
 import (
+	"go-gin-auth/internal/dispensing"
+	"go-gin-auth/internal/payment"
 	"time"
 
 	"gorm.io/gorm"
 )
 
 type SalesRegular struct {
-	ID              uint               `gorm:"primaryKey" json:"id"`
-	SalesCode       string             `gorm:"uniqueIndex;not null" json:"sales_code"`
-	TransactionDate time.Time          `gorm:"not null" json:"transaction_date"`
-	CashierName     string             `gorm:"not null" json:"cashier_name"`
-	CustomerName    *string            `json:"customer_name,omitempty"`
-	CustomerContact *string            `json:"customer_contact,omitempty"`
-	Description     *string            `json:"description,omitempty"`
-	SubTotal        int                `gorm:"not null" json:"sub_total"`
-	TotalDiscount   *int               `json:"total_discount,omitempty"`
-	TotalPay        int                `gorm:"not null" json:"total_pay"`
-	PaymentMethod   string             `gorm:"not null" json:"payment_method"`
-	ShiftID         *uint              `json:"shift_id,omitempty"`
-	CreatedAt       time.Time          `json:"created_at"`
-	UpdatedAt       time.Time          `json:"updated_at"`
-	DeletedAt       gorm.DeletedAt     `gorm:"index" json:"deleted_at"`
-	Items           []SalesRegularItem `gorm:"foreignKey:SalesRegularID" json:"items"`
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	SalesCode       string    `gorm:"uniqueIndex;not null" json:"sales_code"`
+	TransactionDate time.Time `gorm:"not null" json:"transaction_date"`
+	CashierName     string    `gorm:"not null" json:"cashier_name"`
+	CustomerID      *uint     `gorm:"index" json:"customer_id,omitempty"`
+	CustomerName    *string   `json:"customer_name,omitempty"`
+	CustomerContact *string   `json:"customer_contact,omitempty"`
+	Description     *string   `json:"description,omitempty"`
+	SubTotal        int       `gorm:"not null" json:"sub_total"`
+	TotalDiscount   *int      `json:"total_discount,omitempty"`
+	// PromotionDiscount adalah bagian TotalDiscount yang berasal dari promosi
+	PromotionDiscount int `gorm:"not null;default:0" json:"promotion_discount"`
+	// PointsRedeemed dan PointsDiscount adalah poin pelanggan yang ditukar
+	// beserta potongannya (bagian dari TotalDiscount); PointsEarned adalah
+	// poin yang diperoleh dari transaksi ini
+	PointsRedeemed int `gorm:"not null;default:0" json:"points_redeemed"`
+	PointsDiscount int `gorm:"not null;default:0" json:"points_discount"`
+	PointsEarned   int `gorm:"not null;default:0" json:"points_earned"`
+	// TaxBase dan TaxAmount adalah DPP dan PPN seluruh baris
+	TaxBase       int                `gorm:"not null;default:0" json:"tax_base"`
+	TaxAmount     int                `gorm:"not null;default:0" json:"tax_amount"`
+	TotalPay      int                `gorm:"not null" json:"total_pay"`
+	PaymentMethod string             `gorm:"not null" json:"payment_method"`
+	ShiftID       *uint              `json:"shift_id,omitempty"`
+	RegisterID    *uint              `gorm:"index" json:"register_id,omitempty"`
+	Status        string             `gorm:"type:varchar(20);not null;default:'Selesai';index" json:"status"`
+	VoidedAt      *time.Time         `json:"voided_at,omitempty"`
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt     `gorm:"index" json:"deleted_at"`
+	Items         []SalesRegularItem `gorm:"foreignKey:SalesRegularID" json:"items"`
+	Payments      []payment.Payment  `gorm:"polymorphic:Sale;polymorphicValue:regular" json:"payments"`
 }
 
 type SalesRegularItem struct {
@@ -35,7 +53,16 @@ type SalesRegularItem struct {
 	Unit           string         `gorm:"not null" json:"unit"`
 	UnitPrice      int            `gorm:"not null" json:"unit_price"`
 	SubTotal       int            `gorm:"not null" json:"sub_total"`
+	PromotionID    *uint          `json:"promotion_id,omitempty"`
+	Discount       int            `gorm:"not null;default:0" json:"discount"`
+	TaxRate        float64        `gorm:"type:decimal(5,2);not null;default:0" json:"tax_rate"`
+	TaxBase        int            `gorm:"not null;default:0" json:"tax_base"`
+	TaxAmount      int            `gorm:"not null;default:0" json:"tax_amount"`
 	DeletedAt      gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+
+	// StockID adalah baris stok yang dikurangi item ini; pembaruan dan
+	// pembatalan mengembalikan stok ke baris yang sama
+	StockID uint `gorm:"not null;default:0" json:"stock_id"`
 }
 type SalesRegularItemRequest struct {
 	ProductID   uint   `json:"product_id"`
@@ -48,15 +75,64 @@ type SalesRegularItemRequest struct {
 }
 
 type SalesRegularRequest struct {
-	TransactionDate time.Time                 `json:"transaction_date"`
-	CashierName     string                    `json:"cashier_name"`
-	CustomerName    *string                   `json:"customer_name"`
-	CustomerContact *string                   `json:"customer_contact"`
-	Description     *string                   `json:"description"`
-	SubTotal        int                       `json:"sub_total"`
-	TotalDiscount   *int                      `json:"total_discount"`
-	TotalPay        int                       `json:"total_pay"`
-	PaymentMethod   string                    `json:"payment_method"`
-	ShiftID         *uint                     `json:"shift_id"`
-	Items           []SalesRegularItemRequest `json:"items"`
+	TransactionDate time.Time `json:"transaction_date"`
+	CashierName     string    `json:"cashier_name"`
+	CustomerID      *uint     `json:"customer_id"`
+	CustomerName    *string   `json:"customer_name"`
+	CustomerContact *string   `json:"customer_contact"`
+	Description     *string   `json:"description"`
+	SubTotal        int       `json:"sub_total"`
+	// TotalDiscount pada request hanya potongan manual kasir; potongan
+	// promosi dihitung server dan ditambahkan saat transaksi disimpan
+	TotalDiscount *int                      `json:"total_discount"`
+	TotalPay      int                       `json:"total_pay"`
+	PaymentMethod string                    `json:"payment_method"`
+	Items         []SalesRegularItemRequest `json:"items"`
+	Payments      []payment.PaymentRequest  `json:"payments"`
+	// RedeemPoints adalah poin pelanggan yang ditukar menjadi potongan
+	RedeemPoints int `json:"redeem_points"`
+
+	// CashierID diisi dari token; penjualan otomatis terikat ke shift
+	// aktif kasir tersebut
+	CashierID uint `json:"-"`
+
+	// PharmacistOverrideReason adalah alasan apoteker menyerahkan obat keras
+	// tanpa resep; tanpa alasan penjualan yang memuat obat keras ditolak
+	PharmacistOverrideReason string `json:"pharmacist_override_reason"`
+	// PharmacistEmail dan PharmacistPassword adalah login ulang apoteker
+	// yang menyetujui alasan di atas
+	PharmacistEmail    string `json:"pharmacist_email"`
+	PharmacistPassword string `json:"pharmacist_password"`
+
+	// OnCreated dijalankan di dalam transaksi penjualan sebelum commit,
+	// dipakai pemanggil yang harus menandai sumber penjualan (penjualan
+	// offline, penjualan tertunda) secara atomik; error membatalkan penjualan
+	OnCreated func(tx *gorm.DB, sale *SalesRegular) error `json:"-"`
+	// ShiftID membukukan penjualan ke shift Buka tertentu, bukan shift
+	// aktif kasir; dipakai saat supervisor membukukan penjualan offline
+	ShiftID *uint `json:"-"`
+}
+
+func (r *SalesRegularRequest) productIDs() []uint {
+	ids := make([]uint, 0, len(r.Items))
+	for _, item := range r.Items {
+		ids = append(ids, item.ProductID)
+	}
+	return ids
+}
+
+func (r *SalesRegularRequest) approval() dispensing.Approval {
+	return dispensing.Approval{
+		Reason:   r.PharmacistOverrideReason,
+		Email:    r.PharmacistEmail,
+		Password: r.PharmacistPassword,
+	}
+}
+
+func (r *SalesRegularRequest) dispensingLines() []dispensing.Line {
+	lines := make([]dispensing.Line, 0, len(r.Items))
+	for _, item := range r.Items {
+		lines = append(lines, dispensing.Line{ProductID: item.ProductID, Quantity: item.Qty})
+	}
+	return lines
 }
@@ -0,0 +1,239 @@
+package sales
+
+import (
+	"errors"
+	"go-gin-auth/internal/numbering"
+	"go-gin-auth/internal/shift"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrHeldSaleNotFound = errors.New("transaksi tertahan tidak ditemukan")
+
+// HeldSale adalah keranjang kasir yang diparkir sementara. Stok belum
+// dikurangi sampai transaksi diselesaikan menjadi SalesRegular.
+type HeldSale struct {
+	ID              uint           `gorm:"primaryKey" json:"id"`
+	HoldCode        string         `gorm:"uniqueIndex;not null" json:"hold_code"`
+	ShiftID         *uint          `gorm:"index" json:"shift_id,omitempty"`
+	CashierID       uint           `gorm:"index" json:"cashier_id"`
+	CashierName     string         `gorm:"not null" json:"cashier_name"`
+	CustomerID      *uint          `json:"customer_id,omitempty"`
+	CustomerName    *string        `json:"customer_name,omitempty"`
+	CustomerContact *string        `json:"customer_contact,omitempty"`
+	Description     *string        `json:"description,omitempty"`
+	Note            string         `json:"note"`
+	SubTotal        int            `gorm:"not null" json:"sub_total"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
+	Items           []HeldSaleItem `gorm:"foreignKey:HeldSaleID" json:"items"`
+}
+
+type HeldSaleItem struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	HeldSaleID  uint   `gorm:"not null;index" json:"held_sale_id"`
+	ProductID   uint   `gorm:"not null" json:"product_id"`
+	ProductCode string `gorm:"not null" json:"product_code"`
+	ProductName string `gorm:"not null" json:"product_name"`
+	Qty         int    `gorm:"not null" json:"qty"`
+	Unit        string `gorm:"not null" json:"unit"`
+	UnitPrice   int    `gorm:"not null" json:"unit_price"`
+	SubTotal    int    `gorm:"not null" json:"sub_total"`
+}
+
+type HeldSaleRequest struct {
+	CashierName     string                    `json:"cashier_name"`
+	CustomerID      *uint                     `json:"customer_id"`
+	CustomerName    *string                   `json:"customer_name"`
+	CustomerContact *string                   `json:"customer_contact"`
+	Description     *string                   `json:"description"`
+	Note            string                    `json:"note"`
+	Items           []SalesRegularItemRequest `json:"items" binding:"required,min=1"`
+}
+
+// HeldSaleFilter membatasi daftar transaksi tertahan per shift atau kasir
+type HeldSaleFilter struct {
+	ShiftID   *uint
+	CashierID *uint
+}
+
+type HeldSaleService struct {
+	db    *gorm.DB
+	sales SalesRegularService
+}
+
+func NewHeldSaleService(db *gorm.DB, sales SalesRegularService) *HeldSaleService {
+	return &HeldSaleService{db: db, sales: sales}
+}
+
+func (s *HeldSaleService) List(filter HeldSaleFilter) ([]HeldSale, error) {
+	query := s.db.Preload("Items").Order("created_at")
+	if filter.ShiftID != nil {
+		query = query.Where("shift_id = ?", *filter.ShiftID)
+	}
+	if filter.CashierID != nil {
+		query = query.Where("cashier_id = ?", *filter.CashierID)
+	}
+
+	var held []HeldSale
+	if err := query.Find(&held).Error; err != nil {
+		return nil, err
+	}
+	return held, nil
+}
+
+func (s *HeldSaleService) GetByID(id uint) (*HeldSale, error) {
+	var held HeldSale
+	if err := s.db.Preload("Items").First(&held, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrHeldSaleNotFound
+		}
+		return nil, err
+	}
+	return &held, nil
+}
+
+// Hold memarkir keranjang tanpa mengurangi stok pada shift aktif kasir
+func (s *HeldSaleService) Hold(req *HeldSaleRequest, cashierID uint) (*HeldSale, error) {
+	current, err := shift.Current(s.db, cashierID)
+	if err != nil {
+		return nil, err
+	}
+
+	held := &HeldSale{
+		ShiftID:   &current.ID,
+		CashierID: cashierID,
+	}
+	held.apply(req)
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		code, err := numbering.Next(tx, numbering.HeldSale, time.Now())
+		if err != nil {
+			return err
+		}
+		held.HoldCode = code
+		return tx.Create(held).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return held, nil
+}
+
+// Update mengganti isi keranjang yang diparkir, misalnya saat dilanjutkan
+// di terminal lain lalu diparkir kembali
+func (s *HeldSaleService) Update(id uint, req *HeldSaleRequest) (*HeldSale, error) {
+	held, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("held_sale_id = ?", id).Delete(&HeldSaleItem{}).Error; err != nil {
+			return err
+		}
+		held.apply(req)
+		return tx.Save(held).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return held, nil
+}
+
+// Finalize menyelesaikan transaksi tertahan menjadi penjualan reguler pada
+// shift aktif kasir yang menyelesaikannya (req.CashierID). Item
+// pada req dipakai bila diisi (keranjang diubah saat dilanjutkan), selain itu
+// item yang diparkir yang dipakai.
+func (s *HeldSaleService) Finalize(id uint, req *SalesRegularRequest) (*SalesRegular, error) {
+	held, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.Items) == 0 {
+		for _, item := range held.Items {
+			req.Items = append(req.Items, SalesRegularItemRequest{
+				ProductID:   item.ProductID,
+				ProductCode: item.ProductCode,
+				ProductName: item.ProductName,
+				Qty:         item.Qty,
+				Unit:        item.Unit,
+				UnitPrice:   item.UnitPrice,
+				SubTotal:    item.SubTotal,
+			})
+		}
+	}
+	if req.CashierName == "" {
+		req.CashierName = held.CashierName
+	}
+	if req.CustomerID == nil {
+		req.CustomerID = held.CustomerID
+	}
+	if req.CustomerName == nil {
+		req.CustomerName = held.CustomerName
+	}
+	if req.CustomerContact == nil {
+		req.CustomerContact = held.CustomerContact
+	}
+	if req.Description == nil {
+		req.Description = held.Description
+	}
+	if req.TransactionDate.IsZero() {
+		req.TransactionDate = time.Now()
+	}
+
+	// Transaksi tertahan diklaim di dalam transaksi penjualan: bila penjualan
+	// gagal, keranjang tetap tersimpan; bila dua kasir menyelesaikan
+	// bersamaan, yang kedua tidak menemukan baris untuk diklaim dan batal
+	req.OnCreated = func(tx *gorm.DB, _ *SalesRegular) error {
+		result := tx.Delete(&HeldSale{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrHeldSaleNotFound
+		}
+		return tx.Where("held_sale_id = ?", id).Delete(&HeldSaleItem{}).Error
+	}
+	return s.sales.Create(req)
+}
+
+// Discard membuang transaksi tertahan
+func (s *HeldSaleService) Discard(id uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&HeldSale{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrHeldSaleNotFound
+		}
+		return tx.Where("held_sale_id = ?", id).Delete(&HeldSaleItem{}).Error
+	})
+}
+
+func (h *HeldSale) apply(req *HeldSaleRequest) {
+	h.CashierName = req.CashierName
+	h.CustomerID = req.CustomerID
+	h.CustomerName = req.CustomerName
+	h.CustomerContact = req.CustomerContact
+	h.Description = req.Description
+	h.Note = req.Note
+	h.SubTotal = 0
+	h.Items = make([]HeldSaleItem, 0, len(req.Items))
+	for _, item := range req.Items {
+		h.Items = append(h.Items, HeldSaleItem{
+			ProductID:   item.ProductID,
+			ProductCode: item.ProductCode,
+			ProductName: item.ProductName,
+			Qty:         item.Qty,
+			Unit:        item.Unit,
+			UnitPrice:   item.UnitPrice,
+			SubTotal:    item.SubTotal,
+		})
+		h.SubTotal += item.SubTotal
+	}
+}
@@ -0,0 +1,153 @@
+package sales
+
+import (
+	"errors"
+	"go-gin-auth/internal/dispensing"
+	"go-gin-auth/internal/narcotic"
+	"go-gin-auth/internal/shift"
+	"go-gin-auth/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HeldSaleHandler struct {
+	service *HeldSaleService
+}
+
+func NewHeldSaleHandler(service *HeldSaleService) *HeldSaleHandler {
+	return &HeldSaleHandler{service: service}
+}
+
+// ✅ GET /api/sales/regular/held?shift_id=&cashier_id=
+func (h *HeldSaleHandler) GetAll(c *gin.Context) {
+	var filter HeldSaleFilter
+	if v, err := strconv.ParseUint(c.Query("shift_id"), 10, 32); err == nil {
+		id := uint(v)
+		filter.ShiftID = &id
+	}
+	if v, err := strconv.ParseUint(c.Query("cashier_id"), 10, 32); err == nil {
+		id := uint(v)
+		filter.CashierID = &id
+	}
+
+	data, err := h.service.List(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengambil data", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": data, "total": len(data)})
+}
+
+// ✅ GET /api/sales/regular/held/:id
+func (h *HeldSaleHandler) GetByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "ID tidak valid"})
+		return
+	}
+
+	data, err := h.service.GetByID(uint(id))
+	if err != nil {
+		h.respondError(c, "Data tidak ditemukan", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+// ✅ POST /api/sales/regular/held
+func (h *HeldSaleHandler) Hold(c *gin.Context) {
+	var req HeldSaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Payload tidak valid", "error": err.Error()})
+		return
+	}
+
+	data, err := h.service.Hold(&req, utils.GetCurrentUserID(c))
+	if err != nil {
+		h.respondError(c, "Gagal menahan transaksi", err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": data})
+}
+
+// ✅ PUT /api/sales/regular/held/:id
+func (h *HeldSaleHandler) Update(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "ID tidak valid"})
+		return
+	}
+
+	var req HeldSaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Payload tidak valid", "error": err.Error()})
+		return
+	}
+
+	data, err := h.service.Update(uint(id), &req)
+	if err != nil {
+		h.respondError(c, "Gagal mengupdate transaksi tertahan", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+// ✅ POST /api/sales/regular/held/:id/finalize
+func (h *HeldSaleHandler) Finalize(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "ID tidak valid"})
+		return
+	}
+
+	var req SalesRegularRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Payload tidak valid", "error": err.Error()})
+		return
+	}
+
+	req.CashierID = utils.GetCurrentUserID(c)
+
+	data, err := h.service.Finalize(uint(id), &req)
+	if err != nil {
+		h.respondError(c, "Gagal menyelesaikan transaksi", err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": data})
+}
+
+// ✅ DELETE /api/sales/regular/held/:id
+func (h *HeldSaleHandler) Discard(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "ID tidak valid"})
+		return
+	}
+
+	if err := h.service.Discard(uint(id)); err != nil {
+		h.respondError(c, "Gagal membuang transaksi tertahan", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Transaksi tertahan berhasil dibuang"})
+}
+
+func (h *HeldSaleHandler) respondError(c *gin.Context, message string, err error) {
+	if errors.Is(err, ErrHeldSaleNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
+		return
+	}
+	if errors.Is(err, shift.ErrNoOpenShift) || errors.Is(err, shift.ErrShiftNotOpen) {
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+		return
+	}
+	if respondRestricted(c, err) {
+		return
+	}
+	if errors.Is(err, narcotic.ErrPrescriptionRequired) || errors.Is(err, dispensing.ErrOWALimit) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"message": message, "error": err.Error()})
+}
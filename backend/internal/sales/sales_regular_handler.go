@@ -1,10 +1,20 @@
 package sales
 
 import (
+	"errors"
+	"fmt"
+	"go-gin-auth/internal/customer"
+	"go-gin-auth/internal/dispensing"
+	"go-gin-auth/internal/narcotic"
+	"go-gin-auth/internal/receipt"
+	"go-gin-auth/internal/receivable"
+	"go-gin-auth/internal/shift"
+	"go-gin-auth/utils"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type SalesRegularHandler struct {
@@ -60,8 +70,20 @@ func (h *SalesRegularHandler) Create(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "Payload tidak valid", "error": err.Error()})
 		return
 	}
+	req.CashierID = utils.GetCurrentUserID(c)
 
 	data, err := h.service.Create(&req)
+	if errors.Is(err, shift.ErrNoOpenShift) || errors.Is(err, shift.ErrShiftNotOpen) {
+		c.JSON(http.StatusConflict, gin.H{"message": err.Error(), "error": err.Error()})
+		return
+	}
+	if respondRestricted(c, err) {
+		return
+	}
+	if isCustomerError(err) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error(), "error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal membuat transaksi", "error": err.Error()})
 		return
@@ -85,7 +107,16 @@ func (h *SalesRegularHandler) Update(c *gin.Context) {
 		return
 	}
 
+	req.CashierID = utils.GetCurrentUserID(c)
+
 	data, err := h.service.Update(uint(id), &req)
+	if respondRestricted(c, err) {
+		return
+	}
+	if isCustomerError(err) {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error(), "error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengupdate transaksi", "error": err.Error()})
 		return
@@ -94,19 +125,58 @@ func (h *SalesRegularHandler) Update(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": data})
 }
 
-// ✅ DELETE /api/sales/regular/:id (optional)
-func (h *SalesRegularHandler) Delete(c *gin.Context) {
-	idStr := c.Param("id")
-	id, err := strconv.Atoi(idStr)
+// ✅ GET /api/sales/regular/:id/receipt?format=text|escpos|pdf&paper=58|80
+func (h *SalesRegularHandler) Receipt(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "ID tidak valid"})
 		return
 	}
 
-	if err := h.service.Delete(uint(id)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal menghapus transaksi", "error": err.Error()})
+	opts, err := receipt.ParseOptions(c.Query("format"), c.Query("paper"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	data, contentType, err := h.service.PrintReceipt(uint(id), opts, utils.GetCurrentUserID(c))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Data tidak ditemukan", "error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mencetak struk", "error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Transaksi berhasil dihapus"})
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=struk-%d.%s", id, receipt.Extension(opts.Format)))
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// isCustomerError menandai kesalahan pelanggan, poin, piutang atau obat
+// keras yang perlu diperbaiki kasir, bukan kegagalan server
+func isCustomerError(err error) bool {
+	return receivable.IsSaleError(err) ||
+		errors.Is(err, narcotic.ErrPrescriptionRequired) ||
+		errors.Is(err, dispensing.ErrOWALimit) ||
+		errors.Is(err, customer.ErrNotFound) ||
+		errors.Is(err, customer.ErrInactive) ||
+		errors.Is(err, customer.ErrInsufficientPoints) ||
+		errors.Is(err, customer.ErrRedeemDisabled)
+}
+
+// respondRestricted menjawab 409 beserta daftar obat keras bila penjualan
+// bebas tertahan karena belum ada alasan dan login apoteker, atau 403 bila
+// login apoteker ditolak
+func respondRestricted(c *gin.Context, err error) bool {
+	if errors.Is(err, dispensing.ErrPharmacistRejected) {
+		c.JSON(http.StatusForbidden, gin.H{"message": err.Error(), "error": err.Error()})
+		return true
+	}
+	var blocked *dispensing.BlockedError
+	if !errors.As(err, &blocked) {
+		return false
+	}
+	c.JSON(http.StatusConflict, gin.H{"message": err.Error(), "error": err.Error(), "restrictions": blocked.Restrictions})
+	return true
 }
@@ -0,0 +1,175 @@
+package sales
+
+import (
+	"errors"
+	"fmt"
+	"go-gin-auth/internal/customer"
+	"go-gin-auth/internal/payment"
+	"go-gin-auth/internal/promotion"
+	"go-gin-auth/internal/tax"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// pricing adalah hasil perhitungan harga penjualan di server
+type pricing struct {
+	promo  *promotion.Result
+	taxes  []tax.LineTax
+	total  tax.Totals
+	points points
+}
+
+// points adalah penukaran dan perolehan poin pelanggan pada penjualan
+type points struct {
+	redeemed int
+	discount int
+	earned   int
+}
+
+// price menghitung ulang subtotal, potongan promosi, PPN dan total bayar di
+// server. saleID diisi saat mengubah penjualan. Potongan per baris dari
+// hasil promosi dan nilai pajak sudah dibulatkan ke rupiah. Potongan poin
+// diperlakukan seperti potongan manual; poin baru dihitung dari total bayar.
+// Dipanggil di dalam transaksi penjualan: baris pelanggan dikunci sebelum
+// promosi dievaluasi agar batas pemakaian per pelanggan tidak terlewati
+// oleh dua penjualan bersamaan.
+func (s *salesRegularService) price(tx *gorm.DB, req *SalesRegularRequest, saleID uint) (*pricing, error) {
+	if err := applyCustomer(tx, req); err != nil {
+		return nil, err
+	}
+	policy, err := customer.LoadPolicy(tx)
+	if err != nil {
+		return nil, err
+	}
+	if req.CustomerID == nil || req.RedeemPoints < 0 {
+		req.RedeemPoints = 0
+	}
+	pointsDiscount, err := policy.Discount(req.RedeemPoints)
+	if err != nil {
+		return nil, err
+	}
+
+	cart := promotion.Cart{
+		CustomerKey: req.customerKey(),
+		SaleType:    payment.SaleRegular,
+		SaleID:      saleID,
+	}
+	subTotal := 0
+	for i, item := range req.Items {
+		req.Items[i].SubTotal = item.Qty * item.UnitPrice
+		subTotal += req.Items[i].SubTotal
+		cart.Lines = append(cart.Lines, promotion.Line{
+			ProductID: item.ProductID,
+			Qty:       item.Qty,
+			UnitPrice: float64(item.UnitPrice),
+		})
+	}
+
+	at := req.TransactionDate
+	if at.IsZero() {
+		at = time.Now()
+	}
+	if req.CustomerID != nil {
+		if err := customer.Lock(tx, *req.CustomerID); err != nil {
+			return nil, err
+		}
+	}
+	result, err := promotion.Evaluate(tx, cart, at)
+	if err != nil {
+		return nil, err
+	}
+
+	promoDiscount := 0
+	for i := range result.Lines {
+		result.Lines[i].Discount = math.Round(result.Lines[i].Discount)
+		promoDiscount += int(result.Lines[i].Discount)
+	}
+	result.TotalDiscount = float64(promoDiscount)
+
+	manual := pointsDiscount
+	if req.TotalDiscount != nil {
+		manual += *req.TotalDiscount
+	}
+	totalDiscount := manual + promoDiscount
+	if totalDiscount > subTotal {
+		return nil, errors.New("total potongan melebihi subtotal penjualan")
+	}
+
+	taxes, totals, inclusive, err := saleTax(tx, req, result, manual)
+	if err != nil {
+		return nil, err
+	}
+
+	req.SubTotal = subTotal
+	req.TotalDiscount = &totalDiscount
+	req.TotalPay = subTotal - totalDiscount
+	if !inclusive {
+		req.TotalPay += int(totals.Tax)
+	}
+
+	earned := 0
+	if req.CustomerID != nil {
+		earned = policy.Earned(req.TotalPay)
+	}
+	return &pricing{
+		promo:  result,
+		taxes:  taxes,
+		total:  totals,
+		points: points{redeemed: req.RedeemPoints, discount: pointsDiscount, earned: earned},
+	}, nil
+}
+
+// applyCustomer mengisi nama dan kontak dari data pelanggan yang dipilih kasir
+func applyCustomer(tx *gorm.DB, req *SalesRegularRequest) error {
+	if req.CustomerID == nil {
+		return nil
+	}
+	c, err := customer.Find(tx, *req.CustomerID)
+	if err != nil {
+		return err
+	}
+	req.CustomerName = &c.Name
+	req.CustomerContact = &c.Phone
+	return nil
+}
+
+// saleTax menghitung DPP dan PPN per baris setelah potongan promosi, dengan
+// potongan manual dibagi proporsional ke setiap baris
+func saleTax(tx *gorm.DB, req *SalesRegularRequest, promo *promotion.Result, manual int) ([]tax.LineTax, tax.Totals, bool, error) {
+	inclusive, err := tax.PricesIncludeTax(tx)
+	if err != nil {
+		return nil, tax.Totals{}, false, err
+	}
+	rates, err := tax.ProductRates(tx, req.productIDs())
+	if err != nil {
+		return nil, tax.Totals{}, false, err
+	}
+
+	lines := make([]tax.Line, len(req.Items))
+	for i, item := range req.Items {
+		lines[i] = tax.Line{
+			Amount:  float64(item.SubTotal) - promo.Lines[i].Discount,
+			Percent: rates[item.ProductID],
+		}
+	}
+	taxes, _ := tax.Allocate(lines, float64(manual), inclusive)
+	var totals tax.Totals
+	for i := range taxes {
+		taxes[i].Base = math.Round(taxes[i].Base)
+		taxes[i].Tax = math.Round(taxes[i].Tax)
+		totals.Base += taxes[i].Base
+		totals.Tax += taxes[i].Tax
+	}
+	return taxes, totals, inclusive, nil
+}
+
+// customerKey mengidentifikasi pelanggan terdaftar untuk batas promosi per
+// pelanggan; pembeli tanpa pelanggan terdaftar tidak memiliki key
+func (r *SalesRegularRequest) customerKey() string {
+	if r.CustomerID == nil {
+		return ""
+	}
+	return fmt.Sprintf("customer:%d", *r.CustomerID)
+}
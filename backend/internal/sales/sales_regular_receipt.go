@@ -0,0 +1,61 @@
+package sales
+
+import (
+	"fmt"
+	"go-gin-auth/internal/payment"
+	"go-gin-auth/internal/receipt"
+	"go-gin-auth/utils"
+)
+
+// PrintReceipt merender struk penjualan reguler dan mencatat riwayat cetaknya
+func (s *salesRegularService) PrintReceipt(id uint, opts receipt.Options, userID uint) ([]byte, string, error) {
+	sale, err := s.repo.GetSalesRegularByID(id)
+	if err != nil {
+		return nil, "", err
+	}
+	return receipt.Print(s.db, sale.toReceipt(), opts, userID)
+}
+
+func (sale *SalesRegular) toReceipt() *receipt.Receipt {
+	r := &receipt.Receipt{
+		SaleType: payment.SaleRegular,
+		SaleID:   sale.ID,
+		Title:    "STRUK PENJUALAN",
+		Number:   sale.SalesCode,
+		Date:     sale.TransactionDate,
+		Cashier:  sale.CashierName,
+		SubTotal: float64(sale.SubTotal),
+		Total:    float64(sale.TotalPay),
+		Payments: sale.Payments,
+		VoidedAt: sale.VoidedAt,
+
+		TaxBase:   float64(sale.TaxBase),
+		TaxAmount: float64(sale.TaxAmount),
+	}
+	if sale.CustomerName != nil {
+		r.Customer = *sale.CustomerName
+	}
+	if sale.TotalDiscount != nil {
+		r.Discount = float64(*sale.TotalDiscount)
+	}
+	if sale.PointsRedeemed > 0 {
+		r.Info = append(r.Info, fmt.Sprintf("Tukar poin: %d (-%s)", sale.PointsRedeemed, utils.FormatRupiah(float64(sale.PointsDiscount))))
+	}
+	if sale.PointsEarned > 0 {
+		r.Info = append(r.Info, fmt.Sprintf("Poin diperoleh: %d", sale.PointsEarned))
+	}
+	for _, item := range sale.Items {
+		r.Items = append(r.Items, receipt.Item{
+			Name:     item.ProductName,
+			Qty:      item.Qty,
+			Unit:     item.Unit,
+			Price:    float64(item.UnitPrice),
+			SubTotal: float64(item.SubTotal),
+		})
+	}
+	// Penjualan sebelum ada baris pembayaran hanya menyimpan metode di header
+	if len(r.Payments) == 0 {
+		r.Payments = []payment.Payment{{Method: sale.PaymentMethod, Amount: r.Total}}
+	}
+	return r
+}
@@ -24,7 +24,7 @@ func (r *salesRegularRepository) GetAllSalesRegular(limit, offset int) ([]SalesR
 		return nil, 0, err
 	}
 
-	if err := query.Preload("Items").
+	if err := query.Preload("Items").Preload("Payments").
 		Order("transaction_date DESC").
 		Limit(limit).
 		Offset(offset).
@@ -38,7 +38,7 @@ func (r *salesRegularRepository) GetAllSalesRegular(limit, offset int) ([]SalesR
 // Ambil detail satu transaksi
 func (r *salesRegularRepository) GetSalesRegularByID(id uint) (*SalesRegular, error) {
 	var sale SalesRegular
-	if err := r.db.Preload("Items").First(&sale, id).Error; err != nil {
+	if err := r.db.Preload("Items").Preload("Payments").First(&sale, id).Error; err != nil {
 		return nil, err
 	}
 	return &sale, nil
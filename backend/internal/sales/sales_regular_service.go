@@ -3,10 +3,22 @@ package sales
 import (
 	"errors"
 	"fmt"
+	"go-gin-auth/internal/customer"
+	"go-gin-auth/internal/dispensing"
+	"go-gin-auth/internal/narcotic"
+	"go-gin-auth/internal/numbering"
+	"go-gin-auth/internal/opname"
+	"go-gin-auth/internal/payment"
+	"go-gin-auth/internal/promotion"
+	"go-gin-auth/internal/receipt"
+	"go-gin-auth/internal/receivable"
+	"go-gin-auth/internal/sale_void"
+	"go-gin-auth/internal/shift"
 	"go-gin-auth/internal/stock"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Service interface
@@ -15,7 +27,9 @@ type SalesRegularService interface {
 	GetByID(id uint) (*SalesRegular, error)
 	Create(req *SalesRegularRequest) (*SalesRegular, error)
 	Update(id uint, req *SalesRegularRequest) (*SalesRegular, error)
-	Delete(id uint) error
+	VoidInfo(id uint) (*sale_void.Sale, error)
+	Void(tx *gorm.DB, id uint) error
+	PrintReceipt(id uint, opts receipt.Options, userID uint) ([]byte, string, error)
 }
 
 // Service struct
@@ -46,22 +60,70 @@ func (s *salesRegularService) GetByID(id uint) (*SalesRegular, error) {
 
 // ✅ Create new sales regular
 func (s *salesRegularService) Create(req *SalesRegularRequest) (*SalesRegular, error) {
+	if err := narcotic.EnsureNotControlled(s.db, req.productIDs()); err != nil {
+		return nil, err
+	}
+	restrictions, pharmacistID, err := dispensing.Guard(s.db, req.dispensingLines(), req.approval())
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.targetShift(req)
+	if err != nil {
+		return nil, err
+	}
+
 	tx := s.db.Begin()
 
-	salesCode := fmt.Sprintf("SR-%d", time.Now().UnixNano())
+	// shift bisa saja ditutup setelah diambil di atas; status diperiksa
+	// ulang di bawah kunci agar penjualan tidak masuk ke shift yang tertutup
+	if err := shift.EnsureOpen(tx, current.ID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := opname.EnsureNotFrozen(tx, req.productIDs()); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	priced, err := s.price(tx, req, 0)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	payments, paymentMethod, err := payment.Build(req.Payments, req.PaymentMethod, float64(req.TotalPay))
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	salesCode, err := numbering.Next(tx, numbering.SalesRegular, req.TransactionDate)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
 
 	newSale := &SalesRegular{
-		SalesCode:       salesCode,
-		TransactionDate: req.TransactionDate,
-		CashierName:     req.CashierName,
-		CustomerName:    req.CustomerName,
-		CustomerContact: req.CustomerContact,
-		Description:     req.Description,
-		SubTotal:        req.SubTotal,
-		TotalDiscount:   req.TotalDiscount,
-		TotalPay:        req.TotalPay,
-		PaymentMethod:   req.PaymentMethod,
-		ShiftID:         req.ShiftID,
+		SalesCode:         salesCode,
+		TransactionDate:   req.TransactionDate,
+		CashierName:       req.CashierName,
+		CustomerID:        req.CustomerID,
+		CustomerName:      req.CustomerName,
+		CustomerContact:   req.CustomerContact,
+		Description:       req.Description,
+		SubTotal:          req.SubTotal,
+		TotalDiscount:     req.TotalDiscount,
+		PromotionDiscount: int(priced.promo.TotalDiscount),
+		PointsRedeemed:    priced.points.redeemed,
+		PointsDiscount:    priced.points.discount,
+		PointsEarned:      priced.points.earned,
+		TaxBase:           int(priced.total.Base),
+		TaxAmount:         int(priced.total.Tax),
+		TotalPay:          req.TotalPay,
+		PaymentMethod:     paymentMethod,
+		ShiftID:           &current.ID,
+		RegisterID:        current.RegisterID,
+		Status:            payment.SaleCompleted,
 	}
 
 	if err := tx.Create(newSale).Error; err != nil {
@@ -69,9 +131,20 @@ func (s *salesRegularService) Create(req *SalesRegularRequest) (*SalesRegular, e
 		return nil, err
 	}
 
-	for _, item := range req.Items {
-		// Kurangi stok
-		if err := s.stockRepo.UpdateProductStock(item.ProductID, item.Qty, false); err != nil {
+	if err := payment.Replace(tx, payment.SaleRegular, newSale.ID, payments); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := receivable.Sync(tx, newSale.receivableSource(), payments); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	for i, item := range req.Items {
+		// Kurangi stok di dalam transaksi agar ikut dibatalkan bila
+		// penjualan gagal disimpan
+		stockID, err := adjustStock(tx, item.ProductID, -item.Qty)
+		if err != nil {
 			tx.Rollback()
 			return nil, fmt.Errorf("gagal mengurangi stok: %w", err)
 		}
@@ -85,6 +158,12 @@ func (s *salesRegularService) Create(req *SalesRegularRequest) (*SalesRegular, e
 			Unit:           item.Unit,
 			UnitPrice:      item.UnitPrice,
 			SubTotal:       item.SubTotal,
+			PromotionID:    priced.promo.Lines[i].PromotionID,
+			Discount:       int(priced.promo.Lines[i].Discount),
+			TaxRate:        priced.taxes[i].Percent,
+			TaxBase:        int(priced.taxes[i].Base),
+			TaxAmount:      int(priced.taxes[i].Tax),
+			StockID:        stockID,
 		}
 
 		if err := tx.Create(&newItem).Error; err != nil {
@@ -93,14 +172,47 @@ func (s *salesRegularService) Create(req *SalesRegularRequest) (*SalesRegular, e
 		}
 	}
 
+	if err := promotion.Record(tx, payment.SaleRegular, newSale.ID, req.customerKey(), priced.promo); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if req.CustomerID != nil {
+		err := customer.Apply(tx, *req.CustomerID, payment.SaleRegular, newSale.ID,
+			priced.points.redeemed, priced.points.earned, newSale.TransactionDate)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	if err := dispensing.RecordOverride(tx, payment.SaleRegular, newSale.ID, req.CashierID, pharmacistID, req.PharmacistOverrideReason, restrictions); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if req.OnCreated != nil {
+		if err := req.OnCreated(tx, newSale); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
 	if err := tx.Commit().Error; err != nil {
 		return nil, err
 	}
 
+	newSale.Payments = payments
 	return newSale, nil
 }
 
 func (s *salesRegularService) Update(id uint, req *SalesRegularRequest) (*SalesRegular, error) {
+	if err := narcotic.EnsureNotControlled(s.db, req.productIDs()); err != nil {
+		return nil, err
+	}
+	restrictions, pharmacistID, err := dispensing.Guard(s.db, req.dispensingLines(), req.approval())
+	if err != nil {
+		return nil, err
+	}
+
 	tx := s.db.Begin()
 
 	// Ambil transaksi beserta itemnya dengan preload
@@ -109,10 +221,35 @@ func (s *salesRegularService) Update(id uint, req *SalesRegularRequest) (*SalesR
 		tx.Rollback()
 		return nil, errors.New("transaksi tidak ditemukan")
 	}
+	if existing.Status == payment.SaleVoided {
+		tx.Rollback()
+		return nil, payment.ErrSaleVoided
+	}
+
+	priced, err := s.price(tx, req, id)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	payments, paymentMethod, err := payment.Build(req.Payments, req.PaymentMethod, float64(req.TotalPay))
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// stok item lama ikut dikembalikan sehingga produknya juga diperiksa
+	productIDs := req.productIDs()
+	for _, oldItem := range existing.Items {
+		productIDs = append(productIDs, oldItem.ProductID)
+	}
+	if err := opname.EnsureNotFrozen(tx, productIDs); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
 
 	// Step 1: Kembalikan stok lama (rollback stok ke stok semula)
 	for _, oldItem := range existing.Items {
-		if err := s.stockRepo.UpdateProductStock(oldItem.ProductID, oldItem.Qty, true); err != nil {
+		if err := restoreStock(tx, oldItem); err != nil {
 			tx.Rollback()
 			return nil, fmt.Errorf("gagal mengembalikan stok lama: %w", err)
 		}
@@ -125,8 +262,9 @@ func (s *salesRegularService) Update(id uint, req *SalesRegularRequest) (*SalesR
 	}
 
 	// Step 3: Tambah item baru dan kurangi stok
-	for _, item := range req.Items {
-		if err := s.stockRepo.UpdateProductStock(item.ProductID, item.Qty, false); err != nil {
+	for i, item := range req.Items {
+		stockID, err := adjustStock(tx, item.ProductID, -item.Qty)
+		if err != nil {
 			tx.Rollback()
 			return nil, fmt.Errorf("stok tidak mencukupi untuk produk %d", item.ProductID)
 		}
@@ -140,6 +278,12 @@ func (s *salesRegularService) Update(id uint, req *SalesRegularRequest) (*SalesR
 			Unit:           item.Unit,
 			UnitPrice:      item.UnitPrice,
 			SubTotal:       item.SubTotal,
+			PromotionID:    priced.promo.Lines[i].PromotionID,
+			Discount:       int(priced.promo.Lines[i].Discount),
+			TaxRate:        priced.taxes[i].Percent,
+			TaxBase:        int(priced.taxes[i].Base),
+			TaxAmount:      int(priced.taxes[i].Tax),
+			StockID:        stockID,
 		}
 		if err := tx.Create(&newItem).Error; err != nil {
 			tx.Rollback()
@@ -150,14 +294,20 @@ func (s *salesRegularService) Update(id uint, req *SalesRegularRequest) (*SalesR
 	// Step 4: Update header transaksi langsung di tx
 	existing.TransactionDate = req.TransactionDate
 	existing.CashierName = req.CashierName
+	existing.CustomerID = req.CustomerID
 	existing.CustomerName = req.CustomerName
 	existing.CustomerContact = req.CustomerContact
 	existing.Description = req.Description
 	existing.SubTotal = req.SubTotal
 	existing.TotalDiscount = req.TotalDiscount
+	existing.PromotionDiscount = int(priced.promo.TotalDiscount)
+	existing.PointsRedeemed = priced.points.redeemed
+	existing.PointsDiscount = priced.points.discount
+	existing.PointsEarned = priced.points.earned
+	existing.TaxBase = int(priced.total.Base)
+	existing.TaxAmount = int(priced.total.Tax)
 	existing.TotalPay = req.TotalPay
-	existing.PaymentMethod = req.PaymentMethod
-	existing.ShiftID = req.ShiftID
+	existing.PaymentMethod = paymentMethod
 	existing.UpdatedAt = time.Now()
 
 	if err := tx.Save(existing).Error; err != nil {
@@ -165,42 +315,168 @@ func (s *salesRegularService) Update(id uint, req *SalesRegularRequest) (*SalesR
 		return nil, err
 	}
 
+	// Step 5: Ganti baris pembayaran, piutang dan catatan promosi
+	if err := payment.Replace(tx, payment.SaleRegular, id, payments); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := receivable.Sync(tx, existing.receivableSource(), payments); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := promotion.Remove(tx, payment.SaleRegular, id); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := promotion.Record(tx, payment.SaleRegular, id, req.customerKey(), priced.promo); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Step 6: Hitung ulang poin pelanggan
+	if err := customer.Reverse(tx, payment.SaleRegular, id); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if req.CustomerID != nil {
+		err := customer.Apply(tx, *req.CustomerID, payment.SaleRegular, id,
+			priced.points.redeemed, priced.points.earned, existing.TransactionDate)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	// Step 7: Catat ulang alasan apoteker sesuai item terbaru
+	if err := dispensing.RemoveOverrides(tx, payment.SaleRegular, id); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := dispensing.RecordOverride(tx, payment.SaleRegular, id, req.CashierID, pharmacistID, req.PharmacistOverrideReason, restrictions); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
 	if err := tx.Commit().Error; err != nil {
 		return nil, err
 	}
 
+	existing.Payments = payments
 	return existing, nil
 }
 
-func (s *salesRegularService) Delete(id uint) error {
-	tx := s.db.Begin()
-
-	// Ambil transaksi beserta itemnya
+// VoidInfo mengambil ringkasan penjualan untuk pengajuan pembatalan
+func (s *salesRegularService) VoidInfo(id uint) (*sale_void.Sale, error) {
 	sale, err := s.repo.GetSalesRegularByID(id)
 	if err != nil {
-		tx.Rollback()
+		return nil, err
+	}
+	return &sale_void.Sale{
+		Code:        sale.SalesCode,
+		Amount:      float64(sale.TotalPay),
+		CashierName: sale.CashierName,
+		Voided:      sale.Status == payment.SaleVoided,
+	}, nil
+}
+
+// Void membatalkan penjualan yang sudah disetujui supervisor: stok
+// dikembalikan ke baris stok yang dikurangi saat penjualan, catatan promosi
+// dan poin pelanggan dilepas, piutangnya dibatalkan, dan status diubah
+// menjadi Batal. Baris penjualan dikunci agar dua pembatalan tidak
+// mengembalikan stok dua kali.
+// Data penjualan dan baris pembayarannya tetap disimpan sebagai jejak audit.
+func (s *salesRegularService) Void(tx *gorm.DB, id uint) error {
+	var sale SalesRegular
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&sale, id).Error; err != nil {
+		return errors.New("transaksi tidak ditemukan")
+	}
+	if sale.Status == payment.SaleVoided {
+		return payment.ErrSaleVoided
+	}
+	if err := tx.Where("sales_regular_id = ?", id).Find(&sale.Items).Error; err != nil {
 		return err
 	}
 
-	// Kembalikan stok semua item yang ada
+	var productIDs []uint
 	for _, item := range sale.Items {
-		if err := s.stockRepo.UpdateProductStock(item.ProductID, item.Qty, true); err != nil {
-			tx.Rollback()
-			return err
+		productIDs = append(productIDs, item.ProductID)
+	}
+	if err := opname.EnsureNotFrozen(tx, productIDs); err != nil {
+		return err
+	}
+
+	for _, item := range sale.Items {
+		if err := restoreStock(tx, item); err != nil {
+			return fmt.Errorf("gagal mengembalikan stok: %w", err)
 		}
 	}
 
-	// Soft delete item terkait
-	if err := tx.Where("sales_regular_id = ?", id).Delete(&SalesRegularItem{}).Error; err != nil {
-		tx.Rollback()
+	if err := promotion.Remove(tx, payment.SaleRegular, id); err != nil {
+		return err
+	}
+	if err := customer.Reverse(tx, payment.SaleRegular, id); err != nil {
+		return err
+	}
+	if err := receivable.Cancel(tx, payment.SaleRegular, id); err != nil {
 		return err
 	}
 
-	// Soft delete transaksi penjualan
-	if err := tx.Delete(&SalesRegular{}, id).Error; err != nil {
-		tx.Rollback()
+	now := time.Now()
+	return tx.Model(&SalesRegular{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":    payment.SaleVoided,
+		"voided_at": now,
+	}).Error
+}
+
+// targetShift mengambil shift tujuan penjualan: shift yang ditentukan
+// pemanggil atau shift aktif kasir
+func (s *salesRegularService) targetShift(req *SalesRegularRequest) (*shift.Shift, error) {
+	if req.ShiftID != nil {
+		return shift.Open(s.db, *req.ShiftID)
+	}
+	return shift.Current(s.db, req.CashierID)
+}
+
+// adjustStock menambah (delta positif) atau mengurangi stok pada baris
+// stok pertama produk di dalam transaksi penjualan, sehingga perubahan stok
+// ikut dibatalkan bila penjualan, pembaruan atau pembatalannya gagal.
+// ID baris stok yang diubah dikembalikan untuk disimpan pada item.
+func adjustStock(tx *gorm.DB, productID uint, delta int) (uint, error) {
+	var row stock.Stock
+	err := tx.Where("product_id = ?", productID).Order("id").First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		row = stock.Stock{ProductID: productID, Quantity: delta}
+		if err := tx.Create(&row).Error; err != nil {
+			return 0, err
+		}
+		return row.ID, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	err = tx.Model(&stock.Stock{}).Where("id = ?", row.ID).
+		Update("quantity", gorm.Expr("quantity + ?", delta)).Error
+	return row.ID, err
+}
+
+// restoreStock mengembalikan jumlah item ke baris stok yang dikurangi saat
+// penjualan. Item lama yang belum menyimpan baris stoknya dikembalikan
+// lewat adjustStock.
+func restoreStock(tx *gorm.DB, item SalesRegularItem) error {
+	if item.StockID == 0 {
+		_, err := adjustStock(tx, item.ProductID, item.Qty)
 		return err
 	}
+	return tx.Model(&stock.Stock{}).Where("id = ?", item.StockID).
+		Update("quantity", gorm.Expr("quantity + ?", item.Qty)).Error
+}
 
-	return tx.Commit().Error
+func (sale *SalesRegular) receivableSource() receivable.Source {
+	return receivable.Source{
+		SaleType:   payment.SaleRegular,
+		SaleID:     sale.ID,
+		SaleCode:   sale.SalesCode,
+		Date:       sale.TransactionDate,
+		CustomerID: sale.CustomerID,
+	}
 }
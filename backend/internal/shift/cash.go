@@ -0,0 +1,104 @@
+package shift
+
+import (
+	"errors"
+	"go-gin-auth/internal/payment"
+	"time"
+)
+
+// Jenis pergerakan kas laci di luar penjualan
+const (
+	CashIn  = "Masuk"
+	CashOut = "Keluar"
+)
+
+var (
+	ErrInvalidMovement     = errors.New("jenis kas harus Masuk atau Keluar dengan nominal lebih dari 0")
+	ErrApproverRequired    = errors.New("pergerakan kas harus disetujui oleh admin yang aktif")
+	ErrInvalidDenomination = errors.New("pecahan uang tidak dikenal")
+	ErrMovementNotFound    = errors.New("data kas masuk/keluar tidak ditemukan")
+)
+
+// Denominations adalah pecahan rupiah yang diterima saat menghitung laci
+var Denominations = []int{100000, 50000, 20000, 10000, 5000, 2000, 1000, 500, 200, 100}
+
+// CashMovement mencatat uang yang masuk atau keluar dari laci kasir selain
+// penjualan, misalnya biaya kecil, tambahan uang kembalian, atau setoran bank
+type CashMovement struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	ShiftID      uint      `gorm:"not null;index" json:"shift_id"`
+	Type         string    `gorm:"type:varchar(10);not null" json:"type" form:"type"`
+	Amount       float64   `gorm:"type:decimal(14,2);not null" json:"amount" form:"amount"`
+	Reason       string    `gorm:"type:text;not null" json:"reason" form:"reason"`
+	ApprovedBy   uint      `gorm:"not null" json:"approved_by" form:"approved_by"`
+	ApproverName string    `gorm:"type:varchar(100)" json:"approver_name"`
+	CreatedBy    uint      `gorm:"not null" json:"created_by"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (CashMovement) TableName() string {
+	return "shift_cash_movements"
+}
+
+// CashCount adalah jumlah lembar/keping satu pecahan saat shift ditutup
+type CashCount struct {
+	ID           uint    `gorm:"primaryKey" json:"id"`
+	ShiftID      uint    `gorm:"not null;index" json:"shift_id"`
+	Denomination int     `gorm:"not null" json:"denomination"`
+	Quantity     int     `gorm:"not null" json:"quantity"`
+	SubTotal     float64 `gorm:"type:decimal(14,2);not null" json:"sub_total"`
+}
+
+func (CashCount) TableName() string {
+	return "shift_cash_counts"
+}
+
+// ClosingReport adalah laporan tutup shift. Untuk shift yang masih buka,
+// kas seharusnya dihitung dari transaksi sampai saat laporan diminta.
+type ClosingReport struct {
+	Shift          *Shift                `json:"shift"`
+	PaymentMethods []payment.MethodTotal `json:"payment_methods"`
+	TotalSales     float64               `json:"total_sales"`
+	CashSales      float64               `json:"cash_sales"`
+	Movements      []CashMovement        `json:"movements"`
+	CashIn         float64               `json:"cash_in"`
+	CashOut        float64               `json:"cash_out"`
+	Correction     float64               `json:"manual_correction"`
+	ExpectedCash   float64               `json:"expected_cash"`
+	Denominations  []CashCount           `json:"denominations"`
+	CountedCash    *float64              `json:"counted_cash"`
+	Variance       *float64              `json:"variance"`
+}
+
+// RegisterSummary adalah ringkasan satu shift pada tampilan harian
+type RegisterSummary struct {
+	ShiftID        uint       `json:"shift_id"`
+	RegisterID     *uint      `json:"register_id"`
+	RegisterName   string     `json:"register_name"`
+	OfficerID      uint       `json:"officer_id"`
+	OfficerName    string     `json:"officer_name"`
+	Status         string     `json:"status"`
+	OpeningTime    time.Time  `json:"opening_time"`
+	ClosingTime    *time.Time `json:"closing_time"`
+	OpeningBalance float64    `json:"opening_balance"`
+	TotalSales     float64    `json:"total_sales"`
+	CashSales      float64    `json:"cash_sales"`
+	CashIn         float64    `json:"cash_in"`
+	CashOut        float64    `json:"cash_out"`
+	ExpectedCash   float64    `json:"expected_cash"`
+	CountedCash    *float64   `json:"counted_cash"`
+	Variance       *float64   `json:"variance"`
+}
+
+// DailySummary menggabungkan seluruh register pada satu tanggal. Kas dihitung
+// dan selisih hanya mencakup shift yang sudah ditutup.
+type DailySummary struct {
+	Date           string                `json:"date"`
+	Registers      []RegisterSummary     `json:"registers"`
+	PaymentMethods []payment.MethodTotal `json:"payment_methods"`
+	TotalSales     float64               `json:"total_sales"`
+	CashSales      float64               `json:"cash_sales"`
+	ExpectedCash   float64               `json:"expected_cash"`
+	CountedCash    float64               `json:"counted_cash"`
+	Variance       float64               `json:"variance"`
+}
@@ -0,0 +1,60 @@
+package shift
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrNoOpenShift dikembalikan bila kasir mencoba bertransaksi tanpa shift aktif
+var ErrNoOpenShift = errors.New("kasir belum membuka shift, buka shift terlebih dahulu")
+
+// Current mengambil shift berstatus Buka milik petugas. Setiap penjualan
+// wajib terikat pada shift ini agar rekap kas saat tutup shift akurat.
+func Current(db *gorm.DB, officerID uint) (*Shift, error) {
+	var shift Shift
+	err := db.Where("status = ? AND opening_officer_id = ?", "Buka", officerID).
+		Order("id DESC").
+		First(&shift).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNoOpenShift
+		}
+		return nil, err
+	}
+	return &shift, nil
+}
+
+// Open mengambil shift berdasarkan ID yang masih berstatus Buka, dipakai
+// bila penjualan dibukukan ke shift tertentu, bukan shift aktif kasir
+func Open(db *gorm.DB, id uint) (*Shift, error) {
+	var shift Shift
+	err := db.Where("id = ? AND status = ?", id, "Buka").First(&shift).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrShiftNotOpen
+		}
+		return nil, err
+	}
+	return &shift, nil
+}
+
+// EnsureOpen mengunci baris shift FOR SHARE di dalam transaksi penjualan
+// dan memastikan shift masih Buka. Penjualan pada shift yang sama tidak
+// saling menunggu, sedangkan CloseShift yang mengunci FOR UPDATE menunggu
+// transaksi ini selesai.
+func EnsureOpen(tx *gorm.DB, id uint) error {
+	var shift Shift
+	err := tx.Clauses(clause.Locking{Strength: "SHARE"}).Select("id", "status").First(&shift, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrShiftNotOpen
+	}
+	if err != nil {
+		return err
+	}
+	if shift.Status != "Buka" {
+		return ErrShiftNotOpen
+	}
+	return nil
+}
@@ -2,9 +2,11 @@ package shift
 
 import (
 	"errors"
+	"go-gin-auth/internal/register"
 	"go-gin-auth/utils"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -31,8 +33,12 @@ func (h *Handler) OpenShift(c *gin.Context) {
 
 	newShift, err := h.service.OpenShift(&input)
 	if err != nil {
-		if errors.Is(err, ErrInvalidInput) || errors.Is(err, ErrShiftAlreadyOpen) {
+		if errors.Is(err, ErrInvalidInput) || errors.Is(err, ErrShiftAlreadyOpen) || errors.Is(err, register.ErrInactive) {
 			utils.Respond(c, http.StatusBadRequest, err.Error(), err.Error(), nil)
+		} else if errors.Is(err, register.ErrNotFound) {
+			utils.Respond(c, http.StatusNotFound, err.Error(), err.Error(), nil)
+		} else if errors.Is(err, ErrRegisterBusy) {
+			utils.Respond(c, http.StatusConflict, err.Error(), err.Error(), nil)
 		} else {
 			utils.Respond(c, http.StatusInternalServerError, "Gagal membuka shift", err.Error(), nil)
 		}
@@ -62,7 +68,8 @@ func (h *Handler) CloseShift(c *gin.Context) {
 	if err != nil {
 		if errors.Is(err, ErrNotFound) {
 			utils.Respond(c, http.StatusNotFound, err.Error(), err.Error(), nil)
-		} else if errors.Is(err, ErrInvalidInput) || errors.Is(err, ErrShiftNotOpen) {
+		} else if errors.Is(err, ErrInvalidInput) || errors.Is(err, ErrShiftNotOpen) || errors.Is(err, ErrHeldSalesOpen) ||
+			errors.Is(err, ErrInvalidDenomination) {
 			utils.Respond(c, http.StatusBadRequest, err.Error(), err.Error(), nil)
 		} else {
 			utils.Respond(c, http.StatusInternalServerError, "Gagal menutup shift", err.Error(), nil)
@@ -114,6 +121,120 @@ func (h *Handler) GetShiftByID(c *gin.Context) {
 	utils.Respond(c, http.StatusOK, "Detail shift berhasil diambil", nil, shift)
 }
 
+func (h *Handler) GetPaymentBreakdown(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	totals, err := h.service.GetPaymentBreakdown(uint(id))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			utils.Respond(c, http.StatusNotFound, err.Error(), err.Error(), nil)
+		} else {
+			utils.Respond(c, http.StatusInternalServerError, "Gagal mengambil rekap pembayaran shift", err.Error(), nil)
+		}
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Rekap pembayaran shift berhasil diambil", nil, totals)
+}
+
+// GetCurrentShift mengembalikan shift aktif milik pengguna yang sedang login
+func (h *Handler) GetCurrentShift(c *gin.Context) {
+	shift, err := h.service.GetCurrentShift(utils.GetCurrentUserID(c))
+	if err != nil {
+		if errors.Is(err, ErrNoOpenShift) {
+			utils.Respond(c, http.StatusNotFound, err.Error(), err.Error(), nil)
+		} else {
+			utils.Respond(c, http.StatusInternalServerError, "Gagal mengambil shift aktif", err.Error(), nil)
+		}
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Shift aktif berhasil diambil", nil, shift)
+}
+
+// GetClosingReport mengembalikan laporan tutup shift: saldo awal, penjualan
+// per metode, kas masuk/keluar, kas seharusnya, kas dihitung dan selisihnya
+func (h *Handler) GetClosingReport(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	report, err := h.service.GetClosingReport(uint(id))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			utils.Respond(c, http.StatusNotFound, err.Error(), err.Error(), nil)
+		} else {
+			utils.Respond(c, http.StatusInternalServerError, "Gagal menyusun laporan tutup shift", err.Error(), nil)
+		}
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Laporan tutup shift berhasil disusun", nil, report)
+}
+
+func (h *Handler) GetCashMovements(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	movements, err := h.service.GetCashMovements(uint(id))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			utils.Respond(c, http.StatusNotFound, err.Error(), err.Error(), nil)
+		} else {
+			utils.Respond(c, http.StatusInternalServerError, "Gagal mengambil kas masuk/keluar", err.Error(), nil)
+		}
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Daftar kas masuk/keluar berhasil diambil", nil, movements)
+}
+
+func (h *Handler) AddCashMovement(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var input CashMovement
+	if err := c.ShouldBind(&input); err != nil {
+		utils.Respond(c, http.StatusBadRequest, "Input tidak valid", err.Error(), nil)
+		return
+	}
+
+	movement, err := h.service.AddCashMovement(uint(id), &input, utils.GetCurrentUserID(c))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			utils.Respond(c, http.StatusNotFound, err.Error(), err.Error(), nil)
+		} else if errors.Is(err, ErrInvalidInput) || errors.Is(err, ErrShiftNotOpen) ||
+			errors.Is(err, ErrInvalidMovement) || errors.Is(err, ErrApproverRequired) {
+			utils.Respond(c, http.StatusBadRequest, err.Error(), err.Error(), nil)
+		} else {
+			utils.Respond(c, http.StatusInternalServerError, "Gagal mencatat kas masuk/keluar", err.Error(), nil)
+		}
+		return
+	}
+	utils.Respond(c, http.StatusCreated, "Kas masuk/keluar berhasil dicatat", nil, movement)
+}
+
+func (h *Handler) DeleteCashMovement(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	movementID, _ := strconv.ParseUint(c.Param("movementId"), 10, 32)
+	if err := h.service.DeleteCashMovement(uint(id), uint(movementID)); err != nil {
+		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrMovementNotFound) {
+			utils.Respond(c, http.StatusNotFound, err.Error(), err.Error(), nil)
+		} else if errors.Is(err, ErrShiftNotOpen) {
+			utils.Respond(c, http.StatusBadRequest, err.Error(), err.Error(), nil)
+		} else {
+			utils.Respond(c, http.StatusInternalServerError, "Gagal menghapus kas masuk/keluar", err.Error(), nil)
+		}
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Kas masuk/keluar berhasil dihapus", nil, nil)
+}
+
+// GetDailySummary merekap seluruh register pada satu tanggal (?date=YYYY-MM-DD),
+// bawaannya hari ini
+func (h *Handler) GetDailySummary(c *gin.Context) {
+	date, err := time.ParseInLocation("2006-01-02", c.DefaultQuery("date", time.Now().Format("2006-01-02")), time.Local)
+	if err != nil {
+		utils.Respond(c, http.StatusBadRequest, "Format tanggal harus YYYY-MM-DD", err.Error(), nil)
+		return
+	}
+
+	summary, err := h.service.GetDailySummary(date)
+	if err != nil {
+		utils.Respond(c, http.StatusInternalServerError, "Gagal menyusun rekap harian", err.Error(), nil)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Rekap harian seluruh register berhasil disusun", nil, summary)
+}
+
 func (h *Handler) DeleteShift(c *gin.Context) {
 	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err := h.service.DeleteShift(uint(id)); err != nil {
@@ -3,9 +3,13 @@ package shift
 import "time"
 
 type Shift struct {
-	ID               uint       `gorm:"primaryKey" json:"id"`
-	ShiftDate        time.Time  `gorm:"type:date;not null" json:"shift_date"`
-	OpeningOfficerID uint       `gorm:"not null" json:"opening_officer_id,omitempty" form:"opening_officer_id"`
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	ShiftDate time.Time `gorm:"type:date;not null" json:"shift_date"`
+	// Indeks unik parsial mencegah satu register atau satu petugas memiliki
+	// dua shift Buka sekaligus, termasuk saat dua permintaan buka bersamaan
+	RegisterID       *uint      `gorm:"uniqueIndex:idx_shifts_open_register,where:status = 'Buka'" json:"register_id" form:"register_id"`
+	RegisterName     string     `gorm:"-" json:"register_name,omitempty"`
+	OpeningOfficerID uint       `gorm:"not null;uniqueIndex:idx_shifts_open_officer,where:status = 'Buka'" json:"opening_officer_id,omitempty" form:"opening_officer_id"`
 	OpeningOfficer   string     `gorm:"-" json:"opening_officer_name,omitempty"`
 	OpeningTime      time.Time  `gorm:"not null" json:"opening_time"`
 	OpeningBalance   float64    `gorm:"type:decimal(14,2);not null" json:"opening_balance,omitempty" form:"opening_balance"`
@@ -13,8 +17,19 @@ type Shift struct {
 	ClosingOfficer   *string    `gorm:"-" json:"closing_officer_name,omitempty"`
 	ClosingTime      *time.Time `json:"closing_time,omitempty"`
 	ClosingBalance   *float64   `gorm:"type:decimal(14,2)" json:"closing_balance,omitempty" form:"closing_balance"`
-	TotalSales       *float64   `gorm:"type:decimal(14,2)" json:"total_sales,omitempty" form:"total_sales"`
-	ManualCorrection *float64   `gorm:"type:decimal(14,2)" json:"manual_correction,omitempty" form:"manual_correction"`
-	Notes            string     `gorm:"type:text" json:"notes,omitempty" form:"notes"`
-	Status           string     `gorm:"type:varchar(20);not null" json:"status"`
+	// TotalSales, CashSales, CashIn, CashOut, ExpectedCash dan CashVariance
+	// dihitung server saat shift ditutup; nilai yang dikirim klien diabaikan
+	TotalSales       *float64 `gorm:"type:decimal(14,2)" json:"total_sales,omitempty" form:"total_sales"`
+	CashSales        *float64 `gorm:"type:decimal(14,2)" json:"cash_sales,omitempty"`
+	CashIn           *float64 `gorm:"type:decimal(14,2)" json:"cash_in,omitempty"`
+	CashOut          *float64 `gorm:"type:decimal(14,2)" json:"cash_out,omitempty"`
+	ExpectedCash     *float64 `gorm:"type:decimal(14,2)" json:"expected_cash,omitempty"`
+	CashVariance     *float64 `gorm:"type:decimal(14,2)" json:"cash_variance,omitempty"`
+	ManualCorrection *float64 `gorm:"type:decimal(14,2)" json:"manual_correction,omitempty" form:"manual_correction"`
+	Notes            string   `gorm:"type:text" json:"notes,omitempty" form:"notes"`
+	Status           string   `gorm:"type:varchar(20);not null" json:"status"`
+
+	// Denominations diisi saat tutup shift; bila ada, saldo penutupan
+	// dihitung dari rincian pecahan ini
+	Denominations []CashCount `gorm:"foreignKey:ShiftID" json:"denominations,omitempty"`
 }
@@ -2,8 +2,13 @@ package shift
 
 import (
 	"errors"
+	"go-gin-auth/internal/payment"
+	"go-gin-auth/internal/register"
+	"go-gin-auth/model"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Repository interface {
@@ -12,7 +17,20 @@ type Repository interface {
 	GetByID(id uint) (*Shift, error)
 	Update(id uint, shift *Shift) (*Shift, error)
 	Delete(id uint) error
-	FindOpenShift() (*Shift, error)
+	FindOpenShiftByRegister(registerID uint) (*Shift, error)
+	FindOpenShiftByOfficer(officerID uint) (*Shift, error)
+	FindRegister(id uint) (*register.Register, error)
+	GetByDate(date time.Time) ([]Shift, error)
+	PaymentBreakdown(shiftID uint) ([]payment.MethodTotal, error)
+	CountHeldSales(shiftID uint) (int64, error)
+	Close(shift *Shift, counts []CashCount) (*Shift, error)
+	CreateCashMovement(movement *CashMovement) error
+	GetCashMovements(shiftID uint) ([]CashMovement, error)
+	DeleteCashMovement(shiftID, movementID uint) error
+	GetCashCounts(shiftID uint) ([]CashCount, error)
+	FindActiveAdmin(userID uint) (*model.User, error)
+	LockByID(id uint) (*Shift, error)
+	Transaction(fn func(repo Repository) error) error
 }
 
 type repository struct {
@@ -23,15 +41,50 @@ func NewRepository(db *gorm.DB) Repository {
 	return &repository{db: db}
 }
 
-func (r *repository) FindOpenShift() (*Shift, error) {
+func (r *repository) FindOpenShiftByRegister(registerID uint) (*Shift, error) {
 	var shift Shift
-	err := r.db.Where("status = ?", "Buka").First(&shift).Error
+	err := r.db.Where("status = ? AND register_id = ?", "Buka", registerID).First(&shift).Error
 	if err != nil {
 		return nil, err
 	}
 	return &shift, nil
 }
 
+func (r *repository) FindOpenShiftByOfficer(officerID uint) (*Shift, error) {
+	return Current(r.db, officerID)
+}
+
+func (r *repository) FindRegister(id uint) (*register.Register, error) {
+	return register.Find(r.db, id)
+}
+
+// GetByDate mengambil semua shift pada tanggal tertentu beserta nama
+// register dan petugas pembukanya
+func (r *repository) GetByDate(date time.Time) ([]Shift, error) {
+	var shifts []Shift
+	err := r.db.Where("shift_date = ?", date.Format("2006-01-02")).Order("opening_time").Find(&shifts).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range shifts {
+		r.fillNames(&shifts[i])
+	}
+	return shifts, nil
+}
+
+// fillNames melengkapi nama register dan petugas yang tidak disimpan di tabel shifts
+func (r *repository) fillNames(shift *Shift) {
+	if shift.RegisterID != nil {
+		if reg, err := register.Find(r.db.Unscoped(), *shift.RegisterID); err == nil {
+			shift.RegisterName = reg.Name
+		}
+	}
+	var user model.User
+	if err := r.db.Select("full_name").First(&user, shift.OpeningOfficerID).Error; err == nil {
+		shift.OpeningOfficer = user.FullName
+	}
+}
+
 func (r *repository) Create(shift *Shift) (*Shift, error) {
 	if err := r.db.Create(shift).Error; err != nil {
 		return nil, err
@@ -55,11 +108,34 @@ func (r *repository) GetByID(id uint) (*Shift, error) {
 		}
 		return nil, err
 	}
+	r.fillNames(&shift)
 	return &shift, nil
 }
 
+// LockByID mengambil shift dengan kunci FOR UPDATE; hanya bermakna di
+// dalam Transaction
+func (r *repository) LockByID(id uint) (*Shift, error) {
+	var shift Shift
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&shift, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	r.fillNames(&shift)
+	return &shift, nil
+}
+
+// Transaction menjalankan fn dengan repository yang terikat pada satu
+// transaksi database
+func (r *repository) Transaction(fn func(repo Repository) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&repository{db: tx})
+	})
+}
+
 func (r *repository) Update(id uint, shiftData *Shift) (*Shift, error) {
-	if err := r.db.Model(&Shift{ID: id}).Updates(shiftData).Error; err != nil {
+	if err := r.db.Model(&Shift{ID: id}).Omit(clause.Associations).Updates(shiftData).Error; err != nil {
 		return nil, err
 	}
 	return r.GetByID(id)
@@ -75,3 +151,80 @@ func (r *repository) Delete(id uint) error {
 	}
 	return nil
 }
+
+func (r *repository) PaymentBreakdown(shiftID uint) ([]payment.MethodTotal, error) {
+	return payment.BreakdownByShift(r.db, shiftID)
+}
+
+// CountHeldSales menghitung transaksi kasir yang masih diparkir pada shift
+func (r *repository) CountHeldSales(shiftID uint) (int64, error) {
+	var count int64
+	err := r.db.Table("held_sales").
+		Where("shift_id = ? AND deleted_at IS NULL", shiftID).
+		Count(&count).Error
+	return count, err
+}
+
+// Close menyimpan hasil tutup shift beserta rincian pecahan uang
+func (r *repository) Close(shift *Shift, counts []CashCount) (*Shift, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&Shift{ID: shift.ID}).Omit(clause.Associations).Updates(shift).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("shift_id = ?", shift.ID).Delete(&CashCount{}).Error; err != nil {
+			return err
+		}
+		for i := range counts {
+			counts[i].ID = 0
+			counts[i].ShiftID = shift.ID
+		}
+		if len(counts) > 0 {
+			return tx.Create(&counts).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(shift.ID)
+}
+
+func (r *repository) CreateCashMovement(movement *CashMovement) error {
+	return r.db.Create(movement).Error
+}
+
+func (r *repository) GetCashMovements(shiftID uint) ([]CashMovement, error) {
+	var movements []CashMovement
+	err := r.db.Where("shift_id = ?", shiftID).Order("created_at").Find(&movements).Error
+	return movements, err
+}
+
+func (r *repository) DeleteCashMovement(shiftID, movementID uint) error {
+	result := r.db.Where("shift_id = ?", shiftID).Delete(&CashMovement{}, movementID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrMovementNotFound
+	}
+	return nil
+}
+
+func (r *repository) GetCashCounts(shiftID uint) ([]CashCount, error) {
+	var counts []CashCount
+	err := r.db.Where("shift_id = ?", shiftID).Order("denomination DESC").Find(&counts).Error
+	return counts, err
+}
+
+// FindActiveAdmin mengambil admin aktif yang menyetujui pergerakan kas
+func (r *repository) FindActiveAdmin(userID uint) (*model.User, error) {
+	var user model.User
+	err := r.db.Where("id = ? AND role = ? AND active = ?", userID, "admin", true).First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrApproverRequired
+		}
+		return nil, err
+	}
+	return &user, nil
+}
@@ -19,7 +19,14 @@ func ShiftRouter(api *gin.RouterGroup) {
 		shiftGroup.PUT("/close/:id", handler.CloseShift)
 
 		shiftGroup.GET("/", handler.GetAllShifts)
+		shiftGroup.GET("/current", handler.GetCurrentShift)
+		shiftGroup.GET("/daily", handler.GetDailySummary)
 		shiftGroup.GET("/:id", handler.GetShiftByID)
+		shiftGroup.GET("/:id/payments", handler.GetPaymentBreakdown)
+		shiftGroup.GET("/:id/report", handler.GetClosingReport)
+		shiftGroup.GET("/:id/cash-movements", handler.GetCashMovements)
+		shiftGroup.POST("/:id/cash-movements", handler.AddCashMovement)
+		shiftGroup.DELETE("/:id/cash-movements/:movementId", handler.DeleteCashMovement)
 		shiftGroup.PUT("/:id", handler.UpdateShift)
 		shiftGroup.DELETE("/:id", handler.DeleteShift)
 	}
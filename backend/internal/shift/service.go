@@ -2,8 +2,13 @@ package shift
 
 import (
 	"errors"
+	"fmt"
+	"go-gin-auth/internal/payment"
+	"go-gin-auth/internal/register"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
+
 	"gorm.io/gorm"
 )
 
@@ -11,7 +16,9 @@ var (
 	ErrNotFound         = errors.New("shift tidak ditemukan")
 	ErrInvalidInput     = errors.New("input tidak valid atau tidak lengkap")
 	ErrShiftAlreadyOpen = errors.New("masih ada shift yang aktif, harap tutup shift sebelumnya")
+	ErrRegisterBusy     = errors.New("register ini masih memiliki shift yang buka")
 	ErrShiftNotOpen     = errors.New("shift ini tidak dalam status Buka")
+	ErrHeldSalesOpen    = errors.New("masih ada transaksi tertahan pada shift ini, selesaikan atau buang terlebih dahulu")
 )
 
 type Service interface {
@@ -21,6 +28,13 @@ type Service interface {
 	GetAllShifts() ([]Shift, error)
 	GetShiftByID(id uint) (*Shift, error)
 	DeleteShift(id uint) error
+	GetPaymentBreakdown(id uint) ([]payment.MethodTotal, error)
+	GetCurrentShift(officerID uint) (*Shift, error)
+	GetClosingReport(id uint) (*ClosingReport, error)
+	AddCashMovement(shiftID uint, movement *CashMovement, createdBy uint) (*CashMovement, error)
+	GetCashMovements(shiftID uint) ([]CashMovement, error)
+	DeleteCashMovement(shiftID, movementID uint) error
+	GetDailySummary(date time.Time) (*DailySummary, error)
 }
 
 type service struct {
@@ -31,41 +45,256 @@ func NewService(repository Repository) Service {
 	return &service{repository: repository}
 }
 
+// OpenShift membuka shift baru pada register untuk petugas yang login.
+// Pemeriksaan di sini memberi pesan yang jelas; indeks unik parsial pada
+// tabel shifts tetap menjadi penjaga terakhir untuk permintaan bersamaan.
 func (s *service) OpenShift(shift *Shift) (*Shift, error) {
-	existing, err := s.repository.FindOpenShift()
-	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+	if shift.RegisterID == nil {
+		return nil, fmt.Errorf("%w: register wajib dipilih", ErrInvalidInput)
+	}
+	reg, err := s.repository.FindRegister(*shift.RegisterID)
+	if err != nil {
+		return nil, err
+	}
+	if !reg.Active {
+		return nil, register.ErrInactive
+	}
+
+	if _, err := s.repository.FindOpenShiftByRegister(reg.ID); err == nil {
+		return nil, ErrRegisterBusy
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, err
 	}
-	if existing != nil {
+	if _, err := s.repository.FindOpenShiftByOfficer(shift.OpeningOfficerID); err == nil {
 		return nil, ErrShiftAlreadyOpen
+	} else if !errors.Is(err, ErrNoOpenShift) {
+		return nil, err
 	}
 
+	shift.ID = 0
 	shift.ShiftDate = time.Now()
 	shift.OpeningTime = time.Now()
 	shift.Status = "Buka"
 
-	return s.repository.Create(shift)
+	created, err := s.repository.Create(shift)
+	if isUniqueViolation(err) {
+		return nil, ErrRegisterBusy
+	}
+	if err != nil {
+		return nil, err
+	}
+	created.RegisterName = reg.Name
+	return created, nil
 }
 
+// CloseShift menutup shift di dalam satu transaksi. Baris shift dikunci
+// FOR UPDATE sehingga penutupan menunggu penjualan yang sedang disimpan
+// (yang memegang kunci lewat EnsureOpen) dan penjualan baru tertahan sampai
+// shift tertutup, lalu ditolak karena shift tidak lagi Buka.
 func (s *service) CloseShift(id uint, closingData *Shift) (*Shift, error) {
+	counts, err := countCash(closingData.Denominations)
+	if err != nil {
+		return nil, err
+	}
+	if len(counts) > 0 {
+		var counted float64
+		for _, c := range counts {
+			counted += c.SubTotal
+		}
+		closingData.ClosingBalance = &counted
+	}
+	if closingData.ClosingBalance == nil {
+		return nil, fmt.Errorf("%w: saldo kas penutupan atau rincian pecahan wajib diisi", ErrInvalidInput)
+	}
+
+	var closed *Shift
+	err = s.repository.Transaction(func(repo Repository) error {
+		shift, err := repo.LockByID(id)
+		if err != nil {
+			return err
+		}
+		if shift.Status != "Buka" {
+			return ErrShiftNotOpen
+		}
+
+		held, err := repo.CountHeldSales(id)
+		if err != nil {
+			return err
+		}
+		if held > 0 {
+			return fmt.Errorf("%w (%d transaksi)", ErrHeldSalesOpen, held)
+		}
+
+		if closingData.ManualCorrection != nil {
+			shift.ManualCorrection = closingData.ManualCorrection
+		}
+
+		report, err := summarize(repo, shift)
+		if err != nil {
+			return err
+		}
+		variance := *closingData.ClosingBalance - report.ExpectedCash
+
+		now := time.Now()
+		shift.ClosingOfficerID = closingData.ClosingOfficerID
+		shift.ClosingOfficer = closingData.ClosingOfficer
+		shift.ClosingTime = &now
+		shift.ClosingBalance = closingData.ClosingBalance
+		shift.TotalSales = &report.TotalSales
+		shift.CashSales = &report.CashSales
+		shift.CashIn = &report.CashIn
+		shift.CashOut = &report.CashOut
+		shift.ExpectedCash = &report.ExpectedCash
+		shift.CashVariance = &variance
+		shift.Status = "Tutup"
+		shift.Notes = closingData.Notes
+
+		closed, err = repo.Close(shift, counts)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return closed, nil
+}
+
+// summarize menghitung penjualan shift dari transaksi yang tersimpan beserta
+// kas masuk/keluar, lalu kas yang seharusnya ada di laci. Penjualan yang
+// dihapus tidak ikut dihitung sehingga pengembalian dana sudah tercermin.
+func summarize(repo Repository, shift *Shift) (*ClosingReport, error) {
+	totals, err := repo.PaymentBreakdown(shift.ID)
+	if err != nil {
+		return nil, err
+	}
+	movements, err := repo.GetCashMovements(shift.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ClosingReport{Shift: shift, PaymentMethods: totals, Movements: movements}
+	for _, t := range totals {
+		report.TotalSales += t.Amount
+		if t.Method == payment.Cash {
+			report.CashSales += t.Amount
+		}
+	}
+	for _, m := range movements {
+		if m.Type == CashIn {
+			report.CashIn += m.Amount
+		} else {
+			report.CashOut += m.Amount
+		}
+	}
+	if shift.ManualCorrection != nil {
+		report.Correction = *shift.ManualCorrection
+	}
+	report.ExpectedCash = shift.OpeningBalance + report.CashSales +
+		report.CashIn - report.CashOut + report.Correction
+	return report, nil
+}
+
+// countCash memvalidasi rincian pecahan dan menghitung subtotalnya
+func countCash(input []CashCount) ([]CashCount, error) {
+	counts := make([]CashCount, 0, len(input))
+	for _, c := range input {
+		if c.Quantity < 0 || !validDenomination(c.Denomination) {
+			return nil, fmt.Errorf("%w: %d", ErrInvalidDenomination, c.Denomination)
+		}
+		if c.Quantity == 0 {
+			continue
+		}
+		counts = append(counts, CashCount{
+			Denomination: c.Denomination,
+			Quantity:     c.Quantity,
+			SubTotal:     float64(c.Denomination * c.Quantity),
+		})
+	}
+	return counts, nil
+}
+
+func validDenomination(value int) bool {
+	for _, d := range Denominations {
+		if d == value {
+			return true
+		}
+	}
+	return false
+}
+
+// GetClosingReport menyusun laporan tutup shift. Shift yang sudah ditutup
+// memakai kas seharusnya yang tersimpan saat penutupan.
+func (s *service) GetClosingReport(id uint) (*ClosingReport, error) {
 	shift, err := s.repository.GetByID(id)
 	if err != nil {
 		return nil, err
 	}
+	report, err := summarize(s.repository, shift)
+	if err != nil {
+		return nil, err
+	}
+	if report.Denominations, err = s.repository.GetCashCounts(id); err != nil {
+		return nil, err
+	}
+
+	if shift.ExpectedCash != nil {
+		report.ExpectedCash = *shift.ExpectedCash
+	}
+	if shift.ClosingBalance != nil {
+		variance := *shift.ClosingBalance - report.ExpectedCash
+		report.CountedCash = shift.ClosingBalance
+		report.Variance = &variance
+	}
+	return report, nil
+}
 
+// AddCashMovement mencatat kas masuk/keluar pada shift yang masih buka
+func (s *service) AddCashMovement(shiftID uint, movement *CashMovement, createdBy uint) (*CashMovement, error) {
+	shift, err := s.repository.GetByID(shiftID)
+	if err != nil {
+		return nil, err
+	}
 	if shift.Status != "Buka" {
 		return nil, ErrShiftNotOpen
 	}
+	if (movement.Type != CashIn && movement.Type != CashOut) || movement.Amount <= 0 {
+		return nil, ErrInvalidMovement
+	}
+	if movement.Reason == "" {
+		return nil, fmt.Errorf("%w: alasan wajib diisi", ErrInvalidInput)
+	}
 
-	now := time.Now()
-	shift.ClosingOfficer = closingData.ClosingOfficer
-	shift.ClosingTime = &now
-	shift.ClosingBalance = closingData.ClosingBalance
-	shift.TotalSales = closingData.TotalSales
-	shift.Status = "Tutup"
-	shift.Notes = closingData.Notes
+	approver, err := s.repository.FindActiveAdmin(movement.ApprovedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	movement.ID = 0
+	movement.ShiftID = shiftID
+	movement.ApproverName = approver.FullName
+	movement.CreatedBy = createdBy
+	if err := s.repository.CreateCashMovement(movement); err != nil {
+		return nil, err
+	}
+	return movement, nil
+}
 
-	return s.repository.Update(id, shift)
+func (s *service) GetCashMovements(shiftID uint) ([]CashMovement, error) {
+	if _, err := s.repository.GetByID(shiftID); err != nil {
+		return nil, err
+	}
+	return s.repository.GetCashMovements(shiftID)
+}
+
+// DeleteCashMovement membatalkan catatan kas selama shift belum ditutup
+func (s *service) DeleteCashMovement(shiftID, movementID uint) error {
+	shift, err := s.repository.GetByID(shiftID)
+	if err != nil {
+		return err
+	}
+	if shift.Status != "Buka" {
+		return ErrShiftNotOpen
+	}
+	return s.repository.DeleteCashMovement(shiftID, movementID)
 }
 
 func (s *service) UpdateShift(id uint, updateData *Shift) (*Shift, error) {
@@ -86,3 +315,88 @@ func (s *service) GetShiftByID(id uint) (*Shift, error) {
 func (s *service) DeleteShift(id uint) error {
 	return s.repository.Delete(id)
 }
+
+func (s *service) GetPaymentBreakdown(id uint) ([]payment.MethodTotal, error) {
+	if _, err := s.repository.GetByID(id); err != nil {
+		return nil, err
+	}
+	return s.repository.PaymentBreakdown(id)
+}
+
+func (s *service) GetCurrentShift(officerID uint) (*Shift, error) {
+	return s.repository.FindOpenShiftByOfficer(officerID)
+}
+
+// GetDailySummary menggabungkan seluruh shift semua register pada satu
+// tanggal untuk tampilan pemilik apotek
+func (s *service) GetDailySummary(date time.Time) (*DailySummary, error) {
+	shifts, err := s.repository.GetByDate(date)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &DailySummary{Date: date.Format("2006-01-02"), Registers: []RegisterSummary{}}
+	methods := map[string]*payment.MethodTotal{}
+	var order []string
+	for i := range shifts {
+		sh := &shifts[i]
+		report, err := summarize(s.repository, sh)
+		if err != nil {
+			return nil, err
+		}
+		if sh.ExpectedCash != nil {
+			report.ExpectedCash = *sh.ExpectedCash
+		}
+
+		item := RegisterSummary{
+			ShiftID:        sh.ID,
+			RegisterID:     sh.RegisterID,
+			RegisterName:   sh.RegisterName,
+			OfficerID:      sh.OpeningOfficerID,
+			OfficerName:    sh.OpeningOfficer,
+			Status:         sh.Status,
+			OpeningTime:    sh.OpeningTime,
+			ClosingTime:    sh.ClosingTime,
+			OpeningBalance: sh.OpeningBalance,
+			TotalSales:     report.TotalSales,
+			CashSales:      report.CashSales,
+			CashIn:         report.CashIn,
+			CashOut:        report.CashOut,
+			ExpectedCash:   report.ExpectedCash,
+			CountedCash:    sh.ClosingBalance,
+		}
+		if sh.ClosingBalance != nil {
+			variance := *sh.ClosingBalance - report.ExpectedCash
+			item.Variance = &variance
+			summary.CountedCash += *sh.ClosingBalance
+			summary.Variance += variance
+		}
+		summary.Registers = append(summary.Registers, item)
+
+		summary.TotalSales += report.TotalSales
+		summary.CashSales += report.CashSales
+		summary.ExpectedCash += report.ExpectedCash
+		for _, m := range report.PaymentMethods {
+			total, ok := methods[m.Method]
+			if !ok {
+				total = &payment.MethodTotal{Method: m.Method}
+				methods[m.Method] = total
+				order = append(order, m.Method)
+			}
+			total.Amount += m.Amount
+			total.Transactions += m.Transactions
+		}
+	}
+	for _, method := range order {
+		summary.PaymentMethods = append(summary.PaymentMethods, *methods[method])
+	}
+	return summary, nil
+}
+
+func isUniqueViolation(err error) bool {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
@@ -5,6 +5,7 @@ import (
 	"go-gin-auth/config"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Repository interface {
@@ -12,6 +13,7 @@ type Repository interface {
 	UpdateProductStock(id uint, quantity int, isAdd bool) error
 	// Tambahan untuk transaksi opname dan koreksi
 	GetStockByProductID(tx *gorm.DB, productID uint) (*Stock, error)
+	GetStockByProductIDForUpdate(tx *gorm.DB, productID uint) (*Stock, error)
 	CreateStock(tx *gorm.DB, s *Stock) error
 	UpdateStock(tx *gorm.DB, s *Stock) error
 }
@@ -74,6 +76,12 @@ func (r *repository) GetStockByProductID(tx *gorm.DB, productID uint) (*Stock, e
 	return &stock, nil
 }
 
+// GetStockByProductIDForUpdate sama seperti GetStockByProductID namun mengunci
+// baris stok sampai transaksi selesai
+func (r *repository) GetStockByProductIDForUpdate(tx *gorm.DB, productID uint) (*Stock, error) {
+	return r.GetStockByProductID(tx.Clauses(clause.Locking{Strength: "UPDATE"}), productID)
+}
+
 func (r *repository) CreateStock(tx *gorm.DB, s *Stock) error {
 	return tx.Create(s).Error
 }
@@ -270,3 +270,26 @@ func (s *StockService) GetStockDetail(productID uint) ([]StockDetail, error) {
 	err := s.DB.Raw(query, productID, productID).Scan(&results).Error
 	return results, err
 }
+
+// LatestPurchasePrice mengambil harga beli terakhir sebuah produk dari
+// penerimaan PBF maupun non-PBF, dipakai sebagai harga pokok
+func LatestPurchasePrice(db *gorm.DB, productID uint) (float64, error) {
+	var price float64
+	query := `
+		SELECT purchase_price FROM (
+			SELECT d.purchase_price, d.created_at
+			FROM incoming_pbf_details d
+			WHERE d.product_id = ?
+
+			UNION ALL
+
+			SELECT d.purchase_price, d.created_at
+			FROM incoming_non_pbf_details d
+			WHERE d.product_id = ? AND d.deleted_at IS NULL
+		) p
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+	err := db.Raw(query, productID, productID).Scan(&price).Error
+	return price, err
+}
@@ -2,6 +2,7 @@ package stock_correction
 
 import (
 	"go-gin-auth/config"
+	"go-gin-auth/internal/idempotency"
 	"go-gin-auth/internal/stock"
 	"go-gin-auth/middleware"
 
@@ -17,7 +18,7 @@ func StockCorrectionRouter(api *gin.RouterGroup) {
 	correctionGroup := api.Group("/stock-corrections")
 	correctionGroup.Use(middleware.AuthAdminMiddleware())
 	{
-		correctionGroup.POST("/", handler.CreateCorrection)
+		correctionGroup.POST("/", idempotency.Middleware(), handler.CreateCorrection)
 		correctionGroup.GET("/", handler.GetAllCorrections)
 		correctionGroup.GET("/:id", handler.GetCorrectionByID)
 		correctionGroup.DELETE("/:id", handler.DeleteCorrection)
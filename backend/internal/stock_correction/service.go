@@ -2,9 +2,14 @@ package stock_correction
 
 import (
 	"errors"
+	"go-gin-auth/config"
+	"go-gin-auth/internal/narcotic"
+	"go-gin-auth/internal/opname"
 	"go-gin-auth/internal/stock"
 	"strings"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 var (
@@ -38,22 +43,58 @@ func (s *service) CreateCorrection(correction *StockCorrection, officerName stri
 		return nil, ErrInvalidInput
 	}
 
-	currentStock, err := s.stockRepository.GetProductStockById(correction.ProductID)
-	if err != nil {
-		currentStock = &stock.Stock{ProductID: correction.ProductID, Quantity: 0}
-	}
+	// stok, data koreksi dan register narkotika disimpan dalam satu
+	// transaksi agar koreksi tidak tercatat setengah jalan
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := opname.EnsureNotFrozen(tx, []uint{correction.ProductID}); err != nil {
+			return err
+		}
 
-	correction.OldStock = currentStock.Quantity
-	correction.Difference = correction.NewStock - correction.OldStock
-	correction.CorrectionDate = time.Now()
-	correction.CorrectionOfficer = officerName
+		currentStock, err := s.stockRepository.GetStockByProductIDForUpdate(tx, correction.ProductID)
+		if err != nil {
+			return ErrStockUpdateFailed
+		}
 
-	txErr := s.stockRepository.UpdateProductStock(correction.ProductID, correction.Difference, true)
-	if txErr != nil {
-		return nil, ErrStockUpdateFailed
-	}
+		correction.OldStock = 0
+		if currentStock != nil {
+			correction.OldStock = currentStock.Quantity
+		}
+		correction.Difference = correction.NewStock - correction.OldStock
+		correction.CorrectionDate = time.Now()
+		correction.CorrectionOfficer = officerName
 
-	return s.repository.Create(correction)
+		if currentStock == nil {
+			err = s.stockRepository.CreateStock(tx, &stock.Stock{ProductID: correction.ProductID, Quantity: correction.NewStock})
+		} else {
+			currentStock.Quantity = correction.NewStock
+			err = s.stockRepository.UpdateStock(tx, currentStock)
+		}
+		if err != nil {
+			return ErrStockUpdateFailed
+		}
+
+		if err := tx.Create(correction).Error; err != nil {
+			return err
+		}
+
+		line := narcotic.Line{ProductID: correction.ProductID, QtyIn: correction.Difference}
+		if correction.Difference < 0 {
+			line = narcotic.Line{ProductID: correction.ProductID, QtyOut: -correction.Difference}
+		}
+		return narcotic.Record(tx, narcotic.Movement{
+			Type:    narcotic.TypeCorrection,
+			RefType: "stock_correction",
+			RefID:   correction.ID,
+			Date:    correction.CorrectionDate,
+			Party:   officerName,
+			Note:    correction.Reason,
+			Lines:   []narcotic.Line{line},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return correction, nil
 }
 
 func (s *service) GetAllCorrections() ([]StockCorrection, error) {
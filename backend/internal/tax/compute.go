@@ -0,0 +1,94 @@
+package tax
+
+import (
+	"go-gin-auth/internal/pharmacy"
+	"math"
+
+	"gorm.io/gorm"
+)
+
+// Allocate membagi potongan header secara proporsional ke setiap baris lalu
+// menghitung DPP dan pajaknya. Untuk harga termasuk pajak, pajak diambil dari
+// dalam harga; untuk harga belum termasuk pajak, pajak ditambahkan di atasnya.
+func Allocate(lines []Line, headerDiscount float64, inclusive bool) ([]LineTax, Totals) {
+	var gross float64
+	for _, l := range lines {
+		gross += l.Amount
+	}
+
+	result := make([]LineTax, len(lines))
+	var totals Totals
+	for i, l := range lines {
+		amount := l.Amount
+		if gross > 0 && headerDiscount != 0 {
+			amount -= headerDiscount * l.Amount / gross
+		}
+
+		base, tax := Split(amount, l.Percent, inclusive)
+		result[i] = LineTax{Percent: l.Percent, Base: base, Tax: tax}
+		totals.Base += base
+		totals.Tax += tax
+	}
+	totals.Base = round(totals.Base)
+	totals.Tax = round(totals.Tax)
+	return result, totals
+}
+
+// Split memisahkan nilai menjadi DPP dan pajak
+func Split(amount, percent float64, inclusive bool) (base, tax float64) {
+	if percent <= 0 {
+		return round(amount), 0
+	}
+	if inclusive {
+		base = round(amount * 100 / (100 + percent))
+		return base, round(amount - base)
+	}
+	return round(amount), round(amount * percent / 100)
+}
+
+// ProductRates mengembalikan tarif pajak efektif per produk: tarif produk
+// bila diisi, selain itu tarif kategorinya. Tarif bebas pajak bernilai 0.
+func ProductRates(db *gorm.DB, productIDs []uint) (map[uint]float64, error) {
+	rates := make(map[uint]float64, len(productIDs))
+	if len(productIDs) == 0 {
+		return rates, nil
+	}
+
+	var rows []struct {
+		ProductID uint
+		Percent   float64
+	}
+	err := db.Raw(`
+		SELECT p.id AS product_id,
+			COALESCE(
+				CASE WHEN tp.exempt THEN 0 ELSE tp.percent END,
+				CASE WHEN tc.exempt THEN 0 ELSE tc.percent END,
+				0) AS percent
+		FROM products p
+		LEFT JOIN tax_rates tp ON tp.id = p.tax_rate_id AND tp.active AND tp.deleted_at IS NULL
+		LEFT JOIN categories c ON c.id = p.category_id
+		LEFT JOIN tax_rates tc ON tc.id = c.tax_rate_id AND tc.active AND tc.deleted_at IS NULL
+		WHERE p.id IN ?
+	`, productIDs).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range rows {
+		rates[r.ProductID] = r.Percent
+	}
+	return rates, nil
+}
+
+// PricesIncludeTax membaca pengaturan harga jual termasuk pajak dari profil apotek
+func PricesIncludeTax(db *gorm.DB) (bool, error) {
+	profile, err := pharmacy.Get(db)
+	if err != nil {
+		return false, err
+	}
+	return profile.PricesIncludeTax, nil
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
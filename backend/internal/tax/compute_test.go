@@ -0,0 +1,104 @@
+package tax
+
+import "testing"
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name      string
+		lines     []Line
+		discount  float64
+		inclusive bool
+		want      []LineTax
+		totals    Totals
+	}{
+		{
+			name:      "harga termasuk PPN",
+			lines:     []Line{{Amount: 11100, Percent: 11}},
+			inclusive: true,
+			want:      []LineTax{{Percent: 11, Base: 10000, Tax: 1100}},
+			totals:    Totals{Base: 10000, Tax: 1100},
+		},
+		{
+			name:   "harga belum termasuk PPN",
+			lines:  []Line{{Amount: 10000, Percent: 11}},
+			want:   []LineTax{{Percent: 11, Base: 10000, Tax: 1100}},
+			totals: Totals{Base: 10000, Tax: 1100},
+		},
+		{
+			name:     "potongan dibagi proporsional, baris bebas pajak",
+			lines:    []Line{{Amount: 6000, Percent: 11}, {Amount: 4000, Percent: 0}},
+			discount: 1000,
+			want:     []LineTax{{Percent: 11, Base: 5400, Tax: 594}, {Percent: 0, Base: 3600, Tax: 0}},
+			totals:   Totals{Base: 9000, Tax: 594},
+		},
+		{
+			name:      "potongan pada harga termasuk PPN",
+			lines:     []Line{{Amount: 11100, Percent: 11}, {Amount: 2220, Percent: 11}},
+			discount:  3330,
+			inclusive: true,
+			want:      []LineTax{{Percent: 11, Base: 7500, Tax: 825}, {Percent: 11, Base: 1500, Tax: 165}},
+			totals:    Totals{Base: 9000, Tax: 990},
+		},
+		{
+			name:      "pembulatan ke sen",
+			lines:     []Line{{Amount: 10000, Percent: 11}},
+			inclusive: true,
+			want:      []LineTax{{Percent: 11, Base: 9009.01, Tax: 990.99}},
+			totals:    Totals{Base: 9009.01, Tax: 990.99},
+		},
+		{
+			name:     "baris bernilai nol tidak membagi potongan",
+			lines:    []Line{{Amount: 0, Percent: 11}},
+			discount: 500,
+			want:     []LineTax{{Percent: 11, Base: 0, Tax: 0}},
+			totals:   Totals{},
+		},
+		{
+			name:   "tanpa baris",
+			want:   []LineTax{},
+			totals: Totals{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, totals := Allocate(tt.lines, tt.discount, tt.inclusive)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d lines, want %d", len(got), len(tt.want))
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("line %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+			if totals != tt.totals {
+				t.Errorf("totals = %+v, want %+v", totals, tt.totals)
+			}
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name      string
+		amount    float64
+		percent   float64
+		inclusive bool
+		base, tax float64
+	}{
+		{"bebas pajak termasuk", 5000, 0, true, 5000, 0},
+		{"bebas pajak belum termasuk", 5000, 0, false, 5000, 0},
+		{"tarif negatif dianggap bebas pajak", 5000, -11, false, 5000, 0},
+		{"termasuk 11%", 111, 11, true, 100, 11},
+		{"belum termasuk 12%", 1000, 12, false, 1000, 120},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, tax := Split(tt.amount, tt.percent, tt.inclusive)
+			if base != tt.base || tax != tt.tax {
+				t.Errorf("Split() = (%v, %v), want (%v, %v)", base, tax, tt.base, tt.tax)
+			}
+		})
+	}
+}
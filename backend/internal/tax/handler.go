@@ -0,0 +1,103 @@
+package tax
+
+import (
+	"errors"
+	"go-gin-auth/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) GetAll(c *gin.Context) {
+	rates, err := h.service.GetAll()
+	if err != nil {
+		utils.Respond(c, http.StatusInternalServerError, "Gagal mengambil daftar tarif pajak", err.Error(), nil)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Daftar tarif pajak berhasil diambil", nil, rates)
+}
+
+func (h *Handler) GetByID(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	rate, err := h.service.GetByID(uint(id))
+	if err != nil {
+		h.respondError(c, "Gagal mengambil tarif pajak", err)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Detail tarif pajak berhasil diambil", nil, rate)
+}
+
+func (h *Handler) Create(c *gin.Context) {
+	var input Rate
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Respond(c, http.StatusBadRequest, "Input tidak valid", err.Error(), nil)
+		return
+	}
+	rate, err := h.service.Create(&input)
+	if err != nil {
+		h.respondError(c, "Gagal membuat tarif pajak", err)
+		return
+	}
+	utils.Respond(c, http.StatusCreated, "Tarif pajak berhasil dibuat", nil, rate)
+}
+
+func (h *Handler) Update(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var input Rate
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.Respond(c, http.StatusBadRequest, "Input tidak valid", err.Error(), nil)
+		return
+	}
+	rate, err := h.service.Update(uint(id), &input)
+	if err != nil {
+		h.respondError(c, "Gagal memperbarui tarif pajak", err)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Tarif pajak berhasil diperbarui", nil, rate)
+}
+
+func (h *Handler) Delete(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err := h.service.Delete(uint(id)); err != nil {
+		h.respondError(c, "Gagal menghapus tarif pajak", err)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Tarif pajak berhasil dihapus", nil, nil)
+}
+
+// VATReport mengembalikan rekap PPN masukan dan keluaran per bulan,
+// bawaannya bulan berjalan
+func (h *Handler) VATReport(c *gin.Context) {
+	month := c.DefaultQuery("month", time.Now().Format("2006-01"))
+	report, err := h.service.MonthlyVAT(month)
+	if err != nil {
+		if errors.Is(err, ErrInvalidMonth) {
+			utils.Respond(c, http.StatusBadRequest, err.Error(), err.Error(), nil)
+			return
+		}
+		utils.Respond(c, http.StatusInternalServerError, "Gagal menyusun laporan PPN", err.Error(), nil)
+		return
+	}
+	utils.Respond(c, http.StatusOK, "Laporan PPN berhasil disusun", nil, report)
+}
+
+func (h *Handler) respondError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		utils.Respond(c, http.StatusNotFound, err.Error(), err.Error(), nil)
+	case errors.Is(err, ErrInvalidInput):
+		utils.Respond(c, http.StatusBadRequest, err.Error(), err.Error(), nil)
+	default:
+		utils.Respond(c, http.StatusInternalServerError, message, err.Error(), nil)
+	}
+}
@@ -0,0 +1,69 @@
+package tax
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Rate adalah tarif pajak (PPN) yang dapat dipasang pada produk atau
+// kategori. Produk tanpa tarif, baik langsung maupun lewat kategorinya,
+// diperlakukan sebagai bukan objek pajak.
+type Rate struct {
+	ID   uint   `gorm:"primaryKey" json:"id"`
+	Code string `gorm:"type:varchar(20);uniqueIndex;not null" json:"code" form:"code"`
+	Name string `gorm:"type:varchar(100);not null" json:"name" form:"name"`
+	// Percent adalah besaran tarif, misalnya 11 untuk PPN 11%
+	Percent float64 `gorm:"type:decimal(5,2);not null" json:"percent" form:"percent"`
+	// Exempt menandai barang dibebaskan dari PPN (tarif dianggap 0)
+	Exempt    bool           `gorm:"not null;default:false" json:"exempt" form:"exempt"`
+	Active    bool           `gorm:"not null;default:true" json:"active" form:"active"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+func (Rate) TableName() string {
+	return "tax_rates"
+}
+
+// Line adalah nilai satu baris transaksi setelah potongan baris, beserta
+// tarif pajaknya dalam persen
+type Line struct {
+	Amount  float64
+	Percent float64
+}
+
+// LineTax adalah hasil perhitungan pajak satu baris
+type LineTax struct {
+	Percent float64 `json:"percent"`
+	Base    float64 `json:"base"`
+	Tax     float64 `json:"tax"`
+}
+
+// Totals adalah jumlah DPP dan pajak seluruh baris
+type Totals struct {
+	Base float64 `json:"base"`
+	Tax  float64 `json:"tax"`
+}
+
+// VATSummary adalah DPP dan PPN per tarif pada laporan
+type VATSummary struct {
+	Percent float64 `json:"percent"`
+	Base    float64 `json:"base"`
+	Tax     float64 `json:"tax"`
+}
+
+// VATReport adalah rekap PPN keluaran (penjualan) dan PPN masukan
+// (pembelian) satu masa pajak
+type VATReport struct {
+	Period           string       `json:"period"`
+	Output           []VATSummary `json:"output"`
+	OutputBase       float64      `json:"output_base"`
+	OutputTax        float64      `json:"output_tax"`
+	Input            []VATSummary `json:"input"`
+	InputBase        float64      `json:"input_base"`
+	InputTax         float64      `json:"input_tax"`
+	NetPayable       float64      `json:"net_payable"`
+	PricesIncludeTax bool         `json:"prices_include_tax"`
+}
@@ -0,0 +1,23 @@
+package tax
+
+import (
+	"go-gin-auth/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TaxRouter(api *gin.RouterGroup) {
+	service := NewService(config.DB)
+	handler := NewHandler(service)
+
+	taxes := api.Group("/tax")
+	{
+		taxes.GET("/rates", handler.GetAll)
+		taxes.GET("/rates/:id", handler.GetByID)
+		taxes.POST("/rates", handler.Create)
+		taxes.PUT("/rates/:id", handler.Update)
+		taxes.DELETE("/rates/:id", handler.Delete)
+
+		taxes.GET("/vat-report", handler.VATReport)
+	}
+}
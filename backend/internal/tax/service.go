@@ -0,0 +1,177 @@
+package tax
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotFound     = errors.New("tarif pajak tidak ditemukan")
+	ErrInvalidInput = errors.New("data tarif pajak tidak valid")
+	ErrInvalidMonth = errors.New("parameter month harus berformat YYYY-MM")
+)
+
+type Service struct {
+	db *gorm.DB
+}
+
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db}
+}
+
+func (s *Service) GetAll() ([]Rate, error) {
+	var rates []Rate
+	if err := s.db.Order("code").Find(&rates).Error; err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+func (s *Service) GetByID(id uint) (*Rate, error) {
+	var rate Rate
+	if err := s.db.First(&rate, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &rate, nil
+}
+
+func (s *Service) Create(rate *Rate) (*Rate, error) {
+	if err := validate(rate); err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(rate).Error; err != nil {
+		return nil, err
+	}
+	return rate, nil
+}
+
+func (s *Service) Update(id uint, input *Rate) (*Rate, error) {
+	existing, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := validate(input); err != nil {
+		return nil, err
+	}
+
+	input.ID = existing.ID
+	input.CreatedAt = existing.CreatedAt
+	if err := s.db.Save(input).Error; err != nil {
+		return nil, err
+	}
+	return input, nil
+}
+
+func (s *Service) Delete(id uint) error {
+	result := s.db.Delete(&Rate{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// MonthlyVAT menyusun rekap PPN keluaran dan masukan untuk masa pajak
+// month (format 2006-01)
+func (s *Service) MonthlyVAT(month string) (*VATReport, error) {
+	start, err := time.ParseInLocation("2006-01", month, time.Local)
+	if err != nil {
+		return nil, ErrInvalidMonth
+	}
+	end := start.AddDate(0, 1, 0)
+
+	report := &VATReport{Period: month}
+	if report.PricesIncludeTax, err = PricesIncludeTax(s.db); err != nil {
+		return nil, err
+	}
+
+	err = s.db.Raw(`
+		SELECT tax_rate AS percent, SUM(tax_base) AS base, SUM(tax_amount) AS tax
+		FROM (
+			SELECT i.tax_rate, i.tax_base, i.tax_amount
+			FROM sales_regular_items i
+			JOIN sales_regulars s ON s.id = i.sales_regular_id
+			WHERE i.deleted_at IS NULL AND s.deleted_at IS NULL AND s.status <> 'Batal'
+			AND s.transaction_date >= ? AND s.transaction_date < ?
+			UNION ALL
+			SELECT i.tax_rate, i.tax_base, i.tax_amount
+			FROM prescription_items i
+			JOIN prescription_sales s ON s.id = i.prescription_sale_id
+			WHERE i.deleted_at IS NULL AND s.deleted_at IS NULL AND s.status <> 'Batal'
+			AND s.transaction_date >= ? AND s.transaction_date < ?
+			UNION ALL
+			SELECT k.tax_rate, k.tax_base, k.tax_amount
+			FROM prescription_compound_components k
+			JOIN prescription_compounds c ON c.id = k.compound_id
+			JOIN prescription_sales s ON s.id = c.prescription_sale_id
+			WHERE k.deleted_at IS NULL AND c.deleted_at IS NULL AND s.deleted_at IS NULL AND s.status <> 'Batal'
+			AND s.transaction_date >= ? AND s.transaction_date < ?
+			UNION ALL
+			SELECT 0, c.fee_tax_base, 0
+			FROM prescription_compounds c
+			JOIN prescription_sales s ON s.id = c.prescription_sale_id
+			WHERE c.deleted_at IS NULL AND s.deleted_at IS NULL AND s.status <> 'Batal'
+			AND s.transaction_date >= ? AND s.transaction_date < ?
+		) t
+		GROUP BY tax_rate
+		ORDER BY tax_rate
+	`, start, end, start, end, start, end, start, end).Scan(&report.Output).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize output VAT: %w", err)
+	}
+
+	err = s.db.Raw(`
+		SELECT tax_rate AS percent, SUM(tax_base) AS base, SUM(tax_amount) AS tax
+		FROM (
+			SELECT d.tax_rate, d.tax_base, d.tax_amount
+			FROM incoming_pbf_details d
+			JOIN incoming_pbfs h ON h.id = d.incoming_pbf_id
+			WHERE h.receipt_date >= ? AND h.receipt_date < ?
+			UNION ALL
+			SELECT d.tax_rate, d.tax_base, d.tax_amount
+			FROM incoming_non_pbf_details d
+			JOIN incoming_non_pbfs h ON h.id = d.incoming_non_pbf_id
+			WHERE d.deleted_at IS NULL AND h.deleted_at IS NULL
+			AND h.incoming_date >= ? AND h.incoming_date < ?
+		) t
+		GROUP BY tax_rate
+		ORDER BY tax_rate
+	`, start, end, start, end).Scan(&report.Input).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize input VAT: %w", err)
+	}
+
+	for _, o := range report.Output {
+		report.OutputBase += o.Base
+		report.OutputTax += o.Tax
+	}
+	for _, i := range report.Input {
+		report.InputBase += i.Base
+		report.InputTax += i.Tax
+	}
+	report.OutputBase = round(report.OutputBase)
+	report.OutputTax = round(report.OutputTax)
+	report.InputBase = round(report.InputBase)
+	report.InputTax = round(report.InputTax)
+	report.NetPayable = round(report.OutputTax - report.InputTax)
+
+	return report, nil
+}
+
+func validate(rate *Rate) error {
+	if rate.Code == "" || rate.Name == "" {
+		return fmt.Errorf("%w: kode dan nama wajib diisi", ErrInvalidInput)
+	}
+	if rate.Percent < 0 || rate.Percent > 100 {
+		return fmt.Errorf("%w: tarif harus antara 0 dan 100 persen", ErrInvalidInput)
+	}
+	return nil
+}
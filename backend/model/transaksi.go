@@ -1,10 +1,6 @@
 package model
 
-import (
-	"fmt"
-	"math/rand"
-	"time"
-)
+import "time"
 
 // Transaksi merepresentasikan data pembelian obat
 type Transaksi struct {
@@ -22,22 +18,3 @@ type Transaksi struct {
 func (Transaksi) TableName() string {
 	return "transaksi"
 }
-
-// GenerateNomorTransaksi membuat nomor invoice unik, contoh: INV-20250506-XYZ123
-func GenerateNomorTransaksi() string {
-	timestamp := time.Now().Format("20060102")
-	suffix := randomString(6)
-	return fmt.Sprintf("INV-%s-%s", timestamp, suffix)
-}
-
-// randomString menghasilkan string acak untuk suffix invoice
-func randomString(length int) string {
-	letters := []rune("ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789")
-	rand.Seed(time.Now().UnixNano())
-
-	s := make([]rune, length)
-	for i := range s {
-		s[i] = letters[rand.Intn(len(letters))]
-	}
-	return string(s)
-}
@@ -0,0 +1,218 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Ukuran halaman dalam point (1 pt = 1/72 inci)
+const (
+	A4Width  = 595.28
+	A4Height = 841.89
+	MMToPt   = 72.0 / 25.4
+)
+
+type Font string
+
+const (
+	Regular Font = "F1" // Helvetica
+	Bold    Font = "F2" // Helvetica-Bold
+	Mono    Font = "F3" // Courier
+	MonoB   Font = "F4" // Courier-Bold
+)
+
+var fontNames = map[Font]string{
+	Regular: "Helvetica",
+	Bold:    "Helvetica-Bold",
+	Mono:    "Courier",
+	MonoB:   "Courier-Bold",
+}
+
+type Align int
+
+const (
+	Left Align = iota
+	Right
+	Center
+)
+
+// Column adalah satu sel teks pada Row, X diukur dari margin kiri
+type Column struct {
+	X     float64
+	Width float64
+	Text  string
+	Align Align
+}
+
+// Document adalah penulis PDF sederhana berbasis teks dengan font standar PDF,
+// cukup untuk laporan, struk dan label tanpa dependensi eksternal.
+type Document struct {
+	width, height float64
+	margin        float64
+	font          Font
+	size          float64
+	leading       float64
+	y             float64
+	pages         []*bytes.Buffer
+}
+
+func New(width, height, margin float64) *Document {
+	d := &Document{width: width, height: height, margin: margin}
+	d.SetFont(Regular, 10)
+	d.AddPage()
+	return d
+}
+
+func (d *Document) SetFont(font Font, size float64) {
+	d.font = font
+	d.size = size
+	d.leading = size * 1.3
+}
+
+func (d *Document) AddPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = d.height - d.margin
+}
+
+// PageCount adalah jumlah halaman yang sudah dibuat, termasuk halaman yang
+// ditambahkan otomatis saat isi tidak muat
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+// ContentWidth adalah lebar area tulis di antara margin kiri dan kanan
+func (d *Document) ContentWidth() float64 {
+	return d.width - 2*d.margin
+}
+
+// Text menulis satu baris rata kiri lalu pindah ke baris berikutnya
+func (d *Document) Text(s string) {
+	d.Row(Column{Width: d.ContentWidth(), Text: s})
+}
+
+// Centered menulis satu baris rata tengah
+func (d *Document) Centered(s string) {
+	d.Row(Column{Width: d.ContentWidth(), Text: s, Align: Center})
+}
+
+// Row menulis beberapa kolom pada baris yang sama
+func (d *Document) Row(cols ...Column) {
+	d.ensureSpace(d.leading)
+	d.y -= d.size
+	page := d.pages[len(d.pages)-1]
+	for _, c := range cols {
+		x := d.margin + c.X
+		w := d.textWidth(c.Text)
+		switch c.Align {
+		case Right:
+			x += c.Width - w
+		case Center:
+			x += (c.Width - w) / 2
+		}
+		fmt.Fprintf(page, "BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n", d.font, d.size, x, d.y, escape(c.Text))
+	}
+	d.y -= d.leading - d.size
+}
+
+// Line menggambar garis horizontal selebar area tulis
+func (d *Document) Line() {
+	d.ensureSpace(d.leading / 2)
+	d.y -= d.leading / 4
+	fmt.Fprintf(d.pages[len(d.pages)-1], "0.5 w %.2f %.2f m %.2f %.2f l S\n", d.margin, d.y, d.width-d.margin, d.y)
+	d.y -= d.leading / 4
+}
+
+// Rect menggambar kotak dengan sudut kiri atas pada posisi tulis saat ini
+func (d *Document) Rect(x, w, h float64) {
+	fmt.Fprintf(d.pages[len(d.pages)-1], "0.8 w %.2f %.2f %.2f %.2f re S\n", d.margin+x, d.y-h, w, h)
+}
+
+// Gap menambah jarak vertikal kosong
+func (d *Document) Gap(h float64) {
+	d.ensureSpace(h)
+	d.y -= h
+}
+
+func (d *Document) ensureSpace(h float64) {
+	if d.y-h < d.margin {
+		d.AddPage()
+	}
+}
+
+// textWidth memperkirakan lebar teks; Courier lebarnya tetap 0.6 em,
+// Helvetica didekati dengan rata-rata 0.5 em per karakter
+func (d *Document) textWidth(s string) float64 {
+	factor := 0.5
+	if d.font == Mono || d.font == MonoB {
+		factor = 0.6
+	}
+	return float64(len([]rune(s))) * d.size * factor
+}
+
+// Bytes menghasilkan isi file PDF lengkap
+func (d *Document) Bytes() []byte {
+	var out bytes.Buffer
+	var offsets []int
+	obj := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n")
+
+	// 1: catalog, 2: pages, 3-6: font, lalu pasangan page/content
+	fontOrder := []Font{Regular, Bold, Mono, MonoB}
+	firstPage := 3 + len(fontOrder)
+	var kids []string
+	for i := range d.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", firstPage+i*2))
+	}
+
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+
+	var fontRefs []string
+	for i, f := range fontOrder {
+		obj(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", fontNames[f]))
+		fontRefs = append(fontRefs, fmt.Sprintf("/%s %d 0 R", f, 3+i))
+	}
+
+	for i, page := range d.pages {
+		contentRef := firstPage + i*2 + 1
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << %s >> >> /Contents %d 0 R >>",
+			d.width, d.height, strings.Join(fontRefs, " "), contentRef))
+		obj(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.Bytes()
+}
+
+// escape menyiapkan teks untuk string literal PDF; karakter di luar
+// Latin-1 diganti tanda tanya karena font standar memakai WinAnsiEncoding
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32:
+			b.WriteByte(' ')
+		case r > 255:
+			b.WriteByte('?')
+		case r > 127:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
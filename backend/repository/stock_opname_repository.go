@@ -37,6 +37,7 @@ type StockOpnameRepository interface {
 	PreloadProduct(detail *opname.StockOpnameDetail) error
 	FindStockOpNameDetailByID(detailID int) (*opname.StockOpnameDetail, error)
 	UpdateStockOpNameDetail(detail *opname.StockOpnameDetail) error
+	UpdateStockOpNameDetailTx(tx *gorm.DB, detail *opname.StockOpnameDetail) error
 	DeleteStockOpNameDetail(detailID int) error
 	// reporting
 	FindByStatusAndDateRange(status opname.StockOpnameStatus, startDate, endDate time.Time, opnameID string) ([]opname.StockOpname, error)
@@ -368,6 +369,9 @@ func (r *stockOpnameRepository) FindStockOpNameDetailByID(detailID int) (*opname
 func (r *stockOpnameRepository) UpdateStockOpNameDetail(detail *opname.StockOpnameDetail) error {
 	return r.db.Save(detail).Error
 }
+func (r *stockOpnameRepository) UpdateStockOpNameDetailTx(tx *gorm.DB, detail *opname.StockOpnameDetail) error {
+	return tx.Omit("Product").Save(detail).Error
+}
 func (r *stockOpnameRepository) DeleteStockOpNameDetail(detailID int) error {
 	return r.db.Where("detail_id = ?", detailID).Delete(&opname.StockOpnameDetail{}).Error
 }
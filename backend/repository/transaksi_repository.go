@@ -2,7 +2,10 @@ package repository
 
 import (
 	"go-gin-auth/config"
+	"go-gin-auth/internal/numbering"
 	"go-gin-auth/model"
+
+	"gorm.io/gorm"
 )
 
 type TransaksiRepository interface {
@@ -17,8 +20,17 @@ func NewTransaksiRepository() TransaksiRepository {
 	return &transaksiRepository{}
 }
 
+// Create menerbitkan nomor transaksi dan menyimpan transaksi dalam satu
+// transaksi database agar nomor tidak berlubang
 func (r *transaksiRepository) Create(transaksi *model.Transaksi) error {
-	return config.DB.Create(transaksi).Error
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		number, err := numbering.Next(tx, numbering.Transaksi, transaksi.TanggalPembelian)
+		if err != nil {
+			return err
+		}
+		transaksi.NomorTransaksi = number
+		return tx.Create(transaksi).Error
+	})
 }
 
 func (r *transaksiRepository) FindAll() ([]model.Transaksi, error) {
@@ -7,25 +7,40 @@ import (
 	"go-gin-auth/internal/analytics"
 	"go-gin-auth/internal/brand"
 	"go-gin-auth/internal/category"
+	"go-gin-auth/internal/customer"
 	"go-gin-auth/internal/dashboard"
+	"go-gin-auth/internal/dispensing"
 	"go-gin-auth/internal/doctor"
 	"go-gin-auth/internal/drug_category"
 	"go-gin-auth/internal/expense"
 	"go-gin-auth/internal/expense_type"
+	"go-gin-auth/internal/idempotency"
 	"go-gin-auth/internal/incomingProducts"
+	"go-gin-auth/internal/interaction"
+	"go-gin-auth/internal/ledger"
 	"go-gin-auth/internal/location"
+	"go-gin-auth/internal/narcotic"
 	"go-gin-auth/internal/nonpbf"
+	"go-gin-auth/internal/numbering"
 	"go-gin-auth/internal/outgoingProducts"
 	"go-gin-auth/internal/patient"
+	"go-gin-auth/internal/payment"
 	"go-gin-auth/internal/pbf"
+	"go-gin-auth/internal/pharmacy"
+	"go-gin-auth/internal/pos"
 	"go-gin-auth/internal/prescription"
 	"go-gin-auth/internal/product"
+	"go-gin-auth/internal/promotion"
+	"go-gin-auth/internal/receivable"
+	"go-gin-auth/internal/register"
+	"go-gin-auth/internal/sale_void"
 	"go-gin-auth/internal/sales"
 	"go-gin-auth/internal/shift"
 	"go-gin-auth/internal/stock"
 	"go-gin-auth/internal/stock_correction"
 	storagelocation "go-gin-auth/internal/storage_location"
 	"go-gin-auth/internal/supplier"
+	"go-gin-auth/internal/tax"
 	"go-gin-auth/internal/unit"
 	"go-gin-auth/middleware"
 	"go-gin-auth/repository"
@@ -51,8 +66,8 @@ func SetupRouter() *gin.Engine {
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000"}, // alamat asal React kamu
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", idempotency.HeaderKey},
+		ExposeHeaders:    []string{"Content-Length", idempotency.HeaderReplayed},
 		AllowCredentials: true,
 	}))
 
@@ -150,6 +165,7 @@ func SetupRouter() *gin.Engine {
 			// Completion operations
 			stockOpname.Use(middleware.AuthMiddleware()).POST("/:opnameID/complete", ctrlOpname.CompleteOpname)
 			stockOpname.Use(middleware.AuthMiddleware()).POST("/:opnameID/cancel", ctrlOpname.CancelOpname)
+			stockOpname.Use(middleware.AuthMiddleware()).GET("/:opnameID/report", ctrlOpname.GetOpnameReport)
 
 			// users story
 			stockOpname.Use(middleware.AuthMiddleware()).GET("/history", ctrlOpname.GetStockOpnameHistory)
@@ -182,6 +198,11 @@ func SetupRouter() *gin.Engine {
 			outgoingProductGroup.DELETE("/:id", outgoingProduct.DeleteOutgoingProduct)
 		}
 
+		pos.PosRouter(api)
+		pharmacy.PharmacyRouter(api)
+		promotion.PromotionRouter(api)
+		customer.CustomerRouter(api)
+
 		apiAuth := api
 		apiAuth.Use(middleware.AuthAdminMiddleware())
 		storagelocation.StorageLocationRouter(apiAuth)
@@ -192,11 +213,19 @@ func SetupRouter() *gin.Engine {
 		patient.PatientRouter(apiAuth)
 		drug_category.DrugCategoryRouter(apiAuth)
 		shift.ShiftRouter(apiAuth)
+		register.RegisterRouter(apiAuth)
+		ledger.LedgerRouter(apiAuth)
 		stock_correction.StockCorrectionRouter(apiAuth)
+		tax.TaxRouter(apiAuth)
+		receivable.ReceivableRouter(apiAuth)
+		numbering.NumberingRouter(apiAuth)
+		interaction.InteractionRouter(apiAuth)
+		narcotic.NarcoticRouter(apiAuth)
+		dispensing.DispensingRouter(apiAuth)
 
 		pbfRouter := api.Group("/incoming-pbf")
 		pbfRouter.Use(middleware.AuthMiddleware()).GET("", pbf.GetAllIncomingPBF)
-		pbfRouter.Use(middleware.AuthMiddleware()).POST("", pbf.CreateIncomingPBF)
+		pbfRouter.Use(middleware.AuthMiddleware()).POST("", idempotency.Middleware(), pbf.CreateIncomingPBF)
 		pbfRouter.Use(middleware.AuthMiddleware()).GET("/:id", pbf.GetIncomingPBFByID)
 		pbfRouter.Use(middleware.AuthMiddleware()).PUT("/:id", pbf.UpdateIncomingPBF)
 		pbfRouter.Use(middleware.AuthMiddleware()).DELETE("/:id", pbf.DeleteIncomingPBF)
@@ -206,7 +235,7 @@ func SetupRouter() *gin.Engine {
 
 		nonpbfRouter := api.Group("/incoming-nonpbf", middleware.AuthMiddleware())
 		nonpbfRouter.GET("", nonpbfController.GetAll)
-		nonpbfRouter.POST("", nonpbfController.Create)
+		nonpbfRouter.POST("", idempotency.Middleware(), nonpbfController.Create)
 		nonpbfRouter.GET("/:id", nonpbfController.GetByID)
 		nonpbfRouter.PUT("/:id", nonpbfController.Update)
 		nonpbfRouter.DELETE("/:id", nonpbfController.Delete)
@@ -218,9 +247,12 @@ func SetupRouter() *gin.Engine {
 		{
 			prescriptions.GET("", handlerPrescriptions.GetAll)
 			prescriptions.GET("/:id", handlerPrescriptions.GetByID)
-			prescriptions.POST("", handlerPrescriptions.Create)
+			prescriptions.GET("/:id/receipt", handlerPrescriptions.Receipt)
+			prescriptions.GET("/:id/remainder", handlerPrescriptions.Remainder)
+			prescriptions.GET("/:id/copy", handlerPrescriptions.Copy)
+			prescriptions.GET("/:id/labels", handlerPrescriptions.Labels)
+			prescriptions.POST("", idempotency.Middleware(), handlerPrescriptions.Create)
 			prescriptions.PUT("/:id", handlerPrescriptions.Update)
-			prescriptions.DELETE("/:id", handlerPrescriptions.Delete)
 		}
 
 		salesRepo := sales.NewSalesRegularRepository(config.DB)
@@ -228,15 +260,31 @@ func SetupRouter() *gin.Engine {
 		salesService := sales.NewSalesRegularService(config.DB, salesRepo, stockRepo)
 		salesHandler := sales.NewSalesRegularHandler(salesService)
 
+		heldSaleService := sales.NewHeldSaleService(config.DB, salesService)
+		heldSaleHandler := sales.NewHeldSaleHandler(heldSaleService)
+
 		salesGroup := api.Group("/sales/regular")
 		{
+			salesGroup.GET("/held", heldSaleHandler.GetAll)
+			salesGroup.GET("/held/:id", heldSaleHandler.GetByID)
+			salesGroup.POST("/held", heldSaleHandler.Hold)
+			salesGroup.PUT("/held/:id", heldSaleHandler.Update)
+			salesGroup.POST("/held/:id/finalize", idempotency.Middleware(), heldSaleHandler.Finalize)
+			salesGroup.DELETE("/held/:id", heldSaleHandler.Discard)
+
 			salesGroup.GET("", salesHandler.GetAll)
 			salesGroup.GET("/:id", salesHandler.GetByID)
-			salesGroup.POST("", salesHandler.Create)
+			salesGroup.GET("/:id/receipt", salesHandler.Receipt)
+			salesGroup.POST("", idempotency.Middleware(), salesHandler.Create)
 			salesGroup.PUT("/:id", salesHandler.Update)
-			salesGroup.DELETE("/:id", salesHandler.Delete)
 		}
 
+		sale_void.VoidRouter(api, map[string]sale_void.Target{
+			payment.SaleRegular:      salesService,
+			payment.SalePrescription: servicePrescriptions,
+		})
+		pos.SyncRouter(api, salesService)
+
 		stockService := stock.NewStockService(config.DB)
 		stockHandler := stock.NewStockHandler(stockService)
 		stock := api.Group("/stocks")
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"go-gin-auth/config"
+	"go-gin-auth/dto"
+	"go-gin-auth/internal/category"
+	"go-gin-auth/internal/opname"
+	"go-gin-auth/internal/product"
+	"go-gin-auth/model"
+	"go-gin-auth/pkg/pdf"
+	"go-gin-auth/utils"
+	"sort"
+	"strconv"
+)
+
+// GetOpnameReport menyusun data berita acara untuk opname yang sudah selesai
+func (s *stockOpnameService) GetOpnameReport(opnameID string) (*dto.StockOpnameReport, error) {
+	data, err := s.repo.FindByIDWithDetails(opnameID)
+	if err != nil {
+		return nil, err
+	}
+
+	if data.Status != opname.Completed {
+		return nil, errors.New("berita acara hanya tersedia untuk stock opname yang sudah selesai")
+	}
+
+	db := config.DB
+
+	productIDs := make([]uint, 0, len(data.Details))
+	userIDs := []string{data.CompletedBy}
+	for _, d := range data.Details {
+		productIDs = append(productIDs, d.ProductID)
+		userIDs = append(userIDs, d.PerformedBy)
+	}
+
+	var products []product.Product
+	if err := db.Unscoped().Where("id IN ?", productIDs).Find(&products).Error; err != nil {
+		return nil, err
+	}
+	var categories []category.Category
+	if err := db.Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	var users []model.User
+	if err := db.Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	productMap := make(map[uint]product.Product, len(products))
+	for _, p := range products {
+		productMap[p.ID] = p
+	}
+	categoryMap := make(map[uint]string, len(categories))
+	for _, c := range categories {
+		categoryMap[c.ID] = c.Name
+	}
+	userMap := make(map[string]string, len(users))
+	for _, u := range users {
+		userMap[strconv.FormatUint(uint64(u.ID), 10)] = u.FullName
+	}
+	userName := func(id string) string {
+		if name, ok := userMap[id]; ok && name != "" {
+			return name
+		}
+		return id
+	}
+
+	report := &dto.StockOpnameReport{
+		OpnameID:             data.OpnameID,
+		OpnameDate:           data.OpnameDate,
+		StartTime:            data.StartTime,
+		EndTime:              data.EndTime,
+		Notes:                data.Notes,
+		Approver:             userName(data.CompletedBy),
+		TotalVarianceCost:    data.TotalVarianceCost,
+		TotalVarianceSelling: data.TotalVarianceSelling,
+		ShrinkagePercent:     data.ShrinkagePercent,
+	}
+
+	counterSeen := make(map[string]bool)
+	categoryTotals := make(map[string]*dto.StockOpnameCategoryVariance)
+	for _, d := range data.Details {
+		p := productMap[d.ProductID]
+		categoryName := categoryMap[p.CategoryID]
+		counter := userName(d.PerformedBy)
+
+		report.Lines = append(report.Lines, dto.StockOpnameReportLine{
+			ProductID:       d.ProductID,
+			ProductCode:     p.Code,
+			ProductName:     p.Name,
+			Category:        categoryName,
+			SystemStock:     d.SystemStock,
+			ActualStock:     d.ActualStock,
+			Discrepancy:     d.Discrepancy,
+			CostPrice:       d.CostPrice,
+			SellingPrice:    d.SellingPrice,
+			VarianceCost:    d.VarianceCost,
+			VarianceSelling: d.VarianceSelling,
+			Note:            d.AdjustmentNote,
+			PerformedBy:     counter,
+		})
+
+		if !counterSeen[counter] {
+			counterSeen[counter] = true
+			report.Counters = append(report.Counters, counter)
+		}
+
+		total, ok := categoryTotals[categoryName]
+		if !ok {
+			total = &dto.StockOpnameCategoryVariance{Category: categoryName}
+			categoryTotals[categoryName] = total
+		}
+		total.ProductCount++
+		total.VarianceCost += d.VarianceCost
+		total.VarianceSelling += d.VarianceSelling
+	}
+
+	for _, total := range categoryTotals {
+		report.Categories = append(report.Categories, *total)
+	}
+	sort.Slice(report.Categories, func(i, j int) bool {
+		return report.Categories[i].Category < report.Categories[j].Category
+	})
+
+	return report, nil
+}
+
+// RenderOpnameReportPDF mencetak berita acara stock opname dalam format PDF A4
+func RenderOpnameReportPDF(report *dto.StockOpnameReport) []byte {
+	doc := pdf.New(pdf.A4Width, pdf.A4Height, 40)
+
+	doc.SetFont(pdf.Bold, 14)
+	doc.Centered("BERITA ACARA STOCK OPNAME")
+	doc.SetFont(pdf.Regular, 10)
+	doc.Centered("No. " + report.OpnameID)
+	doc.Gap(10)
+
+	doc.Text("Tanggal opname : " + report.OpnameDate.Format("02-01-2006"))
+	doc.Text("Waktu mulai    : " + report.StartTime.Format("02-01-2006 15:04"))
+	doc.Text("Waktu selesai  : " + report.EndTime.Format("02-01-2006 15:04"))
+	for i, counter := range report.Counters {
+		label := "                 "
+		if i == 0 {
+			label = "Petugas hitung : "
+		}
+		doc.Text(label + counter)
+	}
+	doc.Text("Disetujui oleh : " + report.Approver)
+	if report.Notes != "" {
+		doc.Text("Catatan        : " + report.Notes)
+	}
+	doc.Gap(8)
+
+	doc.SetFont(pdf.MonoB, 7)
+	doc.Row(opnameLineColumns("Kode", "Nama Produk", "Sistem", "Fisik", "Selisih", "HPP", "Nilai HPP", "Nilai Jual")...)
+	doc.SetFont(pdf.Mono, 7)
+	doc.Line()
+	for _, l := range report.Lines {
+		doc.Row(opnameLineColumns(
+			l.ProductCode, l.ProductName,
+			strconv.Itoa(l.SystemStock), strconv.Itoa(l.ActualStock), strconv.Itoa(l.Discrepancy),
+			utils.FormatRupiah(l.CostPrice), utils.FormatRupiah(l.VarianceCost), utils.FormatRupiah(l.VarianceSelling),
+		)...)
+	}
+	doc.Line()
+	doc.SetFont(pdf.MonoB, 7)
+	doc.Row(opnameLineColumns("", "TOTAL", "", "", "", "", utils.FormatRupiah(report.TotalVarianceCost), utils.FormatRupiah(report.TotalVarianceSelling))...)
+	doc.Gap(10)
+
+	doc.SetFont(pdf.Bold, 10)
+	doc.Text("Rekap per kategori")
+	doc.SetFont(pdf.Mono, 8)
+	for _, c := range report.Categories {
+		doc.Row(
+			pdf.Column{Width: 200, Text: truncate(c.Category, 40)},
+			pdf.Column{X: 200, Width: 60, Text: fmt.Sprintf("%d item", c.ProductCount), Align: pdf.Right},
+			pdf.Column{X: 270, Width: 110, Text: utils.FormatRupiah(c.VarianceCost), Align: pdf.Right},
+			pdf.Column{X: 390, Width: 110, Text: utils.FormatRupiah(c.VarianceSelling), Align: pdf.Right},
+		)
+	}
+	doc.Gap(6)
+	doc.SetFont(pdf.Regular, 10)
+	doc.Text(fmt.Sprintf("Persentase penyusutan bersih: %.2f%%", report.ShrinkagePercent))
+	doc.Gap(30)
+
+	doc.Row(
+		pdf.Column{Width: 200, Text: "Petugas Hitung", Align: pdf.Center},
+		pdf.Column{X: doc.ContentWidth() - 200, Width: 200, Text: "Penyetuju", Align: pdf.Center},
+	)
+	doc.Gap(50)
+	counter := ""
+	if len(report.Counters) > 0 {
+		counter = report.Counters[0]
+	}
+	doc.Row(
+		pdf.Column{Width: 200, Text: "( " + counter + " )", Align: pdf.Center},
+		pdf.Column{X: doc.ContentWidth() - 200, Width: 200, Text: "( " + report.Approver + " )", Align: pdf.Center},
+	)
+
+	return doc.Bytes()
+}
+
+func opnameLineColumns(code, name, system, actual, diff, cost, varCost, varSelling string) []pdf.Column {
+	return []pdf.Column{
+		{Width: 50, Text: truncate(code, 11)},
+		{X: 52, Width: 150, Text: truncate(name, 35)},
+		{X: 205, Width: 35, Text: system, Align: pdf.Right},
+		{X: 243, Width: 35, Text: actual, Align: pdf.Right},
+		{X: 281, Width: 38, Text: diff, Align: pdf.Right},
+		{X: 322, Width: 60, Text: cost, Align: pdf.Right},
+		{X: 385, Width: 65, Text: varCost, Align: pdf.Right},
+		{X: 453, Width: 62, Text: varSelling, Align: pdf.Right},
+	}
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
@@ -7,15 +7,17 @@ import (
 	"go-gin-auth/config"
 	"go-gin-auth/dto"
 	"go-gin-auth/internal/adjustment"
+	"go-gin-auth/internal/narcotic"
+	"go-gin-auth/internal/numbering"
 	"go-gin-auth/internal/opname"
 	"go-gin-auth/internal/product"
 	"go-gin-auth/internal/stock"
 	"go-gin-auth/repository"
 	"go-gin-auth/utils"
 	"strconv"
+	"strings"
 	"time"
 
-	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -29,9 +31,9 @@ type StockOpnameService interface {
 	GetStockDiscrepancies(ctx context.Context) ([]dto.StockDiscrepancy, error)
 	AdjustProductStock(ctx context.Context, productID string, req dto.StockAdjustmentRequest) (*adjustment.StockAdjustment, error)
 	//Draft operations
-	CreateDraft(createdBy string, opnameDate dto.DateOnly, notes string) (*opname.StockOpname, error)
+	CreateDraft(createdBy string, opnameDate dto.DateOnly, notes string, policy opname.MovementPolicy) (*opname.StockOpname, error)
 	GetDraft(opnameID string) (*opname.StockOpname, error)
-	UpdateDraft(opnameID string, opnameDate dto.DateOnly, notes string) (*opname.StockOpname, error)
+	UpdateDraft(opnameID string, opnameDate dto.DateOnly, notes string, policy opname.MovementPolicy) (*opname.StockOpname, error)
 	DeleteDraft(opnameID string) error
 
 	AddProductToDraft(opnameID string, productID string) (*opname.StockOpnameDetail, error)
@@ -47,6 +49,7 @@ type StockOpnameService interface {
 	GetOpnameDetails(opnameID string) (*opname.StockOpname, error)
 	GetOpnameList(status opname.StockOpnameStatus, startDate, endDate time.Time, opnameID string) ([]dto.StockOpnameResponse, error)
 	GetProducts(ctx context.Context) ([]dto.ProductStockResponse, error)
+	GetOpnameReport(opnameID string) (*dto.StockOpnameReport, error)
 }
 
 type stockOpnameService struct {
@@ -132,6 +135,8 @@ func (s *stockOpnameService) GetStockDiscrepancies(ctx context.Context) ([]dto.S
 			ActualStock:           detail.ActualStock,
 			Discrepancy:           detail.Discrepancy,
 			DiscrepancyPercentage: detail.DiscrepancyPercentage,
+			VarianceCost:          detail.VarianceCost,
+			VarianceSelling:       detail.VarianceSelling,
 			Flag:                  selectedFlag,
 			OpnameDate:            detail.PerformedAt,
 			PerformedBy:           detail.PerformedBy,
@@ -193,11 +198,12 @@ func (s *stockOpnameService) AdjustProductStock(ctx context.Context, productID s
 	return adjustment, nil
 }
 
-func (s *stockOpnameService) CreateDraft(createdBy string, opnameDate dto.DateOnly, notes string) (*opname.StockOpname, error) {
-	opnameID := fmt.Sprintf("OPN-%s", uuid.New().String()[:8])
+func (s *stockOpnameService) CreateDraft(createdBy string, opnameDate dto.DateOnly, notes string, policy opname.MovementPolicy) (*opname.StockOpname, error) {
+	if policy == "" {
+		policy = opname.Reconcile
+	}
 
 	opname := &opname.StockOpname{
-		OpnameID:   opnameID,
 		OpnameDate: opnameDate.Local(),
 		Status:     opname.Draft,
 		Notes:      notes,
@@ -205,9 +211,19 @@ func (s *stockOpnameService) CreateDraft(createdBy string, opnameDate dto.DateOn
 		CreatedAt:  time.Now(),
 		UpdatedAt:  time.Now(),
 		Jenis:      "Regular",
+		Policy:     policy,
 	}
 
-	if err := s.repo.Create(opname); err != nil {
+	// Nomor opname diterbitkan di transaksi yang sama dengan penyimpanannya
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		opnameID, err := numbering.Next(tx, numbering.StockOpname, opname.OpnameDate)
+		if err != nil {
+			return err
+		}
+		opname.OpnameID = opnameID
+		return tx.Create(opname).Error
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -230,7 +246,7 @@ func (s *stockOpnameService) GetDraft(opnameID string) (*opname.StockOpname, err
 }
 
 // UpdateDraft updates a draft stock opname
-func (s *stockOpnameService) UpdateDraft(opnameID string, opnameDate dto.DateOnly, notes string) (*opname.StockOpname, error) {
+func (s *stockOpnameService) UpdateDraft(opnameID string, opnameDate dto.DateOnly, notes string, policy opname.MovementPolicy) (*opname.StockOpname, error) {
 	data, err := s.repo.GetByID(opnameID)
 	if err != nil {
 		return nil, err
@@ -242,6 +258,9 @@ func (s *stockOpnameService) UpdateDraft(opnameID string, opnameDate dto.DateOnl
 
 	data.OpnameDate = opnameDate.Local()
 	data.Notes = notes
+	if policy != "" {
+		data.Policy = policy
+	}
 	data.UpdatedAt = time.Now()
 
 	if err := s.repo.Update(&data); err != nil {
@@ -313,6 +332,13 @@ func (s *stockOpnameService) AddProductToDraft(opnameID string, productID string
 		PerformedAt: time.Now(),
 	}
 
+	// Produk yang ditambahkan saat opname berjalan langsung diambil snapshot-nya
+	if data.Status == opname.InProgress {
+		if err := s.takeSnapshot(config.DB, detail); err != nil {
+			return nil, err
+		}
+	}
+
 	// // Calculate discrepancy for display purposes
 	detail.CalculateDiscrepancy()
 
@@ -389,6 +415,20 @@ func (s *stockOpnameService) StartOpname(opnameID string, startedBy string) (*op
 		return nil, errors.New("cannot start stock opname with no products")
 	}
 
+	// Ambil snapshot stok sistem untuk setiap produk yang akan dihitung
+	for i := range data.Details {
+		detail := &data.Details[i]
+		if err := s.takeSnapshot(tx, detail); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		detail.CalculateDiscrepancy()
+		if err := s.repo.UpdateStockOpNameDetailTx(tx, detail); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
 	// Update status to in progress
 	data.Status = opname.InProgress
 	data.StartTime = time.Now()
@@ -424,21 +464,49 @@ func (s *stockOpnameService) RecordActualStock(detailID int, actualStock int, pe
 	// 	return nil, errors.New("can only record actual stock for in-progress stock opname")
 	// }
 
-	// Update detail
-	detail.ActualStock = actualStock
-	detail.PerformedBy = performedBy
-	detail.PerformedAt = time.Now()
-	detail.AdjustmentNote = note
-	// Calculate discrepancy
-	detail.CalculateDiscrepancy()
+	// Snapshot stok sistem diambil ulang saat hitung fisik dicatat, di
+	// transaksi yang sama, sehingga mutasi sebelum penghitungan sudah
+	// tercermin di hasil hitung dan tidak dikurangkan dua kali saat selesai
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.takeSnapshot(tx, detail); err != nil {
+			return err
+		}
 
-	if err := s.repo.UpdateStockOpNameDetail(detail); err != nil {
+		// Update detail
+		detail.ActualStock = actualStock
+		detail.PerformedBy = performedBy
+		detail.PerformedAt = time.Now()
+		detail.AdjustmentNote = note
+		// Calculate discrepancy
+		detail.CalculateDiscrepancy()
+
+		return s.repo.UpdateStockOpNameDetailTx(tx, detail)
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return detail, nil
 }
 
+// takeSnapshot mencatat stok sistem saat ini sebagai dasar perhitungan
+// selisih. Baris stok dikunci agar tidak ada mutasi di antara snapshot dan
+// penyimpanan detail.
+func (s *stockOpnameService) takeSnapshot(db *gorm.DB, detail *opname.StockOpnameDetail) error {
+	existingStock, err := stock.NewRepository().GetStockByProductIDForUpdate(db, detail.ProductID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	detail.SystemStock = 0
+	if existingStock != nil {
+		detail.SystemStock = existingStock.Quantity
+	}
+	detail.SnapshotAt = &now
+	return nil
+}
+
 // CompleteOpname completes the stock opname process
 func (s *stockOpnameService) CompleteOpname(opnameID string, completedBy string) (*opname.StockOpname, error) {
 	// Begin transaction
@@ -471,20 +539,63 @@ func (s *stockOpnameService) CompleteOpname(opnameID string, completedBy string)
 	}
 
 	// Create stock adjustments for each product
-	for _, detail := range data.Details {
+	stockRepo := stock.NewRepository()
+	for i := range data.Details {
+		detail := &data.Details[i]
 		// Calculate discrepancy
 		detail.CalculateDiscrepancy()
 
+		existingStock, err := stockRepo.GetStockByProductIDForUpdate(tx, detail.ProductID)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		currentStock := 0
+		if existingStock != nil {
+			currentStock = existingStock.Quantity
+		}
+
+		// Stok akhir = hasil hitung fisik + mutasi sejak snapshot, sehingga
+		// penjualan selama penghitungan tidak hilang tertimpa
+		detail.ApplyMovement(currentStock)
+
+		// Nilai selisih pada harga pokok (harga beli terakhir) dan harga jual
+		costPrice, err := stock.LatestPurchasePrice(tx, detail.ProductID)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		var obat product.Product
+		if err := tx.Select("id", "selling_price").First(&obat, detail.ProductID).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		detail.Valuate(costPrice, obat.SellingPrice)
+
+		if err := s.repo.UpdateStockOpNameDetailTx(tx, detail); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
 		// Only create adjustment if there's a discrepancy
-		if detail.Discrepancy != 0 {
+		if detail.FinalStock != currentStock {
+			adjustmentID, err := numbering.Next(tx, numbering.StockAdjustment, time.Now())
+			if err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+			note := detail.AdjustmentNote
+			if detail.MovementSinceSnapshot != 0 {
+				note = strings.TrimSpace(fmt.Sprintf("%s (mutasi sejak snapshot: %d)", note, detail.MovementSinceSnapshot))
+			}
 			adjustment := &adjustment.StockAdjustment{
-				AdjustmentID:   fmt.Sprintf("ADJ-%s", uuid.New().String()[:8]),
+				AdjustmentID:   adjustmentID,
 				ProductID:      strconv.FormatUint(uint64(detail.ProductID), 10),
-				PreviousStock:  detail.SystemStock,
-				AdjustedStock:  detail.ActualStock,
+				PreviousStock:  currentStock,
+				AdjustedStock:  detail.FinalStock,
 				AdjustmentType: adjustment.Opname,
 				ReferenceID:    opnameID,
-				AdjustmentNote: detail.AdjustmentNote,
+				AdjustmentNote: note,
 				AdjustmentDate: time.Now(),
 				PerformedBy:    completedBy,
 			}
@@ -497,23 +608,16 @@ func (s *stockOpnameService) CompleteOpname(opnameID string, completedBy string)
 			}
 
 			/// disable dulu Update product stock
-			if err := s.repo.UpdateProductStock(tx, strconv.FormatUint(uint64(detail.ProductID), 10), detail.ActualStock); err != nil {
+			if err := s.repo.UpdateProductStock(tx, strconv.FormatUint(uint64(detail.ProductID), 10), detail.FinalStock); err != nil {
 				tx.Rollback()
 				return nil, err
 			}
 			// Update atau Insert ke tabel stocks
-			stockRepo := stock.NewRepository()
-			existingStock, err := stockRepo.GetStockByProductID(tx, detail.ProductID)
-			if err != nil {
-				tx.Rollback()
-				return nil, err
-			}
-
 			if existingStock == nil {
 				// Insert baru
 				newStock := &stock.Stock{
 					ProductID: detail.ProductID,
-					Quantity:  detail.ActualStock,
+					Quantity:  detail.FinalStock,
 				}
 				if err := stockRepo.CreateStock(tx, newStock); err != nil {
 					tx.Rollback()
@@ -521,17 +625,37 @@ func (s *stockOpnameService) CompleteOpname(opnameID string, completedBy string)
 				}
 			} else {
 				// Update existing
-				existingStock.Quantity = detail.ActualStock
+				existingStock.Quantity = detail.FinalStock
 				if err := stockRepo.UpdateStock(tx, existingStock); err != nil {
 					tx.Rollback()
 					return nil, err
 				}
 			}
 
+			// Selisih opname narkotika/psikotropika dicatat sebagai koreksi register
+			line := narcotic.Line{ProductID: detail.ProductID, QtyIn: detail.FinalStock - currentStock}
+			if detail.FinalStock < currentStock {
+				line = narcotic.Line{ProductID: detail.ProductID, QtyOut: currentStock - detail.FinalStock}
+			}
+			if err := narcotic.Record(tx, narcotic.Movement{
+				Type:    narcotic.TypeCorrection,
+				RefType: "stock_opname",
+				RefCode: opnameID,
+				Date:    adjustment.AdjustmentDate,
+				Party:   completedBy,
+				Note:    note,
+				Lines:   []narcotic.Line{line},
+			}); err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+
 		}
 	}
 
 	// Update opname status
+	data.Summarize()
+	data.CompletedBy = completedBy
 	data.Status = opname.Completed
 	data.FlagActive = false
 	data.EndTime = time.Now()
@@ -610,6 +734,9 @@ func (s *stockOpnameService) GetOpnameList(status opname.StockOpnameStatus, star
 				Adjustment_note:        d.AdjustmentNote,
 				Performed_by:           d.PerformedBy,
 				Performed_at:           d.PerformedAt,
+				SnapshotAt:             d.SnapshotAt,
+				MovementSinceSnapshot:  d.MovementSinceSnapshot,
+				FinalStock:             d.FinalStock,
 				Product: dto.ProductSimple{
 					ID:   d.Product.ID,
 					Name: d.Product.Name,
@@ -628,6 +755,7 @@ func (s *stockOpnameService) GetOpnameList(status opname.StockOpnameStatus, star
 			Status:          string(o.Status),
 			Notes:           o.Notes,
 			JenisStokOpname: string(o.Jenis),
+			MovementPolicy:  string(o.Policy),
 			FlagActive:      o.FlagActive,
 			CreatedBy:       o.CreatedBy,
 			Details:         detailResponses,
@@ -21,7 +21,6 @@ func NewTransaksiService(r repository.TransaksiRepository) TransaksiService {
 }
 
 func (s *transaksiService) CreateTransaksi(input model.Transaksi) (*model.Transaksi, error) {
-	input.NomorTransaksi = model.GenerateNomorTransaksi()
 	input.TanggalPembelian = time.Now()
 
 	err := s.repo.Create(&input)
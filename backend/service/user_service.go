@@ -9,8 +9,18 @@ import (
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
+var (
+	ErrInvalidApprover = errors.New("email atau password salah, atau akun tidak berwenang")
+	ErrApproverLocked  = errors.New("akun sedang terkunci")
+)
+
+// PharmacistRoles adalah peran yang boleh menyetujui penyerahan obat atas
+// tanggung jawab apoteker
+var PharmacistRoles = []string{"apoteker", "admin"}
+
 // HashPassword generates a bcrypt hash for the given password.
 func HashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
@@ -23,6 +33,37 @@ func VerifyPassword(password, hash string) bool {
 	return err == nil
 }
 
+// VerifyApprover memeriksa login kedua petugas yang menyetujui suatu
+// tindakan (supervisor, apoteker) dengan aturan penguncian akun yang sama
+// seperti login biasa. Akun harus aktif dan memiliki salah satu roles.
+func VerifyApprover(db *gorm.DB, email, password string, roles ...string) (*model.User, error) {
+	var user model.User
+	if err := db.Where("email = ?", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidApprover
+		}
+		return nil, err
+	}
+	if time.Now().Before(user.LockedUntil) {
+		return nil, ErrApproverLocked
+	}
+	if !VerifyPassword(password, user.Password) {
+		_ = HandleFailedLogin(&user)
+		return nil, ErrInvalidApprover
+	}
+	allowed := false
+	for _, role := range roles {
+		if user.Role == role {
+			allowed = true
+		}
+	}
+	if !user.Active || !allowed {
+		return nil, ErrInvalidApprover
+	}
+	_ = ResetFailedLoginAttempts(&user)
+	return &user, nil
+}
+
 func CreateUser(user *model.User) error {
 	hashedPassword, _ := HashPassword(user.Password)
 	user.Password = hashedPassword
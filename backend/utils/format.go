@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"math"
+	"strconv"
+)
+
+// FormatRupiah memformat nominal dengan pemisah ribuan titik tanpa desimal,
+// contoh: 1250000 -> "1.250.000", -12500 -> "-12.500"
+func FormatRupiah(v float64) string {
+	n := int64(math.Round(v))
+	sign := ""
+	if n < 0 {
+		sign = "-"
+		n = -n
+	}
+
+	digits := strconv.FormatInt(n, 10)
+	out := make([]byte, 0, len(digits)+len(digits)/3)
+	for i := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			out = append(out, '.')
+		}
+		out = append(out, digits[i])
+	}
+	return sign + string(out)
+}